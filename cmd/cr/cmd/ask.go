@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/icatw/cr-tool/pkg/history"
+	"github.com/icatw/cr-tool/pkg/review"
+	"github.com/spf13/cobra"
+)
+
+var askFiles []string
+
+var askCmd = &cobra.Command{
+	Use:   "ask <id> <question>",
+	Short: "针对评审结果继续提问",
+	Long: `以评审时的系统提示词、diff 和评审结果作为上下文继续提问，问答记录保存在评审记录中。
+使用示例：
+  cr ask 1a2b3c4d "为什么这是一个问题？"
+  cr ask 1a2b3c4d "给出修复后的代码" --file db/query.go`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		store, err := openHistory(cfg)
+		if err != nil {
+			return err
+		}
+		record, err := store.Get(args[0])
+		if err != nil {
+			return err
+		}
+
+		answer, err := askReview(review.New(), store, record, args[1], askFiles)
+		if err != nil {
+			return err
+		}
+		fmt.Println(answer)
+		return nil
+	},
+}
+
+var chatCmd = &cobra.Command{
+	Use:   "chat [id]",
+	Short: "就最近一次（或指定 ID 的）评审进行对话",
+	Long: `交互式地针对评审结果提问，问答记录保存在评审记录中，之前的问答会作为上下文。
+对话中可以使用以下命令：
+  /file <path>  在下一个问题中附加文件内容
+  /exit         退出`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		store, err := openHistory(cfg)
+		if err != nil {
+			return err
+		}
+		var record *history.Record
+		if len(args) > 0 {
+			record, err = store.Get(args[0])
+		} else {
+			record, err = store.Latest()
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("评审 %s（%d 个问题），输入 /exit 退出\n", record.ID, len(record.Findings))
+		reviewer := review.New()
+		files := append([]string(nil), askFiles...)
+		scanner := bufio.NewScanner(os.Stdin)
+		scanner.Buffer(make([]byte, 64<<10), 1<<20)
+		for {
+			fmt.Print("\n> ")
+			if !scanner.Scan() {
+				fmt.Println()
+				return scanner.Err()
+			}
+			line := strings.TrimSpace(scanner.Text())
+			switch {
+			case line == "":
+				continue
+			case line == "/exit" || line == "/quit":
+				return nil
+			case strings.HasPrefix(line, "/file "):
+				path := strings.TrimSpace(strings.TrimPrefix(line, "/file "))
+				info, err := os.Stat(path)
+				if err != nil {
+					fmt.Printf("无法读取文件: %v\n", err)
+					continue
+				}
+				if info.IsDir() {
+					fmt.Printf("%s 是目录\n", path)
+					continue
+				}
+				if info.Size() > review.MaxContextFileSize {
+					fmt.Printf("文件 %s 超过大小限制: %d > %d bytes\n", path, info.Size(), review.MaxContextFileSize)
+					continue
+				}
+				files = append(files, path)
+				fmt.Printf("已附加 %s，将随下一个问题发送\n", path)
+				continue
+			case strings.HasPrefix(line, "/"):
+				fmt.Println("未知命令，可用命令：/file <path>、/exit")
+				continue
+			}
+
+			answer, err := askReview(reviewer, store, record, line, files)
+			// 无论成功与否都清空附加的文件，避免有问题的文件导致之后的提问一直失败
+			pending := len(files)
+			files = nil
+			if err != nil {
+				fmt.Printf("提问失败: %v\n", err)
+				if pending > 0 {
+					fmt.Println("附加的文件已清空，请使用 /file 重新附加")
+				}
+				continue
+			}
+			fmt.Printf("\n%s\n", answer)
+		}
+	},
+}
+
+func init() {
+	askCmd.Flags().StringArrayVar(&askFiles, "file", nil, "附加文件内容作为上下文，可重复指定")
+	chatCmd.Flags().StringArrayVar(&askFiles, "file", nil, "在第一个问题中附加文件内容，可重复指定")
+	rootCmd.AddCommand(askCmd)
+	rootCmd.AddCommand(chatCmd)
+}
+
+// askReview 针对评审记录提问，附加文件内容，并将问答保存到评审记录
+func askReview(reviewer *review.Reviewer, store *history.Store, record *history.Record, question string, files []string) (string, error) {
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("读取文件失败: %w", err)
+		}
+		content, err := review.FileContext(path, data)
+		if err != nil {
+			return "", err
+		}
+		question += "\n\n" + content
+	}
+
+	answer, _, err := reviewer.Ask(&record.ReviewHistory, record.Diff, record.Conversation, question)
+	if err != nil {
+		return "", err
+	}
+	record.Conversation = append(record.Conversation,
+		review.Message{Role: "user", Content: question},
+		review.Message{Role: "assistant", Content: answer},
+	)
+	if err := store.Save(record); err != nil {
+		return "", err
+	}
+	return answer, nil
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/icatw/cr-tool/pkg/review"
+	"github.com/spf13/cobra"
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit <path>...",
+	Short: "审计已有的文件或目录",
+	Long: `审计完整的文件而不是 diff，适合接手已有的代码库时使用。
+遍历指定的文件和目录，跳过忽略规则命中的文件、二进制文件、依赖锁文件和生成代码，
+按 audit.token_budget 将文件分批，使用审计模板逐批评审，最后生成一份包含各文件问题和整体风险概述的报告。
+使用示例：
+  cr audit pkg/payment
+  cr audit internal cmd/server -f html`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		prepareOutput(cfg)
+
+		reviewer := review.New()
+		files, skipped, err := reviewer.Collect(args)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "共 %d 个文件待审计，跳过 %d 个文件\n", len(files), len(skipped))
+
+		history, err := reviewer.Audit(files, skipped, func(batch, total int, names []string) {
+			fmt.Fprintf(os.Stderr, "正在审计第 %d/%d 批: %s\n", batch, total, strings.Join(names, ", "))
+		})
+		if err != nil {
+			return fmt.Errorf("代码审计失败: %w", err)
+		}
+		saveHistory(cfg, history, "")
+		exportAll(cfg, os.Stdout, history)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/icatw/cr-tool/pkg/review"
+	"github.com/spf13/cobra"
+)
+
+var baselineCmd = &cobra.Command{
+	Use:   "baseline",
+	Short: "管理已知问题基线",
+	Long: `管理已知问题基线，基线中的问题不会出现在后续的评审报告中。
+基线文件默认为仓库根目录下的 .cr-baseline.json，可通过 review.baseline_file 修改，建议提交到仓库。`,
+}
+
+var baselineUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "将当前改动中的问题加入基线",
+	Long: `评审通过管道提供的 diff，并将发现的问题加入基线。
+使用示例：
+  git diff main | cr baseline update`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := loadConfig(); err != nil {
+			return err
+		}
+
+		diffContent, err := readDiff()
+		if err != nil {
+			return err
+		}
+
+		reviewer := review.New()
+		path := reviewer.BaselinePath()
+		if path == "" {
+			return fmt.Errorf("未配置基线文件 (review.baseline_file)")
+		}
+
+		history, err := reviewer.Review(diffContent)
+		if err != nil {
+			return fmt.Errorf("代码评审失败: %w", err)
+		}
+
+		baseline, err := review.LoadBaseline(path)
+		if err != nil {
+			return err
+		}
+		added := baseline.Add(history.Findings)
+		if err := baseline.Save(path); err != nil {
+			return err
+		}
+
+		fmt.Printf("新增 %d 个问题到基线，共 %d 个: %s\n", added, len(baseline.Findings), path)
+		return nil
+	},
+}
+
+func init() {
+	baselineCmd.AddCommand(baselineUpdateCmd)
+	rootCmd.AddCommand(baselineCmd)
+}
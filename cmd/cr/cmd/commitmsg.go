@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/icatw/cr-tool/pkg/review"
+	"github.com/spf13/cobra"
+)
+
+var (
+	commitLanguage string
+	commitHook     bool
+	commitInstall  bool
+	commitForce    bool
+)
+
+// commitHookScript 安装到 .git/hooks/prepare-commit-msg 的脚本
+const commitHookScript = `#!/bin/sh
+# cr commit-msg: 根据暂存区的改动生成提交信息
+exec cr commit-msg --hook "$@"
+`
+
+var commitMsgCmd = &cobra.Command{
+	Use:   "commit-msg [file [source [sha]]]",
+	Short: "根据暂存区的改动生成提交信息",
+	Long: `根据暂存区的改动（git diff --cached）生成符合 Conventional Commits 规范的提交信息，
+scope 根据变更文件的路径和 commit.scopes 规则推断。
+使用 --hook 时作为 prepare-commit-msg 钩子运行：将提交信息写入 git 传入的文件，
+已通过 -m、-F、合并或 --amend 提供提交信息时不做修改，生成失败时不会阻止提交。
+使用示例：
+  cr commit-msg                 # 输出提交信息
+  cr commit-msg --lang en       # 生成英文提交信息
+  git commit -m "$(cr commit-msg)"
+  cr commit-msg --install       # 安装 prepare-commit-msg 钩子`,
+	Args: cobra.MaximumNArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if commitInstall {
+			return installCommitHook()
+		}
+		if commitHook {
+			if len(args) == 0 {
+				return fmt.Errorf("钩子模式需要提交信息文件路径")
+			}
+			if err := prepareCommitMsg(args); err != nil {
+				// 钩子失败不阻止提交，由用户自行填写提交信息
+				fmt.Fprintf(os.Stderr, "cr: 生成提交信息失败: %v\n", err)
+			}
+			return nil
+		}
+
+		msg, err := generateCommitMsg()
+		if err != nil {
+			return err
+		}
+		fmt.Print(msg.String())
+		return nil
+	},
+}
+
+func init() {
+	commitMsgCmd.Flags().StringVar(&commitLanguage, "lang", "", "提交信息使用的语言（zh/en），默认使用 commit.language")
+	commitMsgCmd.Flags().BoolVar(&commitHook, "hook", false, "作为 prepare-commit-msg 钩子运行")
+	commitMsgCmd.Flags().BoolVar(&commitInstall, "install", false, "在当前仓库安装 prepare-commit-msg 钩子")
+	commitMsgCmd.Flags().BoolVar(&commitForce, "force", false, "安装钩子时覆盖已有的 prepare-commit-msg 钩子")
+	rootCmd.AddCommand(commitMsgCmd)
+}
+
+// generateCommitMsg 读取暂存区的改动并生成提交信息
+func generateCommitMsg() (*review.CommitMessage, error) {
+	if _, err := loadConfig(); err != nil {
+		return nil, err
+	}
+	output, err := exec.Command("git", "diff", "--cached").Output()
+	if err != nil {
+		return nil, fmt.Errorf("获取暂存区改动失败: %w", err)
+	}
+	if strings.TrimSpace(string(output)) == "" {
+		return nil, fmt.Errorf("暂存区没有改动，请先执行 git add")
+	}
+	msg, _, err := review.New().Commit(string(output), commitLanguage)
+	return msg, err
+}
+
+// scissorsLine git 在提交信息文件中分隔提交信息和 diff 的剪刀线
+const scissorsLine = "# ------------------------ >8 ------------------------"
+
+// prepareCommitMsg 钩子模式：将生成的提交信息写到提交信息文件的开头，保留 git 生成的注释
+func prepareCommitMsg(args []string) error {
+	if len(args) > 1 {
+		switch args[1] {
+		case "message", "template", "merge", "squash", "commit":
+			// 用户已经提供了提交信息
+			return nil
+		}
+	}
+
+	path := args[0]
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		// git commit -v 时剪刀线之后是 diff，不属于提交信息
+		if line == scissorsLine {
+			break
+		}
+		if strings.TrimSpace(line) != "" && !strings.HasPrefix(line, "#") {
+			return nil
+		}
+	}
+
+	msg, err := generateCommitMsg()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(msg.String()+string(data)), 0644)
+}
+
+// installCommitHook 在当前仓库安装 prepare-commit-msg 钩子
+func installCommitHook() error {
+	output, err := exec.Command("git", "rev-parse", "--git-path", "hooks").Output()
+	if err != nil {
+		return fmt.Errorf("当前目录不是 git 仓库: %w", err)
+	}
+	dir := strings.TrimSpace(string(output))
+	path := filepath.Join(dir, "prepare-commit-msg")
+
+	if data, err := os.ReadFile(path); err == nil && string(data) != commitHookScript && !commitForce {
+		return fmt.Errorf("%s 已存在，使用 --force 覆盖", path)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(commitHookScript), 0755); err != nil {
+		return err
+	}
+	fmt.Printf("已安装 prepare-commit-msg 钩子: %s\n", path)
+	return nil
+}
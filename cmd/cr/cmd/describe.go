@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/icatw/cr-tool/pkg/exporter"
+	"github.com/icatw/cr-tool/pkg/history"
+	"github.com/icatw/cr-tool/pkg/report"
+	"github.com/icatw/cr-tool/pkg/review"
+	"github.com/spf13/cobra"
+)
+
+var describeRange string
+
+var describeCmd = &cobra.Command{
+	Use:   "describe",
+	Short: "根据分支的改动生成 PR 描述",
+	Long: `根据 --range 指定范围内的提交记录和代码变更生成 PR 描述，包含概述、动机、按模块分组的改动列表、风险评估和测试说明。
+默认输出到标准输出，指定 -f markdown 或 -f html 时保存到输出目录。
+使用示例：
+  cr describe --range main..HEAD
+  cr describe --range origin/main..feature -f html`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := loadConfig(); err != nil {
+			return err
+		}
+		from, to, err := splitRange(describeRange)
+		if err != nil {
+			return err
+		}
+
+		commits, err := gitCommits(from, to)
+		if err != nil {
+			return err
+		}
+		// 三个点表示与合并基点比较，只包含分支上的改动
+		output, err := exec.Command("git", "diff", from+"..."+to).Output()
+		if err != nil {
+			return fmt.Errorf("获取代码变更失败: %w", err)
+		}
+
+		content, _, err := review.New().Describe(string(output), report.FormatCommits(commits))
+		if err != nil {
+			return err
+		}
+		return writeDocument(&exporter.Document{Title: "PR 描述", Kind: "describe", Content: content})
+	},
+}
+
+var releaseNotesCmd = &cobra.Command{
+	Use:   "release-notes <from>..<to>",
+	Short: "根据提交记录和评审历史生成发布说明",
+	Long: `汇总范围内的提交记录和评审历史，生成按不兼容改动、新功能、问题修复等分类的发布说明，并附上评审摘要。
+默认输出到标准输出，指定 -f markdown 或 -f html 时保存到输出目录。
+使用示例：
+  cr release-notes v1.2.0..v1.3.0
+  cr release-notes v1.2.0..HEAD -f html`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		from, to, err := splitRange(args[0])
+		if err != nil {
+			return err
+		}
+
+		commits, err := gitCommits(from, to)
+		if err != nil {
+			return err
+		}
+		if len(commits) == 0 {
+			return fmt.Errorf("%s 范围内没有提交", args[0])
+		}
+
+		var reviews string
+		if cfg.History.Enabled {
+			since, err := commitTime(from)
+			if err != nil {
+				return err
+			}
+			until, err := commitTime(to)
+			if err != nil {
+				return err
+			}
+			store, err := openHistory(cfg)
+			if err != nil {
+				return err
+			}
+			records, err := store.List(history.Filter{})
+			if err != nil {
+				return err
+			}
+			reviews = report.FormatReviews(report.ReleaseReviews(records, commits, since, until))
+		}
+
+		content, _, err := review.New().ReleaseNotes(report.FormatCommits(commits), reviews)
+		if err != nil {
+			return err
+		}
+		return writeDocument(&exporter.Document{Title: "发布说明 " + to, Kind: "release_notes", Content: content})
+	},
+}
+
+func init() {
+	describeCmd.Flags().StringVar(&describeRange, "range", "", "提交范围，如 main..HEAD")
+	describeCmd.MarkFlagRequired("range")
+	rootCmd.AddCommand(describeCmd)
+	rootCmd.AddCommand(releaseNotesCmd)
+}
+
+// splitRange 解析 from..to 或 from...to 形式的提交范围，省略 to 时为 HEAD
+func splitRange(s string) (string, string, error) {
+	from, to, ok := strings.Cut(s, "..")
+	to = strings.TrimPrefix(to, ".")
+	if !ok || from == "" {
+		return "", "", fmt.Errorf("无效的提交范围: %s，格式为 <from>..<to>", s)
+	}
+	if to == "" {
+		to = "HEAD"
+	}
+	return from, to, nil
+}
+
+// gitCommits 返回范围内不含合并提交的提交记录，按时间倒序
+func gitCommits(from, to string) ([]report.Commit, error) {
+	output, err := exec.Command("git", "log", "--no-merges", "--format="+report.CommitLogFormat, from+".."+to).Output()
+	if err != nil {
+		return nil, fmt.Errorf("获取提交记录失败: %w", err)
+	}
+	return report.ParseCommits(string(output)), nil
+}
+
+// commitTime 返回提交的时间
+func commitTime(rev string) (time.Time, error) {
+	output, err := exec.Command("git", "log", "-1", "--format=%cI", rev).Output()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("获取提交 %s 失败: %w", rev, err)
+	}
+	return time.Parse(time.RFC3339, strings.TrimSpace(string(output)))
+}
+
+// writeDocument 未指定 -f 时将文档输出到标准输出，否则通过对应的导出器保存
+func writeDocument(doc *exporter.Document) error {
+	if format == "" {
+		fmt.Println(doc.Content)
+		return nil
+	}
+	exp, err := exporter.NewDocumentExporter(format)
+	if err != nil {
+		return err
+	}
+	outputPath, err := exp.ExportDocument(doc)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("已保存到: %s\n", outputPath)
+	return nil
+}
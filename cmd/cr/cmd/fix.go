@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/icatw/cr-tool/pkg/diff"
+	"github.com/icatw/cr-tool/pkg/fix"
+	"github.com/icatw/cr-tool/pkg/history"
+	"github.com/icatw/cr-tool/pkg/review"
+	"github.com/icatw/cr-tool/pkg/term"
+	"github.com/spf13/cobra"
+)
+
+var (
+	fixYes      bool
+	fixVerify   string
+	fixSeverity string
+)
+
+var fixCmd = &cobra.Command{
+	Use:   "fix [id]",
+	Short: "让模型为评审问题生成补丁并应用到工作区",
+	Long: `为最近一次（或指定 ID 的）评审中的每个问题请求模型生成 unified diff 补丁，
+校验补丁可以干净地应用后显示出来，确认后写入工作区。
+设置 --verify 或 fix.verify_command 后，每个补丁应用后都会执行校验命令，失败时回滚该补丁。
+已标记为误报、不修复或已经应用过修复的问题会被跳过。
+使用示例：
+  cr fix
+  cr fix 1a2b3c4d --severity 中等 --verify "go test ./..."`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		store, err := openHistory(cfg)
+		if err != nil {
+			return err
+		}
+		var record *history.Record
+		if len(args) > 0 {
+			record, err = store.Get(args[0])
+		} else {
+			record, err = store.Latest()
+		}
+		if err != nil {
+			return err
+		}
+
+		var minSeverity review.Severity
+		if fixSeverity != "" {
+			severity, ok := review.ParseSeverity(fixSeverity)
+			if !ok {
+				return fmt.Errorf("无效的问题级别: %s", fixSeverity)
+			}
+			minSeverity = severity
+		}
+		verify := fixVerify
+		if !cmd.Flags().Changed("verify") {
+			verify = cfg.Fix.VerifyCommand
+		}
+
+		return runFix(record, func() error { return store.Save(record) }, minSeverity, verify)
+	},
+}
+
+func init() {
+	fixCmd.Flags().BoolVarP(&fixYes, "yes", "y", false, "不逐个确认，直接应用所有补丁")
+	fixCmd.Flags().StringVar(&fixVerify, "verify", "", "每个补丁应用后执行的校验命令，默认使用 fix.verify_command")
+	fixCmd.Flags().StringVar(&fixSeverity, "severity", "", "只修复达到该级别的问题（严重/中等/低）")
+	rootCmd.AddCommand(fixCmd)
+}
+
+// runFix 逐个为问题生成、确认、应用并校验补丁
+func runFix(record *history.Record, save func() error, minSeverity review.Severity, verify string) error {
+	root := review.RepoRoot()
+	reviewer := review.New()
+	files := diff.Parse(record.Diff)
+	// 已经修改过的文件行号会变化，不再与评审时的 diff 核对
+	touched := make(map[string]bool)
+	color := term.IsTerminal(os.Stdout) && os.Getenv("NO_COLOR") == ""
+	input := bufio.NewScanner(os.Stdin)
+
+	// 修改前先执行一次校验命令，已经失败时无法判断补丁是否引入了问题
+	if verify != "" {
+		fmt.Printf("正在执行校验命令: %s\n", verify)
+		if out, err := fix.Verify(root, verify); err != nil {
+			fmt.Printf("%s\n", tail(out, 20))
+			return fmt.Errorf("修改前校验命令已经失败，请先修复或更换校验命令: %w", err)
+		}
+	}
+
+	var applied, rolledBack, skipped int
+	for i, f := range record.Findings {
+		fmt.Printf("\n[%d/%d] %s %s %s\n", i+1, len(record.Findings), f.Severity, f.Location(), f.Title)
+		if reason := skipReason(record, f, minSeverity); reason != "" {
+			fmt.Printf("跳过：%s\n", reason)
+			skipped++
+			continue
+		}
+
+		var reviewed *diff.File
+		if !touched[f.File] {
+			for _, file := range files {
+				if file.Name() == f.File {
+					reviewed = file
+				}
+			}
+		}
+		fmt.Println("正在生成补丁...")
+		patch, err := fix.Generate(reviewer, root, f, reviewed)
+		if err != nil {
+			fmt.Printf("跳过：%v\n", err)
+			skipped++
+			continue
+		}
+		fmt.Println(colorizePatch(patch.Diff, color))
+
+		if !fixYes {
+			fmt.Print("应用该补丁？[y/N/q] ")
+			if !input.Scan() {
+				fmt.Println()
+				break
+			}
+			answer := strings.ToLower(strings.TrimSpace(input.Text()))
+			if answer == "q" {
+				break
+			}
+			if answer != "y" && answer != "yes" {
+				skipped++
+				continue
+			}
+		}
+
+		rollback, err := patch.Apply()
+		if err != nil {
+			fmt.Printf("应用补丁失败: %v\n", err)
+			skipped++
+			continue
+		}
+		if verify != "" {
+			fmt.Printf("正在执行校验命令: %s\n", verify)
+			if out, err := fix.Verify(root, verify); err != nil {
+				fmt.Printf("%s\n%s\n", tail(out, 20), err)
+				if err := rollback(); err != nil {
+					return fmt.Errorf("回滚补丁失败: %w", err)
+				}
+				fmt.Println("已回滚该补丁")
+				rolledBack++
+				continue
+			}
+		}
+
+		for _, name := range patch.Files() {
+			touched[name] = true
+		}
+		record.Decide(f, history.StatusAccepted, true)
+		if err := save(); err != nil {
+			return err
+		}
+		fmt.Printf("已修改 %s\n", strings.Join(patch.Files(), ", "))
+		applied++
+	}
+
+	fmt.Printf("\n已应用 %d 个补丁", applied)
+	if rolledBack > 0 {
+		fmt.Printf("，%d 个未通过校验已回滚", rolledBack)
+	}
+	fmt.Printf("，跳过 %d 个问题\n", skipped)
+	return nil
+}
+
+// skipReason 返回不需要修复该问题的原因
+func skipReason(record *history.Record, f review.Finding, minSeverity review.Severity) string {
+	if f.File == "" {
+		return "该问题没有对应的文件"
+	}
+	if minSeverity != "" && f.Severity.Rank() < minSeverity.Rank() {
+		return "低于指定的问题级别"
+	}
+	if d, ok := record.Decision(f); ok {
+		if d.Applied {
+			return "修复已应用过"
+		}
+		if d.Status != history.StatusAccepted {
+			return "已标记为" + d.Status.Label()
+		}
+	}
+	return ""
+}
+
+// colorizePatch 为补丁的新增和删除行添加颜色
+func colorizePatch(patch string, color bool) string {
+	patch = strings.TrimRight(patch, "\n")
+	if !color {
+		return patch
+	}
+	lines := strings.Split(patch, "\n")
+	for i, l := range lines {
+		switch {
+		case strings.HasPrefix(l, "+++ "), strings.HasPrefix(l, "--- "):
+			lines[i] = "\x1b[1m" + l + "\x1b[0m"
+		case strings.HasPrefix(l, "@@"):
+			lines[i] = "\x1b[36m" + l + "\x1b[0m"
+		case strings.HasPrefix(l, "+"):
+			lines[i] = "\x1b[32m" + l + "\x1b[0m"
+		case strings.HasPrefix(l, "-"):
+			lines[i] = "\x1b[31m" + l + "\x1b[0m"
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// tail 返回输出的最后 n 行
+func tail(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+var githubCmd = &cobra.Command{
+	Use:   "github",
+	Short: "GitHub 集成",
+}
+
+var githubReviewCmd = &cobra.Command{
+	Use:   "review",
+	Short: "评审 GitHub PR 并发布评论",
+	Long: `等同于 cr pr review --forge github。
+通过 GitHub API 获取 PR 的 diff 进行评审，在问题所在行发布行内评论，并发布总结评论和提交状态。
+重新评审时会更新之前的评论，已修复的问题会被标记，不会重复发布。
+在 GitHub Actions 中运行时，仓库和 PR 编号可以从环境变量中获取。
+使用示例：
+  cr github review --pr 42 --repo icatw/cr-tool`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runPRReview(cmd.Context(), "github")
+	},
+}
+
+func init() {
+	githubReviewCmd.Flags().IntVar(&prNumber, "pr", 0, "PR 编号")
+	githubReviewCmd.Flags().StringVar(&prRepo, "repo", "", "仓库 (owner/name)")
+	githubCmd.AddCommand(githubReviewCmd)
+	rootCmd.AddCommand(githubCmd)
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+var gitlabCmd = &cobra.Command{
+	Use:   "gitlab",
+	Short: "GitLab 集成",
+}
+
+var gitlabReviewCmd = &cobra.Command{
+	Use:   "review",
+	Short: "评审 GitLab MR 并发布讨论",
+	Long: `等同于 cr pr review --forge gitlab，MR 编号和项目使用 --mr 和 --project 指定。
+通过 GitLab API 获取 MR 的变更进行评审，在问题所在行发起讨论，并发布总结评论和提交状态。
+重新评审时会更新之前的讨论，已修复问题的讨论会被标记为已解决。
+在 GitLab CI 的合并请求流水线中运行时，项目、MR 编号和 API 地址可以从 CI_* 环境变量中获取。
+使用示例：
+  cr gitlab review --mr 17 --project group/app`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runPRReview(cmd.Context(), "gitlab")
+	},
+}
+
+func init() {
+	gitlabReviewCmd.Flags().IntVar(&prNumber, "mr", 0, "MR 编号 (iid)")
+	gitlabReviewCmd.Flags().StringVar(&prRepo, "project", "", "项目路径或 ID")
+	gitlabCmd.AddCommand(gitlabReviewCmd)
+	rootCmd.AddCommand(gitlabCmd)
+}
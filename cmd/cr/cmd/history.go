@@ -0,0 +1,308 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/icatw/cr-tool/pkg/config"
+	"github.com/icatw/cr-tool/pkg/history"
+	"github.com/icatw/cr-tool/pkg/review"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyBranch    string
+	historyAuthor    string
+	historySince     string
+	historyUntil     string
+	historySeverity  string
+	historyLimit     int
+	historyOlderThan string
+	historyKeep      int
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "查看和管理评审历史",
+	Long: `查看和管理评审历史，每次评审的结果、Git 信息、模型和 token 用量都会保存在 history.dir 中。
+使用示例：
+  cr history list --branch main --since 7d     # 查看 main 分支最近 7 天的评审
+  cr history show 1a2b3c4d                     # 查看评审详情
+  cr history export 1a2b3c4d -f html           # 重新导出评审报告
+  cr history prune --older-than 90d            # 清理 90 天前的记录`,
+}
+
+var historyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出评审记录",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		store, err := openHistory(cfg)
+		if err != nil {
+			return err
+		}
+
+		filter, err := historyFilter()
+		if err != nil {
+			return err
+		}
+		records, err := store.List(filter)
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			fmt.Println("没有评审记录")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\t时间\t分支\t作者\t严重/中等/低\t模型\t模板")
+		for _, r := range records {
+			counts := make(map[review.Severity]int)
+			for _, f := range r.Findings {
+				counts[f.Severity]++
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d/%d/%d\t%s\t%s\n",
+				r.ID, r.DateTime.Format("2006-01-02 15:04"), r.Branch(), r.Author(),
+				counts[review.SeverityHigh], counts[review.SeverityMedium], counts[review.SeverityLow],
+				r.Model, r.Template)
+		}
+		return w.Flush()
+	},
+}
+
+var historyShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "查看评审详情",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		store, err := openHistory(cfg)
+		if err != nil {
+			return err
+		}
+		r, err := store.Get(args[0])
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("ID:       %s\n", r.ID)
+		fmt.Printf("时间:     %s\n", r.DateTime.Format("2006-01-02 15:04:05"))
+		if r.GitInfo != nil {
+			fmt.Printf("分支:     %s\n", r.GitInfo.Branch)
+			fmt.Printf("提交:     %s %s\n", r.GitInfo.CommitHash, r.GitInfo.CommitMessage)
+			fmt.Printf("作者:     %s\n", r.GitInfo.Author)
+		}
+		fmt.Printf("模型:     %s\n", r.Model)
+		fmt.Printf("模板:     %s\n", r.Template)
+		if r.Usage != nil {
+			fmt.Printf("Token:    %d (输入 %d / 输出 %d)\n", r.Usage.TotalTokens, r.Usage.PromptTokens, r.Usage.CompletionTokens)
+		}
+
+		if len(r.Findings) > 0 {
+			fmt.Printf("\n问题 (%d):\n", len(r.Findings))
+			for _, f := range r.Findings {
+				status := ""
+				if d, ok := r.Decision(f); ok {
+					status = " - " + d.Status.Label()
+				}
+				fmt.Printf("  [%s] %s %s (%s)%s\n", f.Severity, f.Location(), f.Title, f.Category, status)
+			}
+		}
+		if r.Suppressed > 0 {
+			fmt.Printf("已忽略 %d 个已知问题\n", r.Suppressed)
+		}
+
+		fmt.Printf("\n%s\n", r.ReviewResult)
+
+		for _, m := range r.Conversation {
+			if m.Role == "user" {
+				fmt.Printf("\n> %s\n", strings.ReplaceAll(m.Content, "\n", "\n> "))
+			} else {
+				fmt.Printf("\n%s\n", m.Content)
+			}
+		}
+		return nil
+	},
+}
+
+var historyExportCmd = &cobra.Command{
+	Use:   "export <id>",
+	Short: "导出评审记录",
+	Long:  `使用配置的导出格式重新导出评审报告，-f json 时将完整记录以 JSON 输出到标准输出。`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		store, err := openHistory(cfg)
+		if err != nil {
+			return err
+		}
+		r, err := store.Get(args[0])
+		if err != nil {
+			return err
+		}
+
+		for _, format := range cfg.Output.Format {
+			if format == "json" {
+				data, err := json.MarshalIndent(r, "", "  ")
+				if err != nil {
+					return fmt.Errorf("序列化评审记录失败: %w", err)
+				}
+				fmt.Println(string(data))
+				continue
+			}
+
+			if _, err := export(os.Stdout, format, &r.ReviewHistory); err != nil {
+				log.Printf("导出失败 (%s): %v", format, err)
+			}
+		}
+		return nil
+	},
+}
+
+var historyPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "清理评审记录",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if historyOlderThan == "" && historyKeep <= 0 {
+			return fmt.Errorf("请指定 --older-than 或 --keep")
+		}
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		store, err := openHistory(cfg)
+		if err != nil {
+			return err
+		}
+
+		var before time.Time
+		if historyOlderThan != "" {
+			age, err := parseAge(historyOlderThan)
+			if err != nil {
+				return err
+			}
+			before = time.Now().Add(-age)
+		}
+
+		removed, err := store.Prune(before, historyKeep)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("已删除 %d 条评审记录\n", removed)
+		return nil
+	},
+}
+
+func init() {
+	historyListCmd.Flags().StringVar(&historyBranch, "branch", "", "按分支过滤")
+	historyListCmd.Flags().StringVar(&historyAuthor, "author", "", "按作者过滤（包含匹配）")
+	historyListCmd.Flags().StringVar(&historySince, "since", "", "起始时间，如 2024-01-02 或 7d")
+	historyListCmd.Flags().StringVar(&historyUntil, "until", "", "结束时间，如 2024-01-31 或 1d")
+	historyListCmd.Flags().StringVar(&historySeverity, "severity", "", "最低问题级别(严重/中等/低)")
+	historyListCmd.Flags().IntVar(&historyLimit, "limit", 20, "最多显示的记录数，0 表示不限制")
+	historyPruneCmd.Flags().StringVar(&historyOlderThan, "older-than", "", "删除早于该时长的记录，如 90d")
+	historyPruneCmd.Flags().IntVar(&historyKeep, "keep", 0, "最多保留的记录数")
+
+	historyCmd.AddCommand(historyListCmd, historyShowCmd, historyExportCmd, historyPruneCmd)
+	rootCmd.AddCommand(historyCmd)
+}
+
+// openHistory 打开评审历史存储
+func openHistory(cfg *config.Config) (*history.Store, error) {
+	return history.Open(cfg.History.Dir)
+}
+
+// saveHistory 保存评审记录，未启用历史记录时直接返回
+func saveHistory(cfg *config.Config, h *review.ReviewHistory, diffContent string) {
+	if !cfg.History.Enabled {
+		return
+	}
+	store, err := openHistory(cfg)
+	if err == nil {
+		err = store.Save(history.NewRecord(h, diffContent))
+	}
+	if err != nil {
+		log.Printf("保存评审记录失败: %v", err)
+	}
+}
+
+// historyFilter 根据命令行参数构造查询条件
+func historyFilter() (history.Filter, error) {
+	filter := history.Filter{
+		Branch: historyBranch,
+		Author: historyAuthor,
+		Limit:  historyLimit,
+	}
+
+	var err error
+	if filter.Since, err = parseTime(historySince); err != nil {
+		return filter, err
+	}
+	if filter.Until, err = parseUntil(historyUntil); err != nil {
+		return filter, err
+	}
+	if historySeverity != "" {
+		severity, ok := review.ParseSeverity(historySeverity)
+		if !ok {
+			return filter, fmt.Errorf("无效的问题级别: %s", historySeverity)
+		}
+		filter.MinSeverity = severity
+	}
+	return filter, nil
+}
+
+// parseTime 解析日期（2006-01-02）或相对时长（7d、12h）
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	age, err := parseAge(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("无效的时间: %s", s)
+	}
+	return time.Now().Add(-age), nil
+}
+
+// parseUntil 解析结束时间，只给出日期时表示当天结束，使 --until 包含当天的记录
+func parseUntil(s string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+	return parseTime(s)
+}
+
+// parseAge 解析时长，在 time.ParseDuration 的基础上支持以 d 为单位的天数
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("无效的时长: %s", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("无效的时长: %s", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"os"
+	"time"
+
+	"github.com/icatw/cr-tool/pkg/lsp"
+	"github.com/icatw/cr-tool/pkg/review"
+	"github.com/spf13/cobra"
+)
+
+var lspDebounce time.Duration
+
+var lspCmd = &cobra.Command{
+	Use:   "lsp",
+	Short: "以 Language Server 的形式在编辑器中评审改动",
+	Long: `通过标准输入输出运行 Language Server Protocol 服务。
+保存文件时评审该文件未提交的改动，评审问题作为诊断信息显示在编辑器中，
+模型给出修复代码时可以通过快速修复（Code Action）直接应用。
+评审结果按 diff 内容缓存，改动未变化时不会重复调用模型。`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := loadConfig(); err != nil {
+			return err
+		}
+
+		// 标准输出只用于协议消息，评审过程中的提示通过 log 输出到标准错误
+		reviewer := review.New()
+		s := lsp.NewServer(lsp.Options{
+			Review:   reviewer.Review,
+			Diff:     lsp.GitDiff,
+			Debounce: lspDebounce,
+		})
+		return s.Serve(cmd.Context(), os.Stdin, os.Stdout)
+	},
+}
+
+func init() {
+	lspCmd.Flags().DurationVar(&lspDebounce, "debounce", 500*time.Millisecond, "保存后等待多久开始评审")
+	rootCmd.AddCommand(lspCmd)
+}
@@ -0,0 +1,252 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/icatw/cr-tool/pkg/config"
+	"github.com/icatw/cr-tool/pkg/exporter"
+	"github.com/icatw/cr-tool/pkg/history"
+	"github.com/icatw/cr-tool/pkg/mcp"
+	"github.com/icatw/cr-tool/pkg/review"
+	"github.com/spf13/cobra"
+)
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "以 MCP 服务的形式为 AI 编码助手提供评审工具",
+	Long: `通过标准输入输出运行 Model Context Protocol 服务，支持 MCP 的 AI 编码助手可以调用以下工具：
+  review_diff    评审给定的 diff
+  review_staged  评审当前仓库暂存区的改动
+  list_history   查询评审历史
+  get_report     获取评审报告
+在助手的 MCP 配置中添加：
+  {"mcpServers": {"cr": {"command": "cr", "args": ["mcp", "-c", "/path/to/config.json"]}}}`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		// 标准输出只用于协议消息，评审过程中的提示通过 log 输出到标准错误
+		return newMCPServer(cfg).Serve(cmd.Context(), os.Stdin, os.Stdout)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mcpCmd)
+}
+
+// newMCPServer 创建 MCP 服务并注册评审工具
+func newMCPServer(cfg *config.Config) *mcp.Server {
+	s := mcp.NewServer("cr-tool", "1.0")
+	s.AddTool(mcp.Tool{
+		Name:        "review_diff",
+		Description: "按团队的评审规范评审 unified diff，返回评审意见和结构化的问题列表",
+		InputSchema: objectSchema(map[string]interface{}{
+			"diff": map[string]string{"type": "string", "description": "git diff 格式的改动"},
+		}, "diff"),
+		Handler: func(ctx context.Context, args json.RawMessage) (*mcp.ToolResult, error) {
+			var params struct {
+				Diff string `json:"diff"`
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return nil, fmt.Errorf("无效的参数: %w", err)
+			}
+			return reviewTool(cfg, params.Diff)
+		},
+	})
+	s.AddTool(mcp.Tool{
+		Name:        "review_staged",
+		Description: "评审当前仓库暂存区的改动（git diff --cached），返回评审意见和结构化的问题列表",
+		InputSchema: objectSchema(map[string]interface{}{}),
+		Handler: func(ctx context.Context, args json.RawMessage) (*mcp.ToolResult, error) {
+			output, err := exec.CommandContext(ctx, "git", "diff", "--cached").Output()
+			if err != nil {
+				return nil, fmt.Errorf("获取暂存区改动失败: %w", err)
+			}
+			if strings.TrimSpace(string(output)) == "" {
+				return nil, fmt.Errorf("暂存区没有改动")
+			}
+			return reviewTool(cfg, string(output))
+		},
+	})
+	s.AddTool(mcp.Tool{
+		Name:        "list_history",
+		Description: "按分支、作者、时间和问题级别查询评审历史",
+		InputSchema: objectSchema(map[string]interface{}{
+			"branch":       map[string]string{"type": "string"},
+			"author":       map[string]string{"type": "string"},
+			"since":        map[string]string{"type": "string", "description": "起始时间，如 2024-01-02 或 7d"},
+			"min_severity": map[string]string{"type": "string", "description": "严重、中等或低"},
+			"limit":        map[string]string{"type": "integer", "description": "最多返回的记录数，默认 20"},
+		}),
+		Handler: func(ctx context.Context, args json.RawMessage) (*mcp.ToolResult, error) {
+			return listHistoryTool(cfg, args)
+		},
+	})
+	s.AddTool(mcp.Tool{
+		Name:        "get_report",
+		Description: "获取一次评审的完整报告，format 为 markdown（默认）、html 或 json",
+		InputSchema: objectSchema(map[string]interface{}{
+			"id":     map[string]string{"type": "string", "description": "评审 ID 或 ID 前缀"},
+			"format": map[string]interface{}{"type": "string", "enum": []string{"markdown", "html", "json"}},
+		}, "id"),
+		Handler: func(ctx context.Context, args json.RawMessage) (*mcp.ToolResult, error) {
+			var params struct {
+				ID     string `json:"id"`
+				Format string `json:"format"`
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return nil, fmt.Errorf("无效的参数: %w", err)
+			}
+			return reportTool(cfg, params.ID, params.Format)
+		},
+	})
+	return s
+}
+
+// objectSchema 工具参数的 JSON Schema
+func objectSchema(properties map[string]interface{}, required ...string) map[string]interface{} {
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// reviewTool 评审 diff，返回评审内容和结构化的问题
+func reviewTool(cfg *config.Config, diffContent string) (*mcp.ToolResult, error) {
+	h, err := review.New().Review(diffContent)
+	if errors.Is(err, review.ErrEmptyDiff) {
+		return mcp.TextResult("没有需要评审的文件", map[string]interface{}{"findings": []review.Finding{}}), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("代码评审失败: %w", err)
+	}
+	saveHistory(cfg, h, diffContent)
+
+	counts := severityCounts(h.Findings)
+	findings := h.Findings
+	if findings == nil {
+		findings = []review.Finding{}
+	}
+	text := fmt.Sprintf("评审 ID: %s\n发现 %d 个问题（严重 %d · 中等 %d · 低 %d）\n\n%s",
+		h.ID, len(h.Findings), counts[review.SeverityHigh], counts[review.SeverityMedium], counts[review.SeverityLow], h.ReviewResult)
+	return mcp.TextResult(text, map[string]interface{}{
+		"id":         h.ID,
+		"findings":   findings,
+		"counts":     counts,
+		"suppressed": h.Suppressed,
+	}), nil
+}
+
+// listHistoryTool 查询评审历史
+func listHistoryTool(cfg *config.Config, args json.RawMessage) (*mcp.ToolResult, error) {
+	var params struct {
+		Branch      string `json:"branch"`
+		Author      string `json:"author"`
+		Since       string `json:"since"`
+		MinSeverity string `json:"min_severity"`
+		Limit       int    `json:"limit"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("无效的参数: %w", err)
+	}
+	filter := history.Filter{Branch: params.Branch, Author: params.Author, Limit: params.Limit}
+	if filter.Limit <= 0 {
+		filter.Limit = 20
+	}
+	var err error
+	if filter.Since, err = parseTime(params.Since); err != nil {
+		return nil, err
+	}
+	if params.MinSeverity != "" {
+		severity, ok := review.ParseSeverity(params.MinSeverity)
+		if !ok {
+			return nil, fmt.Errorf("无效的问题级别: %s", params.MinSeverity)
+		}
+		filter.MinSeverity = severity
+	}
+
+	store, err := openHistory(cfg)
+	if err != nil {
+		return nil, err
+	}
+	records, err := store.List(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	type item struct {
+		ID       string                  `json:"id"`
+		DateTime string                  `json:"datetime"`
+		Branch   string                  `json:"branch,omitempty"`
+		Author   string                  `json:"author,omitempty"`
+		Counts   map[review.Severity]int `json:"counts"`
+	}
+	items := make([]item, 0, len(records))
+	var b strings.Builder
+	for _, r := range records {
+		it := item{
+			ID:       r.ID,
+			DateTime: r.DateTime.Format("2006-01-02 15:04"),
+			Branch:   r.Branch(),
+			Author:   r.Author(),
+			Counts:   severityCounts(r.Findings),
+		}
+		items = append(items, it)
+		b.WriteString(fmt.Sprintf("%s  %s  %s  %s  严重 %d · 中等 %d · 低 %d\n", it.ID, it.DateTime, it.Branch, it.Author,
+			it.Counts[review.SeverityHigh], it.Counts[review.SeverityMedium], it.Counts[review.SeverityLow]))
+	}
+	if len(items) == 0 {
+		b.WriteString("没有评审记录")
+	}
+	return mcp.TextResult(b.String(), map[string]interface{}{"reviews": items}), nil
+}
+
+// reportTool 获取评审报告
+func reportTool(cfg *config.Config, id, format string) (*mcp.ToolResult, error) {
+	store, err := openHistory(cfg)
+	if err != nil {
+		return nil, err
+	}
+	r, err := store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(r.ReviewHistory, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("序列化评审记录失败: %w", err)
+		}
+		return mcp.TextResult(string(data), nil), nil
+	case "", "markdown", "html":
+		if format == "" {
+			format = "markdown"
+		}
+		data, err := exporter.Render(format, &r.ReviewHistory)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.TextResult(string(data), nil), nil
+	default:
+		return nil, fmt.Errorf("不支持的报告格式: %s", format)
+	}
+}
+
+// severityCounts 统计各级别的问题数
+func severityCounts(findings []review.Finding) map[review.Severity]int {
+	counts := map[review.Severity]int{review.SeverityHigh: 0, review.SeverityMedium: 0, review.SeverityLow: 0}
+	for _, f := range findings {
+		counts[f.Severity]++
+	}
+	return counts
+}
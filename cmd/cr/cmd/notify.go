@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/icatw/cr-tool/pkg/notify"
+	"github.com/spf13/cobra"
+)
+
+var notifyCmd = &cobra.Command{
+	Use:   "notify",
+	Short: "管理评审通知",
+}
+
+var notifyDigestCmd = &cobra.Command{
+	Use:   "digest",
+	Short: "发送待发送的评审摘要",
+	Long: `将命中 digest 规则的评审按渠道汇总为一条消息发送，发送成功后清空摘要队列。
+建议通过定时任务每天执行一次，例如：
+  0 18 * * 1-5 cd /path/to/repo && cr notify digest`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		router, err := notify.NewRouter(cfg)
+		if err != nil {
+			return err
+		}
+
+		sent, err := router.SendDigest(cmd.Context())
+		if sent > 0 {
+			fmt.Printf("已发送 %d 条摘要消息\n", sent)
+		} else if err == nil {
+			fmt.Println("没有待发送的摘要")
+		}
+		return err
+	},
+}
+
+func init() {
+	notifyCmd.AddCommand(notifyDigestCmd)
+	rootCmd.AddCommand(notifyCmd)
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/icatw/cr-tool/pkg/config"
+	"github.com/icatw/cr-tool/pkg/forge"
+	"github.com/icatw/cr-tool/pkg/review"
+	"github.com/spf13/cobra"
+)
+
+var (
+	prForge  string
+	prNumber int
+	prRepo   string
+)
+
+var prCmd = &cobra.Command{
+	Use:   "pr",
+	Short: "PR/MR 评审，支持 GitHub、GitLab、Gitee 和 Gitea",
+}
+
+var prReviewCmd = &cobra.Command{
+	Use:   "review",
+	Short: "评审 PR/MR 并发布评论",
+	Long: `通过平台 API 获取 PR/MR 的 diff 进行评审，发布行内评论、总结评论和提交状态。
+所有平台使用相同的参数，--forge 未指定时根据 CI 环境变量判断平台
+（GitHub Actions、GitLab CI、Gitea Actions）。
+Gitee 没有提交状态接口，评审结论只体现在总结评论中。
+使用示例：
+  cr pr review --forge gitee --pr 12 --repo icatw/cr-tool
+  cr pr review --forge gitea --pr 5 --repo team/app`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := prForge
+		if name == "" {
+			name = forge.Detect()
+		}
+		if name == "" {
+			return fmt.Errorf("请通过 --forge 指定平台: %s", strings.Join(forge.Names, "/"))
+		}
+		return runPRReview(cmd.Context(), strings.ToLower(name))
+	},
+}
+
+func init() {
+	prReviewCmd.Flags().StringVar(&prForge, "forge", "", "平台: "+strings.Join(forge.Names, "/"))
+	prReviewCmd.Flags().IntVar(&prNumber, "pr", 0, "PR/MR 编号")
+	prReviewCmd.Flags().StringVar(&prRepo, "repo", "", "仓库 (owner/name)，GitLab 为项目路径或 ID")
+	prCmd.AddCommand(prReviewCmd)
+	rootCmd.AddCommand(prCmd)
+}
+
+// runPRReview 使用 --pr 和 --repo 指定的 PR/MR 在平台上运行评审，
+// cr github review 和 cr gitlab review 也通过它运行
+func runPRReview(ctx context.Context, name string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if fc := forge.ConfigFor(cfg, name); fc != nil && prRepo != "" {
+		fc.Repo = prRepo
+	}
+	number := prNumber
+	if number == 0 {
+		number = forge.NumberFromEnv()
+	}
+	if number <= 0 {
+		return fmt.Errorf("请指定 PR/MR 编号")
+	}
+
+	f, err := forge.New(name, cfg)
+	if err != nil {
+		return err
+	}
+	return reviewPullRequest(ctx, cfg, f, number)
+}
+
+// reviewPullRequest 获取 PR/MR 的 diff 进行评审，并将结果发布到平台
+func reviewPullRequest(ctx context.Context, cfg *config.Config, f forge.Forge, number int) error {
+	pr, result, err := forge.ReviewPullRequest(ctx, f, number, pullRequestReviewer(cfg))
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		fmt.Println("没有需要评审的文件")
+		return nil
+	}
+	fmt.Printf("已发布到 %s：新增 %d 条评论，更新 %d 条，%d 个问题已修复，状态 %s\n",
+		pr.URL, result.Created, result.Updated, result.Resolved, result.State)
+	return nil
+}
+
+// pullRequestReviewer 评审 PR/MR 的 diff 并保存评审记录
+func pullRequestReviewer(cfg *config.Config) forge.ReviewFunc {
+	return func(pr *forge.PullRequest, diffContent string) (*review.ReviewHistory, error) {
+		history, err := review.NewRemote().Review(diffContent)
+		if err != nil {
+			return nil, err
+		}
+		history.GitInfo = pr.GitInfo(diffContent)
+		saveHistory(cfg, history, diffContent)
+		return history, nil
+	}
+}
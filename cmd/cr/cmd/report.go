@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/icatw/cr-tool/pkg/history"
+	"github.com/icatw/cr-tool/pkg/report"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reportBranch string
+	reportAuthor string
+	reportSince  string
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "基于评审历史生成汇总报告",
+}
+
+var reportTrendsCmd = &cobra.Command{
+	Use:   "trends",
+	Short: "生成评审趋势看板",
+	Long: `汇总评审历史，生成包含各级别问题趋势、高频问题类别、作者与目录的变更/问题对比以及问题平均消失时间的 HTML 看板。
+使用示例：
+  cr report trends --since 90d
+  cr report trends --branch main -o ./reports`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		store, err := openHistory(cfg)
+		if err != nil {
+			return err
+		}
+
+		since, err := parseTime(reportSince)
+		if err != nil {
+			return err
+		}
+		records, err := store.List(history.Filter{
+			Branch: reportBranch,
+			Author: reportAuthor,
+			Since:  since,
+		})
+		if err != nil {
+			return err
+		}
+
+		outputPath, err := report.ExportTrendsHTML(report.Aggregate(records), cfg.Output.Dir)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("趋势报告已保存到: %s\n", outputPath)
+		return nil
+	},
+}
+
+func init() {
+	reportTrendsCmd.Flags().StringVar(&reportBranch, "branch", "", "按分支过滤")
+	reportTrendsCmd.Flags().StringVar(&reportAuthor, "author", "", "按作者过滤（包含匹配）")
+	reportTrendsCmd.Flags().StringVar(&reportSince, "since", "", "起始时间，如 2024-01-02 或 30d")
+
+	reportCmd.AddCommand(reportTrendsCmd)
+	rootCmd.AddCommand(reportCmd)
+}
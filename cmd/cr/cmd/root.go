@@ -5,10 +5,15 @@ import (
 	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/icatw/cr-tool/pkg/config"
 	"github.com/icatw/cr-tool/pkg/exporter"
+	"github.com/icatw/cr-tool/pkg/notify"
 	"github.com/icatw/cr-tool/pkg/review"
+	"github.com/icatw/cr-tool/pkg/term"
 	"github.com/spf13/cobra"
 )
 
@@ -28,54 +33,43 @@ var rootCmd = &cobra.Command{
   cr -o ./reports -f html        # 指定输出目录和格式`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// 加载配置
-		if err := config.Init(); err != nil {
-			return fmt.Errorf("加载配置失败: %w", err)
-		}
-
-		// 覆盖配置选项
-		cfg := config.Get()
-		if outputDir != "" {
-			cfg.Output.Dir = outputDir
-		}
-		if format != "" {
-			cfg.Output.Format = []string{format}
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
 		}
 
 		// 读取 diff 内容
-		var diffContent string
-		if stat, _ := os.Stdin.Stat(); (stat.Mode() & os.ModeCharDevice) == 0 {
-			// 从管道读取
-			data, err := io.ReadAll(os.Stdin)
-			if err != nil {
-				return fmt.Errorf("读取输入失败: %w", err)
-			}
-			diffContent = string(data)
-		} else {
-			return fmt.Errorf("请通过管道提供 git diff 内容")
+		diffContent, err := readDiff()
+		if err != nil {
+			return err
 		}
 
+		prepareOutput(cfg)
+
 		// 执行评审
 		reviewer := review.New()
 		history, err := reviewer.Review(diffContent)
 		if err != nil {
 			return fmt.Errorf("代码评审失败: %w", err)
 		}
+		saveHistory(cfg, history, diffContent)
 
 		// 导出结果
-		for _, format := range cfg.Output.Format {
-			exp, err := exporter.New(format)
-			if err != nil {
-				log.Printf("创建导出器失败 (%s): %v", format, err)
-				continue
-			}
+		reports := exportAll(cfg, os.Stdout, history)
 
-			outputPath, err := exp.Export(history)
-			if err != nil {
-				log.Printf("导出失败 (%s): %v", format, err)
-				continue
+		// 发送通知
+		router, err := notify.NewRouter(cfg)
+		if err != nil {
+			log.Printf("通知配置无效: %v", err)
+		} else if !router.Empty() {
+			n := &notify.Notification{
+				History:   history,
+				ReportURL: reportURL(cfg, reports),
+				Reports:   reports,
+			}
+			if err := router.Route(cmd.Context(), n); err != nil {
+				log.Printf("发送通知失败: %v", err)
 			}
-
-			fmt.Printf("评审报告已保存到: %s\n", outputPath)
 		}
 
 		return nil
@@ -85,9 +79,101 @@ var rootCmd = &cobra.Command{
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "config.json", "配置文件路径")
 	rootCmd.PersistentFlags().StringVarP(&outputDir, "output", "o", "", "输出目录")
-	rootCmd.PersistentFlags().StringVarP(&format, "format", "f", "", "输出格式(markdown/html/pdf)")
+	rootCmd.PersistentFlags().StringVarP(&format, "format", "f", "", "输出格式(terminal/markdown/html/pdf/junit/github/gitlab/rdjson/checkstyle/errorformat)")
+}
 
+// loadConfig 加载配置并应用命令行覆盖选项
+func loadConfig() (*config.Config, error) {
 	config.SetConfigFile(configFile)
+	if err := config.Init(); err != nil {
+		return nil, fmt.Errorf("加载配置失败: %w", err)
+	}
+
+	cfg := config.Get()
+	if outputDir != "" {
+		cfg.Output.Dir = outputDir
+	}
+	if format != "" {
+		cfg.Output.Format = []string{format}
+	}
+	return cfg, nil
+}
+
+// prepareOutput 在终端中运行且未指定格式时，同时在终端中显示评审结果
+func prepareOutput(cfg *config.Config) {
+	if format == "" && term.IsTerminal(os.Stdout) && !slices.Contains(cfg.Output.Format, string(exporter.FormatTerminal)) {
+		cfg.Output.Format = append([]string{string(exporter.FormatTerminal)}, cfg.Output.Format...)
+	}
+}
+
+// exportAll 按配置的所有格式导出评审结果，返回保存的报告路径
+func exportAll(cfg *config.Config, out *os.File, history *review.ReviewHistory) []string {
+	var reports []string
+	for _, format := range cfg.Output.Format {
+		outputPath, err := export(out, format, history)
+		if err != nil {
+			log.Printf("导出失败 (%s): %v", format, err)
+			continue
+		}
+		if outputPath != "" {
+			reports = append(reports, outputPath)
+		}
+	}
+	return reports
+}
+
+// export 导出评审结果并返回报告路径，注解格式和终端格式直接输出到 out，返回空路径；
+// 其他提示输出到标准错误，避免混入注解内容
+func export(out *os.File, format string, history *review.ReviewHistory) (string, error) {
+	exp, err := exporter.New(format)
+	if err != nil {
+		return "", err
+	}
+	if r, ok := exp.(exporter.Renderer); ok && exporter.Format(format).Streamed() {
+		if t, ok := exp.(*exporter.TerminalExporter); ok {
+			t.Color = t.Color && term.IsTerminal(out)
+			t.Width, _ = term.Size()
+		}
+		data, err := r.Render(history)
+		if err != nil {
+			return "", err
+		}
+		return "", term.Page(out, data)
+	}
+
+	outputPath, err := exp.Export(history)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(os.Stderr, "评审报告已保存到: %s\n", outputPath)
+	return outputPath, nil
+}
+
+// reportURL 根据 output.base_url 生成报告链接，优先使用 HTML 报告
+func reportURL(cfg *config.Config, reports []string) string {
+	if cfg.Output.BaseURL == "" || len(reports) == 0 {
+		return ""
+	}
+	report := reports[0]
+	for _, r := range reports {
+		if strings.HasSuffix(r, ".html") {
+			report = r
+			break
+		}
+	}
+	return strings.TrimRight(cfg.Output.BaseURL, "/") + "/" + filepath.Base(report)
+}
+
+// readDiff 从管道读取 diff 内容
+func readDiff() (string, error) {
+	if !term.IsTerminal(os.Stdin) {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("读取输入失败: %w", err)
+		}
+		return string(data), nil
+	}
+	return "", fmt.Errorf("请通过管道提供 git diff 内容")
 }
 
 func Execute() {
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/icatw/cr-tool/pkg/forge"
+	"github.com/icatw/cr-tool/pkg/review"
+	"github.com/icatw/cr-tool/pkg/server"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveAddr    string
+	serveWorkers int
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "启动评审服务：PR/MR webhook 和 REST API",
+	Long: `监听 GitHub、GitLab 和 Gitea 的 PR/MR webhook，PR 创建、重新打开或推送新提交时自动评审，
+并通过平台 API 发布行内评论、总结评论和提交状态。
+webhook 地址为 /webhook/github、/webhook/gitlab、/webhook/gitea，只有配置了 webhook_secret 的平台会接收；
+任务状态保存在 server.state_file 中，重启后继续未完成的任务。
+配置 server.api_keys 后提供 /v1 REST API，接口文档见 /v1/openapi.yaml。
+使用示例：
+  cr serve --addr :8080 --workers 4`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		if serveAddr != "" {
+			cfg.Server.Addr = serveAddr
+		}
+		if serveWorkers > 0 {
+			cfg.Server.Workers = serveWorkers
+		}
+
+		secrets := make(map[string]string)
+		for _, name := range []string{"github", "gitlab", "gitea"} {
+			if secret := forge.ConfigFor(cfg, name).WebhookSecret; secret != "" {
+				secrets[name] = secret
+			}
+		}
+		if len(secrets) == 0 && len(cfg.Server.APIKeys) == 0 {
+			return fmt.Errorf("未配置任何平台的 webhook_secret 或 server.api_keys")
+		}
+
+		srv, err := server.New(cfg.Server, server.Options{
+			Forge: func(name, repo string) (forge.Forge, error) {
+				c := *cfg
+				forge.ConfigFor(&c, name).Repo = repo
+				return forge.New(name, &c)
+			},
+			Review: pullRequestReviewer(cfg),
+			ReviewDiff: func(diffContent string) (*review.ReviewHistory, error) {
+				history, err := review.NewRemote().Review(diffContent)
+				if err == nil {
+					saveHistory(cfg, history, diffContent)
+				}
+				return history, err
+			},
+			Secrets: secrets,
+		})
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		log.Printf("webhook 服务已启动: %s", cfg.Server.Addr)
+		return srv.ListenAndServe(ctx)
+	},
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "", "监听地址 (默认 server.addr)")
+	serveCmd.Flags().IntVar(&serveWorkers, "workers", 0, "同时进行的评审数 (默认 server.workers)")
+	rootCmd.AddCommand(serveCmd)
+}
@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/icatw/cr-tool/pkg/history"
+	"github.com/icatw/cr-tool/pkg/review"
+	"github.com/icatw/cr-tool/pkg/term"
+	"github.com/icatw/cr-tool/pkg/triage"
+	"github.com/spf13/cobra"
+)
+
+var triageCmd = &cobra.Command{
+	Use:   "triage [id]",
+	Short: "交互式处理评审问题",
+	Long: `在终端中逐个查看最近一次（或指定 ID 的）评审发现的问题及对应的变更块：
+  a 接受  f 误报  w 不修复  u 撤销
+  p 将模型给出的修复应用到工作区
+  e 在 $EDITOR 中打开问题所在的行
+处理决定保存在评审记录中，可通过 cr history show 查看。`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		store, err := openHistory(cfg)
+		if err != nil {
+			return err
+		}
+		var record *history.Record
+		if len(args) > 0 {
+			record, err = store.Get(args[0])
+		} else {
+			record, err = store.Latest()
+		}
+		if err != nil {
+			return err
+		}
+		if len(record.Findings) == 0 {
+			fmt.Printf("评审 %s 没有需要处理的问题\n", record.ID)
+			return nil
+		}
+		if !term.IsTerminal(os.Stdout) {
+			return fmt.Errorf("cr triage 需要在终端中运行")
+		}
+
+		tty, err := os.Open("/dev/tty")
+		if err != nil {
+			return fmt.Errorf("无法打开终端: %w", err)
+		}
+		defer tty.Close()
+
+		s := triage.New(record, review.RepoRoot())
+		return runTriage(s, tty, func() error { return store.Save(record) })
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(triageCmd)
+}
+
+// runTriage 在备用屏幕中运行交互界面，直到用户退出
+func runTriage(s *triage.Session, tty *os.File, save func() error) error {
+	restore, err := enterScreen(tty)
+	if err != nil {
+		return err
+	}
+	defer func() { restore() }()
+
+	buf := make([]byte, 16)
+	for {
+		width, height := term.Size()
+		// 原始模式下换行不会回到行首
+		screen := strings.ReplaceAll(s.Render(width, height), "\n", "\r\n")
+		fmt.Fprint(os.Stdout, "\x1b[H\x1b[2J"+screen)
+
+		n, err := tty.Read(buf)
+		if err != nil {
+			return err
+		}
+		switch s.Handle(parseKey(buf[:n])) {
+		case triage.Quit:
+			return nil
+		case triage.Changed:
+			if err := save(); err != nil {
+				s.Message = err.Error()
+			}
+		case triage.Edit:
+			f, _ := s.Current()
+			restore()
+			if err := openEditor(s.Path(f), f.Line); err != nil {
+				s.Message = err.Error()
+			}
+			next, err := enterScreen(tty)
+			if err != nil {
+				// 终端已经恢复，退出时不再重复恢复
+				restore = func() {}
+				return err
+			}
+			restore = next
+		}
+	}
+}
+
+// enterScreen 切换到原始模式和备用屏幕，返回恢复终端的函数
+func enterScreen(tty *os.File) (func(), error) {
+	restoreMode, err := term.MakeRaw(tty)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprint(os.Stdout, "\x1b[?1049h\x1b[?25l")
+	return func() {
+		fmt.Fprint(os.Stdout, "\x1b[?25h\x1b[?1049l")
+		restoreMode()
+	}, nil
+}
+
+// parseKey 将终端输入转换为按键名称
+func parseKey(b []byte) string {
+	switch string(b) {
+	case "\x1b[A", "\x1bOA":
+		return "up"
+	case "\x1b[B", "\x1bOB":
+		return "down"
+	case "\x1b[5~":
+		return "pgup"
+	case "\x1b[6~", " ":
+		return "pgdown"
+	case "\x1b[H", "\x1b[1~", "\x1bOH":
+		return "home"
+	case "\x1b[F", "\x1b[4~", "\x1bOF":
+		return "end"
+	case "\x1b":
+		return "esc"
+	case "\x03":
+		return "ctrl-c"
+	}
+	return string(b)
+}
+
+// openEditor 在 $EDITOR（默认 vi）中打开文件并跳转到指定行
+func openEditor(path string, line int) error {
+	editor := strings.Fields(os.Getenv("VISUAL"))
+	if len(editor) == 0 {
+		editor = strings.Fields(os.Getenv("EDITOR"))
+	}
+	if len(editor) == 0 {
+		editor = []string{"vi"}
+	}
+
+	args := editor[1:]
+	switch name := filepath.Base(editor[0]); {
+	case line <= 0:
+		args = append(args, path)
+	case strings.HasPrefix(name, "code"), name == "subl", name == "zed":
+		if strings.HasPrefix(name, "code") {
+			args = append(args, "--wait", "-g")
+		}
+		args = append(args, fmt.Sprintf("%s:%d", path, line))
+	default:
+		// vi、vim、nano、emacs 等支持 +行号
+		args = append(args, fmt.Sprintf("+%d", line), path)
+	}
+
+	cmd := exec.Command(editor[0], args...)
+	cmd.Stdin = os.Stdin
+	if tty, err := os.Open("/dev/tty"); err == nil {
+		defer tty.Close()
+		cmd.Stdin = tty
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("打开编辑器失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+// Package codeowners 解析 CODEOWNERS 文件，查找文件的负责人
+package codeowners
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/icatw/cr-tool/pkg/ignore"
+)
+
+// Locations CODEOWNERS 文件的查找位置，与 GitHub/GitLab 一致
+var Locations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS", ".gitlab/CODEOWNERS"}
+
+// Rule 单条规则
+type Rule struct {
+	Pattern string
+	Owners  []string
+	matcher *ignore.Matcher
+}
+
+// File CODEOWNERS 规则集，后面的规则优先
+type File struct {
+	Rules []Rule
+}
+
+// Parse 解析 CODEOWNERS 内容，忽略 GitLab 的 [Section] 标题
+func Parse(content string) *File {
+	f := &File{}
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "[") || strings.HasPrefix(line, "^[") {
+			continue
+		}
+		if idx := strings.Index(line, " #"); idx >= 0 {
+			line = line[:idx]
+		}
+		fields := strings.Fields(line)
+		f.Rules = append(f.Rules, Rule{
+			Pattern: fields[0],
+			Owners:  fields[1:],
+			matcher: ignore.New(fields[:1]),
+		})
+	}
+	return f
+}
+
+// Load 在仓库根目录下查找并解析 CODEOWNERS，未找到时返回空规则集
+func Load(root string) (*File, error) {
+	for _, name := range Locations {
+		data, err := os.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("读取 CODEOWNERS 失败: %w", err)
+		}
+		return Parse(string(data)), nil
+	}
+	return &File{}, nil
+}
+
+// Owners 返回文件的负责人，以最后一条匹配的规则为准
+func (f *File) Owners(file string) []string {
+	for i := len(f.Rules) - 1; i >= 0; i-- {
+		if f.Rules[i].matcher.Match(file) {
+			return f.Rules[i].Owners
+		}
+	}
+	return nil
+}
+
+// OwnersOf 返回多个文件的负责人，按首次出现的顺序去重
+func (f *File) OwnersOf(files []string) []string {
+	seen := make(map[string]bool)
+	var owners []string
+	for _, file := range files {
+		for _, owner := range f.Owners(file) {
+			if !seen[owner] {
+				seen[owner] = true
+				owners = append(owners, owner)
+			}
+		}
+	}
+	return owners
+}
+
+// IsEmail 负责人是否为邮箱地址，其余为 @user 或 @org/team
+func IsEmail(owner string) bool {
+	return !strings.HasPrefix(owner, "@") && strings.Contains(owner, "@")
+}
@@ -0,0 +1,51 @@
+package codeowners
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const sample = `# 默认负责人
+*                   @org/maintainers
+*.go                @gopher dev@example.com   # Go 代码
+
+[数据库]
+/db/                dba@example.com
+docs/**/*.md        @writer
+`
+
+func TestOwners(t *testing.T) {
+	f := Parse(sample)
+	assert.Len(t, f.Rules, 4)
+
+	assert.Equal(t, []string{"@org/maintainers"}, f.Owners("README"))
+	assert.Equal(t, []string{"@gopher", "dev@example.com"}, f.Owners("pkg/review/review.go"))
+	assert.Equal(t, []string{"dba@example.com"}, f.Owners("db/migrate.go"))
+	assert.Equal(t, []string{"@org/maintainers"}, f.Owners("pkg/db/x.sql"))
+	assert.Equal(t, []string{"@writer"}, f.Owners("docs/guide/intro.md"))
+
+	assert.Equal(t, []string{"@gopher", "dev@example.com", "dba@example.com"},
+		f.OwnersOf([]string{"main.go", "db/a.go", "cmd/b.go"}))
+}
+
+func TestLoad(t *testing.T) {
+	root := t.TempDir()
+	f, err := Load(root)
+	assert.NoError(t, err)
+	assert.Empty(t, f.Rules)
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, ".github"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, ".github", "CODEOWNERS"), []byte(sample), 0644))
+	f, err = Load(root)
+	assert.NoError(t, err)
+	assert.Len(t, f.Rules, 4)
+}
+
+func TestIsEmail(t *testing.T) {
+	assert.True(t, IsEmail("dev@example.com"))
+	assert.False(t, IsEmail("@gopher"))
+	assert.False(t, IsEmail("@org/team"))
+}
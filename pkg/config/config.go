@@ -44,17 +44,44 @@ func Get() *Config {
 	return defaultConfig
 }
 
+// Set 设置配置，以库方式使用时可直接传入自定义配置
+func Set(cfg *Config) {
+	defaultConfig = cfg
+}
+
 // setDefaults 设置默认值
 func setDefaults(v *viper.Viper) {
 	v.SetDefault("model_name", "qwen-plus")
 	v.SetDefault("base_url", "https://dashscope.aliyuncs.com/compatible-mode/v1/chat/completions")
 	v.SetDefault("output.dir", "./review_results")
 	v.SetDefault("output.format", []string{"markdown"})
+	v.SetDefault("output.junit_fail_on", "中等")
 	v.SetDefault("cache.enabled", true)
 	v.SetDefault("cache.dir", "./.cache/code_review")
 	v.SetDefault("cache.expire_days", 7)
+	v.SetDefault("email.port", 587)
+	v.SetDefault("email.starttls", true)
+	v.SetDefault("notify.digest_file", "./.cr-tool/digest.json")
+	v.SetDefault("github.fail_on", "严重")
+	v.SetDefault("gitlab.fail_on", "严重")
+	v.SetDefault("gitee.fail_on", "严重")
+	v.SetDefault("gitea.fail_on", "严重")
+	v.SetDefault("server.addr", ":8080")
+	v.SetDefault("server.workers", 2)
+	v.SetDefault("server.queue_size", 100)
+	v.SetDefault("server.state_file", "./.cr-tool/jobs.json")
+	v.SetDefault("server.max_request_size", 10<<20)
+	v.SetDefault("server.async_threshold", 20000)
+	v.SetDefault("history.enabled", true)
+	v.SetDefault("history.dir", "./.cr-tool/history")
 	v.SetDefault("review.template", "default")
 	v.SetDefault("review.max_diff_size", 2000)
+	v.SetDefault("review.ignore_file", ".crignore")
+	v.SetDefault("review.baseline_file", ".cr-baseline.json")
+	v.SetDefault("commit.template", "commit")
+	v.SetDefault("commit.language", "zh")
+	v.SetDefault("audit.template", "audit")
+	v.SetDefault("audit.token_budget", 24000)
 }
 
 // loadConfig 加载配置文件
@@ -2,18 +2,37 @@ package config
 
 // Config 配置结构
 type Config struct {
-	APIKey    string       `mapstructure:"api_key"`
-	ModelName string       `mapstructure:"model_name"`
-	BaseURL   string       `mapstructure:"base_url"`
-	Output    OutputConfig `mapstructure:"output"`
-	Cache     CacheConfig  `mapstructure:"cache"`
-	Review    ReviewConfig `mapstructure:"review"`
+	APIKey    string        `mapstructure:"api_key"`
+	ModelName string        `mapstructure:"model_name"`
+	BaseURL   string        `mapstructure:"base_url"`
+	Output    OutputConfig  `mapstructure:"output"`
+	Cache     CacheConfig   `mapstructure:"cache"`
+	Review    ReviewConfig  `mapstructure:"review"`
+	History   HistoryConfig `mapstructure:"history"`
+	Ding      DingConfig    `mapstructure:"ding"`
+	Feishu    FeishuConfig  `mapstructure:"feishu"`
+	WeCom     WeComConfig   `mapstructure:"wecom"`
+	Slack     SlackConfig   `mapstructure:"slack"`
+	Email     EmailConfig   `mapstructure:"email"`
+	Notify    NotifyConfig  `mapstructure:"notify"`
+	GitHub    ForgeConfig   `mapstructure:"github"`
+	GitLab    ForgeConfig   `mapstructure:"gitlab"`
+	Gitee     ForgeConfig   `mapstructure:"gitee"`
+	Gitea     ForgeConfig   `mapstructure:"gitea"`
+	Server    ServerConfig  `mapstructure:"server"`
+	Fix       FixConfig     `mapstructure:"fix"`
+	Commit    CommitConfig  `mapstructure:"commit"`
+	Audit     AuditConfig   `mapstructure:"audit"`
 }
 
 // OutputConfig 输出配置
 type OutputConfig struct {
 	Dir    string   `mapstructure:"dir"`
 	Format []string `mapstructure:"format"`
+	// BaseURL 报告的访问地址前缀，设置后通知中会附带报告链接
+	BaseURL string `mapstructure:"base_url"`
+	// JUnitFailOn junit 格式中达到该级别的问题作为失败，默认为中等
+	JUnitFailOn string `mapstructure:"junit_fail_on"`
 }
 
 // CacheConfig 缓存配置
@@ -23,16 +42,174 @@ type CacheConfig struct {
 	ExpireDays int    `mapstructure:"expire_days"`
 }
 
+// DingConfig 钉钉机器人配置
+type DingConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Webhook string `mapstructure:"webhook"`
+	Secret  string `mapstructure:"secret"`
+	// MsgType 消息类型：markdown 或 actionCard
+	MsgType string `mapstructure:"msg_type"`
+}
+
+// FeishuConfig 飞书/Lark 机器人配置
+type FeishuConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Webhook string `mapstructure:"webhook"`
+	Secret  string `mapstructure:"secret"`
+}
+
+// WeComConfig 企业微信机器人配置
+type WeComConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Webhook string `mapstructure:"webhook"`
+}
+
+// SlackConfig Slack Incoming Webhook 配置
+type SlackConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Webhook string `mapstructure:"webhook"`
+}
+
+// EmailConfig 邮件通知配置
+type EmailConfig struct {
+	Enabled  bool   `mapstructure:"enabled"`
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	// StartTLS 是否要求使用 STARTTLS 加密连接
+	StartTLS bool     `mapstructure:"starttls"`
+	From     string   `mapstructure:"from"`
+	To       []string `mapstructure:"to"`
+	Cc       []string `mapstructure:"cc"`
+	// Recipients 额外的收件人来源：authors 为提交作者，codeowners 为改动文件的 CODEOWNERS
+	Recipients []string `mapstructure:"recipients"`
+	// Attachments 附件格式：pdf、markdown
+	Attachments []string `mapstructure:"attachments"`
+}
+
+// NotifyConfig 通知路由配置
+// 未配置规则时，评审结果发送到所有已启用的 ding/feishu/wecom/slack 渠道
+type NotifyConfig struct {
+	Channels   map[string]ChannelConfig `mapstructure:"channels"`
+	Rules      []NotifyRule             `mapstructure:"rules"`
+	DigestFile string                   `mapstructure:"digest_file"`
+}
+
+// ChannelConfig 命名的通知渠道，同一平台可以配置多个群
+type ChannelConfig struct {
+	// Type 渠道类型：ding、feishu、wecom、slack
+	Type    string `mapstructure:"type"`
+	Webhook string `mapstructure:"webhook"`
+	Secret  string `mapstructure:"secret"`
+	MsgType string `mapstructure:"msg_type"`
+}
+
+// NotifyRule 通知路由规则，按顺序匹配，命中第一条规则后停止，除非设置了 continue
+type NotifyRule struct {
+	Name     string   `mapstructure:"name"`
+	Channels []string `mapstructure:"channels"`
+	// MinSeverity、Categories 和 Paths 作用于问题：存在同时满足这些条件的问题时命中
+	// 只配置 Paths 时匹配变更的文件
+	MinSeverity string   `mapstructure:"min_severity"`
+	Categories  []string `mapstructure:"categories"`
+	Paths       []string `mapstructure:"paths"`
+	Branches    []string `mapstructure:"branches"`
+	Authors     []string `mapstructure:"authors"`
+	// Digest 为 true 时不立即发送，而是加入摘要，由 cr notify digest 统一发送
+	Digest   bool `mapstructure:"digest"`
+	Continue bool `mapstructure:"continue"`
+}
+
+// HistoryConfig 评审历史配置
+type HistoryConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Dir     string `mapstructure:"dir"`
+}
+
+// FixConfig 自动修复配置
+type FixConfig struct {
+	// VerifyCommand 每个补丁应用后执行的校验命令，如 go test ./...，失败时回滚该补丁
+	VerifyCommand string `mapstructure:"verify_command"`
+}
+
+// CommitConfig 提交信息生成配置
+type CommitConfig struct {
+	// Template 生成提交信息使用的模板，默认为内置的 commit 模板
+	Template string `mapstructure:"template"`
+	// Language 提交信息使用的语言：zh 或 en
+	Language string `mapstructure:"language"`
+	// Scopes 按路径推断 scope 的规则，未命中时使用变更文件所在的目录名
+	Scopes []CommitScope `mapstructure:"scopes"`
+}
+
+// CommitScope 路径与 scope 的对应规则，不含 / 的路径模式只匹配文件名
+type CommitScope struct {
+	Paths []string `mapstructure:"paths"`
+	Scope string   `mapstructure:"scope"`
+}
+
+// AuditConfig 整体审计配置
+type AuditConfig struct {
+	// Template 审计使用的模板，默认为内置的 audit 模板
+	Template string `mapstructure:"template"`
+	// TokenBudget 每批发送给模型的文件内容的 token 预算，按字节数估算
+	TokenBudget int `mapstructure:"token_budget"`
+}
+
 // ReviewConfig 评审配置
 type ReviewConfig struct {
 	Template       string                    `mapstructure:"template"`
 	Templates      map[string]ReviewTemplate `mapstructure:"templates"`
+	Routes         []TemplateRoute           `mapstructure:"routes"`
 	IgnorePatterns []string                  `mapstructure:"ignore_patterns"`
+	IgnoreFile     string                    `mapstructure:"ignore_file"`
+	BaselineFile   string                    `mapstructure:"baseline_file"`
 	MaxDiffSize    int                       `mapstructure:"max_diff_size"`
 }
 
 // ReviewTemplate 评审模板
 type ReviewTemplate struct {
-	SystemPrompt string   `mapstructure:"system_prompt"`
-	FocusPoints  []string `mapstructure:"focus_points"`
+	SystemPrompt string   `mapstructure:"system_prompt" json:"system_prompt"`
+	FocusPoints  []string `mapstructure:"focus_points" json:"focus_points"`
+}
+
+// TemplateRoute 模板路由规则，按文件路径或语言为文件选择评审模板
+type TemplateRoute struct {
+	Paths     []string `mapstructure:"paths"`
+	Languages []string `mapstructure:"languages"`
+	Template  string   `mapstructure:"template"`
+}
+
+// ForgeConfig 代码托管平台配置
+type ForgeConfig struct {
+	// Token 访问令牌，为空时读取平台对应的环境变量
+	Token string `mapstructure:"token"`
+	// BaseURL API 地址，私有部署时修改
+	BaseURL string `mapstructure:"base_url"`
+	// Repo 仓库，格式为 owner/name
+	Repo string `mapstructure:"repo"`
+	// FailOn 存在该级别及以上的问题时，提交状态为失败
+	FailOn string `mapstructure:"fail_on"`
+	// StatusContext 提交状态的名称
+	StatusContext string `mapstructure:"status_context"`
+	// WebhookSecret cr serve 校验 webhook 签名的密钥，未配置时不接收该平台的 webhook
+	WebhookSecret string `mapstructure:"webhook_secret"`
+}
+
+// ServerConfig cr serve 配置
+type ServerConfig struct {
+	Addr string `mapstructure:"addr"`
+	// Workers 同时进行的评审数
+	Workers int `mapstructure:"workers"`
+	// QueueSize 等待评审的任务上限，队列已满时 webhook 返回 503 由平台重试
+	QueueSize int `mapstructure:"queue_size"`
+	// StateFile 任务状态文件，重启后继续未完成的任务
+	StateFile string `mapstructure:"state_file"`
+	// APIKeys REST API 的访问密钥，未配置时不启用 /v1 接口
+	APIKeys []string `mapstructure:"api_keys"`
+	// MaxRequestSize REST API 请求内容上限（字节）
+	MaxRequestSize int `mapstructure:"max_request_size"`
+	// AsyncThreshold 超过该大小（字节）的 diff 自动转为异步评审
+	AsyncThreshold int `mapstructure:"async_threshold"`
 }
@@ -0,0 +1,182 @@
+package diff
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Apply 将文件的 diff 应用到原始内容，返回修改后的内容。
+// 变更块的上下文和删除行必须与原始内容完全一致；行号允许偏移，取离 diff 中位置最近的匹配
+func (f *File) Apply(content string) (string, error) {
+	if f.Binary {
+		return "", errors.New("不支持二进制文件")
+	}
+	if f.IsNew() && content != "" {
+		return "", fmt.Errorf("文件 %s 已存在", f.Name())
+	}
+
+	eol := "\n"
+	if strings.Contains(content, "\r\n") {
+		eol = "\r\n"
+	}
+	lines := splitLines(content)
+
+	var out []string
+	pos, offset := 0, 0
+	for i, h := range f.Hunks {
+		var old, added []string
+		for _, l := range h.Lines {
+			if l.Kind != Added {
+				old = append(old, l.Content)
+			}
+			if l.Kind != Deleted {
+				added = append(added, l.Content)
+			}
+		}
+
+		// 只有新增行时 OldStart 为插入位置的前一行
+		start := h.OldStart - 1
+		if h.OldLines == 0 {
+			start = h.OldStart
+		}
+		at := findLines(lines, old, start+offset, pos)
+		if at < 0 {
+			return "", fmt.Errorf("第 %d 个变更块（@@ -%d,%d @@）与文件内容不一致", i+1, h.OldStart, h.OldLines)
+		}
+		offset = at - start
+
+		out = append(out, lines[pos:at]...)
+		out = append(out, added...)
+		pos = at + len(old)
+	}
+	out = append(out, lines[pos:]...)
+
+	if len(out) == 0 {
+		return "", nil
+	}
+	result := strings.Join(out, eol)
+	// 保留原文件末尾是否换行，新文件默认以换行结尾
+	if content == "" || strings.HasSuffix(content, "\n") {
+		result += eol
+	}
+	return result, nil
+}
+
+// CheckLines 核对内容中 from 到 to 行是否与 diff 中新文件的对应行一致，diff 中没有的行不检查
+func (f *File) CheckLines(content string, from, to int) error {
+	lines := splitLines(content)
+	if to > len(lines) {
+		return fmt.Errorf("文件只有 %d 行", len(lines))
+	}
+	for n := from; n <= to; n++ {
+		if line, ok := f.LineAt(n); ok && lines[n-1] != line.Content {
+			return fmt.Errorf("第 %d 行与评审时不一致，文件可能已经修改", n)
+		}
+	}
+	return nil
+}
+
+// splitLines 按行拆分内容，去掉行尾换行符
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSuffix(l, "\r")
+	}
+	return lines
+}
+
+// findLines 从 start 开始向两侧查找与 want 一致的位置，不早于 min
+func findLines(lines, want []string, start, min int) int {
+	last := len(lines) - len(want)
+	if start < min {
+		start = min
+	}
+	if start > last {
+		start = last
+	}
+	for d := 0; start-d >= min || start+d <= last; d++ {
+		for _, at := range []int{start - d, start + d} {
+			if at >= min && at <= last && matchLines(lines[at:], want) {
+				return at
+			}
+		}
+	}
+	return -1
+}
+
+// matchLines 检查 lines 是否以 want 开头，忽略行尾空白
+func matchLines(lines, want []string) bool {
+	for i, w := range want {
+		if strings.TrimRight(lines[i], " \t") != strings.TrimRight(w, " \t") {
+			return false
+		}
+	}
+	return true
+}
+
+// hunkHeader 匹配变更块头部中的行号
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+(\d+)(?:,\d+)? @@(.*)$`)
+
+// Recount 按变更块的实际内容重新计算头部中的行数，与 git apply --recount 相同。
+// 模型生成的 diff 经常算错行数，直接解析会丢失或多出内容
+func Recount(content string) string {
+	lines := strings.Split(content, "\n")
+	var out []string
+	for i := 0; i < len(lines); i++ {
+		m := hunkHeader.FindStringSubmatch(lines[i])
+		if m == nil {
+			out = append(out, lines[i])
+			continue
+		}
+
+		var body []string
+		oldLines, newLines := 0, 0
+		j := i + 1
+		for ; j < len(lines); j++ {
+			l := lines[j]
+			if strings.HasPrefix(l, "@@ ") || strings.HasPrefix(l, "diff ") ||
+				strings.HasPrefix(l, "--- ") && j+1 < len(lines) && strings.HasPrefix(lines[j+1], "+++ ") {
+				break
+			}
+			if l == "" && j == len(lines)-1 {
+				// 内容末尾的换行
+				break
+			}
+			switch {
+			case l == "" || l[0] == ' ':
+				oldLines++
+				newLines++
+			case l[0] == '-':
+				oldLines++
+			case l[0] == '+':
+				newLines++
+			case l[0] == '\\':
+			default:
+				// 模型有时会漏掉上下文行前面的空格
+				l = " " + l
+				oldLines++
+				newLines++
+			}
+			body = append(body, l)
+		}
+
+		oldStart, _ := strconv.Atoi(m[1])
+		newStart, _ := strconv.Atoi(m[2])
+		if oldLines > 0 && oldStart == 0 {
+			oldStart = 1
+		}
+		if newLines > 0 && newStart == 0 {
+			newStart = 1
+		}
+		out = append(out, fmt.Sprintf("@@ -%d,%d +%d,%d @@%s", oldStart, oldLines, newStart, newLines, m[3]))
+		out = append(out, body...)
+		i = j - 1
+	}
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,285 @@
+// Package diff 解析 unified diff，按文件拆分并记录每一行对应的行号
+package diff
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DevNull 新增或删除文件时 diff 中使用的空路径
+const DevNull = "/dev/null"
+
+// LineKind 行类型
+type LineKind int
+
+const (
+	Context LineKind = iota
+	Added
+	Deleted
+)
+
+// File 单个文件的 diff
+type File struct {
+	OldName string
+	NewName string
+	Binary  bool
+	Hunks   []*Hunk
+	// Raw 原始文本，包含文件头，拼接所有文件的 Raw 即可还原输入
+	Raw string
+}
+
+// Hunk 变更块
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Section  string
+	Lines    []Line
+}
+
+// Line 变更块中的一行
+type Line struct {
+	Kind    LineKind
+	Content string
+	OldLine int
+	NewLine int
+	// Position 相对文件第一个 @@ 的行偏移，与 GitHub 评论的 position 语义一致
+	Position int
+}
+
+// Name 返回文件路径，删除的文件返回旧路径
+func (f *File) Name() string {
+	if f.NewName == "" || f.NewName == DevNull {
+		return f.OldName
+	}
+	return f.NewName
+}
+
+// IsNew 是否为新增文件
+func (f *File) IsNew() bool {
+	return f.OldName == DevNull
+}
+
+// IsDeleted 是否为删除的文件
+func (f *File) IsDeleted() bool {
+	return f.NewName == DevNull
+}
+
+// AddedLines 返回所有新增行
+func (f *File) AddedLines() []Line {
+	var lines []Line
+	for _, h := range f.Hunks {
+		for _, l := range h.Lines {
+			if l.Kind == Added {
+				lines = append(lines, l)
+			}
+		}
+	}
+	return lines
+}
+
+// LineAt 返回新文件中指定行号对应的 diff 行
+func (f *File) LineAt(newLine int) (Line, bool) {
+	for _, h := range f.Hunks {
+		for _, l := range h.Lines {
+			if l.Kind != Deleted && l.NewLine == newLine {
+				return l, true
+			}
+		}
+	}
+	return Line{}, false
+}
+
+// Parse 解析 diff 内容
+// 既支持 git diff 输出，也支持只有 ---/+++ 文件头的普通 unified diff；
+// 无法识别的内容会原样保留在 Raw 中
+func Parse(content string) []*File {
+	var (
+		files    []*File
+		current  *File
+		raw      strings.Builder
+		hunk     *Hunk
+		oldLeft  int
+		newLeft  int
+		oldLine  int
+		newLine  int
+		position int
+	)
+
+	flush := func() {
+		if current == nil {
+			if strings.TrimSpace(raw.String()) == "" && len(files) > 0 {
+				// 文件之间的空白直接并入上一个文件，保证还原后内容不变
+				files[len(files)-1].Raw += raw.String()
+				raw.Reset()
+				return
+			}
+			if raw.Len() == 0 {
+				return
+			}
+			current = &File{}
+		}
+		current.Raw = raw.String()
+		files = append(files, current)
+		raw.Reset()
+		current = nil
+		hunk = nil
+	}
+
+	lines := strings.SplitAfter(content, "\n")
+	for i, rawLine := range lines {
+		if rawLine == "" {
+			continue
+		}
+		line := strings.TrimRight(rawLine, "\r\n")
+		inHunk := hunk != nil && (oldLeft > 0 || newLeft > 0)
+
+		switch {
+		case !inHunk && strings.HasPrefix(line, "diff --git "):
+			flush()
+			current = &File{}
+			current.OldName, current.NewName = parseGitHeader(line)
+			position = 0
+		case !inHunk && strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			if current == nil || len(current.Hunks) > 0 {
+				flush()
+				current = &File{}
+				position = 0
+			}
+			current.OldName = parseFileName(line[4:])
+		case !inHunk && current != nil && strings.HasPrefix(line, "+++ "):
+			current.NewName = parseFileName(line[4:])
+		case !inHunk && current != nil && (strings.HasPrefix(line, "Binary files ") || line == "GIT binary patch"):
+			current.Binary = true
+		case current != nil && strings.HasPrefix(line, "@@ "):
+			h, err := parseHunkHeader(line)
+			if err != nil {
+				break
+			}
+			if len(current.Hunks) > 0 {
+				position++
+			}
+			hunk = h
+			current.Hunks = append(current.Hunks, hunk)
+			oldLeft, newLeft = h.OldLines, h.NewLines
+			oldLine, newLine = h.OldStart, h.NewStart
+		case inHunk:
+			position++
+			if strings.HasPrefix(line, "\\") {
+				// "\ No newline at end of file"
+				break
+			}
+			l := Line{Content: line, Position: position}
+			if line != "" {
+				l.Content = line[1:]
+			}
+			switch {
+			case strings.HasPrefix(line, "+"):
+				l.Kind = Added
+				l.NewLine = newLine
+				newLine++
+				newLeft--
+			case strings.HasPrefix(line, "-"):
+				l.Kind = Deleted
+				l.OldLine = oldLine
+				oldLine++
+				oldLeft--
+			default:
+				l.Kind = Context
+				l.OldLine, l.NewLine = oldLine, newLine
+				oldLine++
+				newLine++
+				oldLeft--
+				newLeft--
+			}
+			hunk.Lines = append(hunk.Lines, l)
+		}
+
+		raw.WriteString(rawLine)
+	}
+	flush()
+
+	return files
+}
+
+// Join 将多个文件的 diff 拼接为完整的 diff 内容
+func Join(files []*File) string {
+	var b strings.Builder
+	for i, f := range files {
+		b.WriteString(f.Raw)
+		if i < len(files)-1 && f.Raw != "" && !strings.HasSuffix(f.Raw, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// parseGitHeader 解析 "diff --git a/x b/x" 中的文件名
+func parseGitHeader(line string) (string, string) {
+	rest := strings.TrimPrefix(line, "diff --git ")
+	if idx := strings.LastIndex(rest, " b/"); idx >= 0 {
+		return strings.TrimPrefix(rest[:idx], "a/"), rest[idx+3:]
+	}
+	parts := strings.Fields(rest)
+	if len(parts) == 2 {
+		return strings.TrimPrefix(parts[0], "a/"), strings.TrimPrefix(parts[1], "b/")
+	}
+	return "", ""
+}
+
+// parseFileName 解析 ---/+++ 行中的文件名
+func parseFileName(name string) string {
+	// 去掉 diff -u 输出中文件名后面的时间戳
+	if idx := strings.Index(name, "\t"); idx >= 0 {
+		name = name[:idx]
+	}
+	name = strings.TrimSpace(name)
+	if name == DevNull {
+		return name
+	}
+	if strings.HasPrefix(name, "a/") || strings.HasPrefix(name, "b/") {
+		return name[2:]
+	}
+	return name
+}
+
+// parseHunkHeader 解析 "@@ -1,2 +3,4 @@ section"
+func parseHunkHeader(line string) (*Hunk, error) {
+	end := strings.Index(line[3:], " @@")
+	if end < 0 {
+		return nil, fmt.Errorf("无效的 hunk 头: %s", line)
+	}
+	ranges := strings.Fields(line[3 : 3+end])
+	if len(ranges) != 2 || !strings.HasPrefix(ranges[0], "-") || !strings.HasPrefix(ranges[1], "+") {
+		return nil, fmt.Errorf("无效的 hunk 头: %s", line)
+	}
+
+	h := &Hunk{Section: strings.TrimSpace(line[3+end+3:])}
+	var err error
+	if h.OldStart, h.OldLines, err = parseRange(ranges[0][1:]); err != nil {
+		return nil, err
+	}
+	if h.NewStart, h.NewLines, err = parseRange(ranges[1][1:]); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// parseRange 解析 "start,count"，省略 count 时为 1
+func parseRange(s string) (int, int, error) {
+	start, count, found := strings.Cut(s, ",")
+	n, err := strconv.Atoi(start)
+	if err != nil {
+		return 0, 0, fmt.Errorf("无效的行号: %s", s)
+	}
+	if !found {
+		return n, 1, nil
+	}
+	c, err := strconv.Atoi(count)
+	if err != nil {
+		return 0, 0, fmt.Errorf("无效的行数: %s", s)
+	}
+	return n, c, nil
+}
@@ -0,0 +1,112 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const sample = `diff --git a/main.go b/main.go
+index 83db48f..bf269f4 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,4 @@
+ package main
+-import "fmt"
++import (
++	"fmt"
++)
+@@ -10,2 +11,2 @@ func main() {
+ 	fmt.Println("a")
+-	fmt.Println("b")
++	fmt.Println("c")
+diff --git a/db/init.sql b/db/init.sql
+new file mode 100644
+--- /dev/null
++++ b/db/init.sql
+@@ -0,0 +1,2 @@
++-- comment
++CREATE TABLE t (id INT);
+diff --git a/logo.png b/logo.png
+Binary files a/logo.png and b/logo.png differ
+`
+
+func TestParse(t *testing.T) {
+	files := Parse(sample)
+	assert.Len(t, files, 3)
+	assert.Equal(t, sample, Join(files))
+
+	f := files[0]
+	assert.Equal(t, "main.go", f.Name())
+	assert.Len(t, f.Hunks, 2)
+
+	line, ok := f.LineAt(12)
+	assert.True(t, ok)
+	assert.Equal(t, Added, line.Kind)
+	assert.Equal(t, "\tfmt.Println(\"c\")", line.Content)
+	assert.Equal(t, 9, line.Position)
+
+	added := files[1].AddedLines()
+	assert.True(t, files[1].IsNew())
+	assert.Len(t, added, 2)
+	assert.Equal(t, "-- comment", added[0].Content)
+
+	assert.True(t, files[2].Binary)
+}
+
+func TestParseDeletedSQLComment(t *testing.T) {
+	content := `--- a/q.sql
++++ b/q.sql
+@@ -1,2 +1 @@
+--- old comment
+ SELECT 1;
+`
+	files := Parse(content)
+	assert.Len(t, files, 1)
+	assert.Equal(t, "q.sql", files[0].Name())
+	assert.Equal(t, Deleted, files[0].Hunks[0].Lines[0].Kind)
+	assert.Equal(t, "-- old comment", files[0].Hunks[0].Lines[0].Content)
+}
+
+func TestParseWithoutHeaders(t *testing.T) {
+	files := Parse("+ func main() {}\n")
+	assert.Len(t, files, 1)
+	assert.Equal(t, "", files[0].Name())
+	assert.Equal(t, "+ func main() {}\n", Join(files))
+}
+
+func TestApply(t *testing.T) {
+	content := "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"a\")\n\tfmt.Println(\"b\")\n}\n"
+	// 变更块的行号偏移了 2 行，行数也是错的
+	patch := Recount(`--- a/main.go
++++ b/main.go
+@@ -3,9 +3,9 @@
+ func main() {
+-	fmt.Println("a")
++	fmt.Println("A")
+ 	fmt.Println("b")
+`)
+	assert.Contains(t, patch, "@@ -3,3 +3,3 @@")
+	files := Parse(patch)
+	assert.Len(t, files, 1)
+
+	result, err := files[0].Apply(content)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Replace(content, `"a"`, `"A"`, 1), result)
+
+	_, err = files[0].Apply(strings.Replace(content, `"b"`, `"c"`, 1))
+	assert.Error(t, err)
+
+	// 新增文件
+	files = Parse(Recount("--- /dev/null\n+++ b/new.go\n@@ -0,0 +1 @@\n+package main\n"))
+	result, err = files[0].Apply("")
+	assert.NoError(t, err)
+	assert.Equal(t, "package main\n", result)
+	_, err = files[0].Apply(content)
+	assert.Error(t, err)
+
+	files = Parse(sample)
+	assert.NoError(t, files[0].CheckLines("package main\nimport (\n\t\"fmt\"\n)\n", 1, 3))
+	assert.Error(t, files[0].CheckLines("package main\nimport \"fmt\"\n", 1, 2))
+}
@@ -0,0 +1,335 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/icatw/cr-tool/pkg/config"
+	"github.com/icatw/cr-tool/pkg/review"
+)
+
+// annotationExts 注解格式保存为文件时的扩展名
+var annotationExts = map[Format]string{
+	FormatGitHub:      ".github.txt",
+	FormatGitLab:      ".codequality.json",
+	FormatRDJSON:      ".rdjson",
+	FormatCheckstyle:  ".checkstyle.xml",
+	FormatErrorformat: ".errors.txt",
+}
+
+// AnnotationExporter 将问题列表输出为 CI 和编辑器可以识别的注解格式
+type AnnotationExporter struct {
+	config *config.Config
+	format Format
+}
+
+// NewAnnotationExporter 创建注解导出器
+func NewAnnotationExporter(format Format) *AnnotationExporter {
+	return &AnnotationExporter{
+		config: config.Get(),
+		format: format,
+	}
+}
+
+// Render 生成注解内容
+func (e *AnnotationExporter) Render(history *review.ReviewHistory) ([]byte, error) {
+	var findings []review.Finding
+	for _, f := range history.Findings {
+		if f.File != "" {
+			findings = append(findings, f)
+		}
+	}
+
+	switch e.format {
+	case FormatGitHub:
+		return renderGitHub(findings), nil
+	case FormatGitLab:
+		return renderCodeQuality(findings)
+	case FormatRDJSON:
+		return renderRDJSON(findings)
+	case FormatCheckstyle:
+		return renderCheckstyle(findings)
+	case FormatErrorformat:
+		return renderErrorformat(findings), nil
+	default:
+		return nil, fmt.Errorf("不支持的导出格式: %s", e.format)
+	}
+}
+
+// Export 将注解保存到输出目录
+func (e *AnnotationExporter) Export(history *review.ReviewHistory) (string, error) {
+	data, err := e.Render(history)
+	if err != nil {
+		return "", err
+	}
+
+	outputDir := e.config.Output.Dir
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", fmt.Errorf("创建输出目录失败: %w", err)
+	}
+
+	filename := fmt.Sprintf("%s_review%s", time.Now().Format("20060102_150405"), annotationExts[e.format])
+	outputPath := filepath.Join(outputDir, filename)
+
+	if err := os.WriteFile(outputPath, data, 0644); err != nil {
+		return "", fmt.Errorf("保存评审报告失败: %w", err)
+	}
+
+	return outputPath, nil
+}
+
+// findingMessage 问题的完整描述
+func findingMessage(f review.Finding) string {
+	msg := f.Title
+	if f.Detail != "" {
+		msg += "\n" + f.Detail
+	}
+	if f.Suggestion != "" {
+		msg += "\n建议：" + f.Suggestion
+	}
+	return msg
+}
+
+// findingLine 问题所在行，没有行号时指向文件第一行
+func findingLine(f review.Finding) int {
+	if f.Line > 0 {
+		return f.Line
+	}
+	return 1
+}
+
+// findingEndLine 问题结束的行
+func findingEndLine(f review.Finding) int {
+	if f.EndLine > findingLine(f) {
+		return f.EndLine
+	}
+	return findingLine(f)
+}
+
+// renderGitHub GitHub Actions 工作流命令，如 ::warning file=main.go,line=3::message
+func renderGitHub(findings []review.Finding) []byte {
+	commands := map[review.Severity]string{
+		review.SeverityHigh:   "error",
+		review.SeverityMedium: "warning",
+		review.SeverityLow:    "notice",
+	}
+	var b bytes.Buffer
+	for _, f := range findings {
+		command, ok := commands[f.Severity]
+		if !ok {
+			command = "notice"
+		}
+		props := []string{"file=" + escapeProperty(f.File)}
+		if f.Line > 0 {
+			props = append(props, fmt.Sprintf("line=%d", f.Line))
+			if f.EndLine > f.Line {
+				props = append(props, fmt.Sprintf("endLine=%d", f.EndLine))
+			}
+		}
+		props = append(props, "title="+escapeProperty(fmt.Sprintf("[%s] %s", f.Severity, f.Title)))
+		fmt.Fprintf(&b, "::%s %s::%s\n", command, strings.Join(props, ","), escapeData(findingMessage(f)))
+	}
+	return b.Bytes()
+}
+
+// escapeData 转义工作流命令的消息内容
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty 转义工作流命令的属性值
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// renderCodeQuality GitLab Code Quality 报告
+func renderCodeQuality(findings []review.Finding) ([]byte, error) {
+	severities := map[review.Severity]string{
+		review.SeverityHigh:   "critical",
+		review.SeverityMedium: "major",
+		review.SeverityLow:    "minor",
+	}
+	type lines struct {
+		Begin int `json:"begin"`
+		End   int `json:"end,omitempty"`
+	}
+	type location struct {
+		Path  string `json:"path"`
+		Lines lines  `json:"lines"`
+	}
+	type issue struct {
+		Description string   `json:"description"`
+		CheckName   string   `json:"check_name"`
+		Fingerprint string   `json:"fingerprint"`
+		Severity    string   `json:"severity"`
+		Location    location `json:"location"`
+	}
+
+	issues := make([]issue, 0, len(findings))
+	for _, f := range findings {
+		severity, ok := severities[f.Severity]
+		if !ok {
+			severity = "info"
+		}
+		checkName := "cr-tool"
+		if f.Category != "" {
+			checkName += "/" + f.Category
+		}
+		it := issue{
+			Description: findingMessage(f),
+			CheckName:   checkName,
+			Fingerprint: f.Fingerprint(),
+			Severity:    severity,
+			Location:    location{Path: f.File, Lines: lines{Begin: findingLine(f)}},
+		}
+		if end := findingEndLine(f); end > findingLine(f) {
+			it.Location.Lines.End = end
+		}
+		issues = append(issues, it)
+	}
+	return json.MarshalIndent(issues, "", "  ")
+}
+
+// renderRDJSON reviewdog Diagnostic Format，行和列从 1 开始，
+// 模型给出修复代码时附带替换 Line 到 EndLine 整行的建议
+func renderRDJSON(findings []review.Finding) ([]byte, error) {
+	severities := map[review.Severity]string{
+		review.SeverityHigh:   "ERROR",
+		review.SeverityMedium: "WARNING",
+		review.SeverityLow:    "INFO",
+	}
+	type position struct {
+		Line   int `json:"line"`
+		Column int `json:"column"`
+	}
+	type rng struct {
+		Start position  `json:"start"`
+		End   *position `json:"end,omitempty"`
+	}
+	type location struct {
+		Path  string `json:"path"`
+		Range rng    `json:"range"`
+	}
+	type suggestion struct {
+		Range rng    `json:"range"`
+		Text  string `json:"text"`
+	}
+	type code struct {
+		Value string `json:"value"`
+	}
+	type diagnostic struct {
+		Message     string       `json:"message"`
+		Location    location     `json:"location"`
+		Severity    string       `json:"severity"`
+		Code        *code        `json:"code,omitempty"`
+		Suggestions []suggestion `json:"suggestions,omitempty"`
+	}
+
+	diagnostics := make([]diagnostic, 0, len(findings))
+	for _, f := range findings {
+		severity, ok := severities[f.Severity]
+		if !ok {
+			severity = "INFO"
+		}
+		d := diagnostic{
+			Message:  findingMessage(f),
+			Location: location{Path: f.File, Range: rng{Start: position{Line: findingLine(f), Column: 1}}},
+			Severity: severity,
+		}
+		if f.Category != "" {
+			d.Code = &code{Value: f.Category}
+		}
+		if f.Fix != "" && f.Line > 0 {
+			text := f.Fix
+			if !strings.HasSuffix(text, "\n") {
+				text += "\n"
+			}
+			d.Suggestions = []suggestion{{
+				Range: rng{Start: position{Line: f.Line, Column: 1}, End: &position{Line: findingEndLine(f) + 1, Column: 1}},
+				Text:  text,
+			}}
+		}
+		diagnostics = append(diagnostics, d)
+	}
+
+	return json.MarshalIndent(map[string]interface{}{
+		"source":      map[string]string{"name": "cr-tool"},
+		"diagnostics": diagnostics,
+	}, "", "  ")
+}
+
+// renderCheckstyle Checkstyle XML 报告，按文件分组
+func renderCheckstyle(findings []review.Finding) ([]byte, error) {
+	severities := map[review.Severity]string{
+		review.SeverityHigh:   "error",
+		review.SeverityMedium: "warning",
+		review.SeverityLow:    "info",
+	}
+	type checkstyleError struct {
+		Line     int    `xml:"line,attr"`
+		Column   int    `xml:"column,attr"`
+		Severity string `xml:"severity,attr"`
+		Message  string `xml:"message,attr"`
+		Source   string `xml:"source,attr"`
+	}
+	type checkstyleFile struct {
+		Name   string            `xml:"name,attr"`
+		Errors []checkstyleError `xml:"error"`
+	}
+	type checkstyle struct {
+		XMLName xml.Name          `xml:"checkstyle"`
+		Version string            `xml:"version,attr"`
+		Files   []*checkstyleFile `xml:"file"`
+	}
+
+	report := checkstyle{Version: "4.3"}
+	files := make(map[string]*checkstyleFile)
+	for _, f := range findings {
+		file, ok := files[f.File]
+		if !ok {
+			file = &checkstyleFile{Name: f.File}
+			files[f.File] = file
+			report.Files = append(report.Files, file)
+		}
+		severity, ok := severities[f.Severity]
+		if !ok {
+			severity = "info"
+		}
+		source := "cr-tool"
+		if f.Category != "" {
+			source += "." + f.Category
+		}
+		file.Errors = append(file.Errors, checkstyleError{
+			Line:     findingLine(f),
+			Column:   1,
+			Severity: severity,
+			Message:  findingMessage(f),
+			Source:   source,
+		})
+	}
+
+	data, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+// renderErrorformat file:line:col: message 格式，用于 vim/emacs 的 quickfix
+func renderErrorformat(findings []review.Finding) []byte {
+	var b bytes.Buffer
+	for _, f := range findings {
+		msg := fmt.Sprintf("[%s] %s", f.Severity, f.Title)
+		if f.Suggestion != "" {
+			msg += "（建议：" + f.Suggestion + "）"
+		}
+		fmt.Fprintf(&b, "%s:%d:1: %s\n", f.File, findingLine(f), strings.ReplaceAll(msg, "\n", " "))
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,74 @@
+package exporter
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/icatw/cr-tool/pkg/review"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnnotationExporter_Render(t *testing.T) {
+	history := &review.ReviewHistory{Findings: []review.Finding{
+		{File: "cmd/main.go", Line: 3, EndLine: 4, Severity: review.SeverityHigh, Category: "security", Title: "SQL 注入", Detail: "拼接了用户输入", Suggestion: "使用参数化查询", Fix: "db.Query(q, id)"},
+		{File: "a,b.go", Severity: review.SeverityLow, Title: "100% 覆盖"},
+		{Severity: review.SeverityMedium, Title: "没有文件的问题会被跳过"},
+	}}
+	render := func(format Format) string {
+		data, err := NewAnnotationExporter(format).Render(history)
+		assert.NoError(t, err)
+		return string(data)
+	}
+
+	assert.Equal(t,
+		"::error file=cmd/main.go,line=3,endLine=4,title=[严重] SQL 注入::SQL 注入%0A拼接了用户输入%0A建议：使用参数化查询\n"+
+			"::notice file=a%2Cb.go,title=[低] 100%25 覆盖::100%25 覆盖\n",
+		render(FormatGitHub))
+
+	assert.Equal(t,
+		"cmd/main.go:3:1: [严重] SQL 注入（建议：使用参数化查询）\n"+
+			"a,b.go:1:1: [低] 100% 覆盖\n",
+		render(FormatErrorformat))
+
+	var issues []map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(render(FormatGitLab)), &issues))
+	assert.Len(t, issues, 2)
+	assert.Equal(t, "critical", issues[0]["severity"])
+	assert.Equal(t, "cr-tool/security", issues[0]["check_name"])
+	assert.Equal(t, history.Findings[0].Fingerprint(), issues[0]["fingerprint"])
+	assert.Equal(t, map[string]interface{}{"path": "cmd/main.go", "lines": map[string]interface{}{"begin": float64(3), "end": float64(4)}}, issues[0]["location"])
+	assert.Equal(t, "minor", issues[1]["severity"])
+
+	var rdjson struct {
+		Diagnostics []struct {
+			Severity    string
+			Code        *struct{ Value string }
+			Suggestions []struct {
+				Range struct{ Start, End struct{ Line, Column int } }
+				Text  string
+			}
+		}
+	}
+	assert.NoError(t, json.Unmarshal([]byte(render(FormatRDJSON)), &rdjson))
+	assert.Len(t, rdjson.Diagnostics, 2)
+	assert.Equal(t, "ERROR", rdjson.Diagnostics[0].Severity)
+	assert.Equal(t, "security", rdjson.Diagnostics[0].Code.Value)
+	assert.Equal(t, "db.Query(q, id)\n", rdjson.Diagnostics[0].Suggestions[0].Text)
+	assert.Equal(t, 3, rdjson.Diagnostics[0].Suggestions[0].Range.Start.Line)
+	assert.Equal(t, 5, rdjson.Diagnostics[0].Suggestions[0].Range.End.Line, "替换到 EndLine 的下一行开头")
+	assert.Nil(t, rdjson.Diagnostics[1].Code)
+	assert.Empty(t, rdjson.Diagnostics[1].Suggestions)
+
+	checkstyle := render(FormatCheckstyle)
+	assert.Contains(t, checkstyle, `<?xml version="1.0" encoding="UTF-8"?>`)
+	assert.Contains(t, checkstyle, `<file name="cmd/main.go">`)
+	assert.Contains(t, checkstyle, `<error line="3" column="1" severity="error" message="SQL 注入&#xA;拼接了用户输入&#xA;建议：使用参数化查询" source="cr-tool.security"></error>`)
+	assert.Contains(t, checkstyle, `<file name="a,b.go">`)
+
+	path, err := NewAnnotationExporter(FormatCheckstyle).Export(history)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasSuffix(path, ".checkstyle.xml"))
+	os.Remove(path)
+}
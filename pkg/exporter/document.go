@@ -0,0 +1,77 @@
+package exporter
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Document 评审报告以外由模型生成的 Markdown 文档，如 PR 描述和发布说明
+type Document struct {
+	Title string
+	// Kind 文档类型，用作文件名后缀，如 describe、release_notes
+	Kind    string
+	Content string
+}
+
+// DocumentExporter 可以导出 Document 的导出器
+type DocumentExporter interface {
+	ExportDocument(doc *Document) (string, error)
+}
+
+// NewDocumentExporter 创建文档导出器，目前支持 markdown 和 html
+func NewDocumentExporter(format string) (DocumentExporter, error) {
+	exp, err := New(format)
+	if err != nil {
+		return nil, err
+	}
+	d, ok := exp.(DocumentExporter)
+	if !ok {
+		return nil, fmt.Errorf("导出格式 %s 不支持导出文档", format)
+	}
+	return d, nil
+}
+
+// ExportDocument 将文档保存为 Markdown 文件
+func (e *MarkdownExporter) ExportDocument(doc *Document) (string, error) {
+	return writeDocument(e.config.Output.Dir, doc.Kind+".md", "# "+doc.Title+"\n\n"+doc.Content+"\n")
+}
+
+// ExportDocument 将文档保存为 HTML 文件，样式与评审报告一致
+func (e *HTMLExporter) ExportDocument(doc *Document) (string, error) {
+	return writeDocument(e.config.Output.Dir, doc.Kind+".html", e.RenderDocument(doc))
+}
+
+// RenderDocument 生成文档的 HTML 内容
+func (e *HTMLExporter) RenderDocument(doc *Document) string {
+	title := template.HTMLEscapeString(doc.Title)
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>%s</title>
+    <style>%s</style>
+</head>
+<body>
+<div class="container">
+<h1>%s</h1>
+<div class="markdown-body">%s</div>
+<div class="footer">
+	生成时间：%s
+</div>
+</div></body></html>`, title, e.getCSS(), title, formatMarkdown(doc.Content), time.Now().Format("2006-01-02 15:04:05"))
+}
+
+// writeDocument 将文档写入输出目录，文件名以时间为前缀
+func writeDocument(outputDir, suffix, content string) (string, error) {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", fmt.Errorf("创建输出目录失败: %w", err)
+	}
+	outputPath := filepath.Join(outputDir, fmt.Sprintf("%s_%s", time.Now().Format("20060102_150405"), suffix))
+	if err := os.WriteFile(outputPath, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("保存文档失败: %w", err)
+	}
+	return outputPath, nil
+}
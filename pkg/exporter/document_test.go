@@ -0,0 +1,34 @@
+package exporter
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportDocument(t *testing.T) {
+	doc := &Document{Title: "PR 描述", Kind: "describe", Content: "## 概述\n\n- 支持 <html> 导出"}
+
+	exp, err := NewDocumentExporter("markdown")
+	assert.NoError(t, err)
+	path, err := exp.ExportDocument(doc)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasSuffix(path, "_describe.md"))
+	content, _ := os.ReadFile(path)
+	assert.Equal(t, "# PR 描述\n\n## 概述\n\n- 支持 <html> 导出\n", string(content))
+	os.Remove(path)
+
+	exp, err = NewDocumentExporter("html")
+	assert.NoError(t, err)
+	path, err = exp.ExportDocument(doc)
+	assert.NoError(t, err)
+	content, _ = os.ReadFile(path)
+	assert.Contains(t, string(content), "<title>PR 描述</title>")
+	assert.Contains(t, string(content), "<li>支持 &lt;html&gt; 导出</li>")
+	os.Remove(path)
+
+	_, err = NewDocumentExporter("junit")
+	assert.Error(t, err)
+}
@@ -1,6 +1,10 @@
 package exporter
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/icatw/cr-tool/pkg/review"
+)
 
 // New 创建导出器
 func New(format string) (Exporter, error) {
@@ -11,7 +15,32 @@ func New(format string) (Exporter, error) {
 		return NewHTMLExporter(), nil
 	case FormatPDF:
 		return NewPDFExporter(), nil
+	case FormatTerminal:
+		return NewTerminalExporter(), nil
+	case FormatJUnit:
+		return NewJUnitExporter(), nil
+	case FormatGitHub, FormatGitLab, FormatRDJSON, FormatCheckstyle, FormatErrorformat:
+		return NewAnnotationExporter(Format(format)), nil
 	default:
 		return nil, fmt.Errorf("不支持的导出格式: %s", format)
 	}
 }
+
+// Render 在内存中生成指定格式的报告内容，不在输出目录中保存文件
+func Render(format string, history *review.ReviewHistory) ([]byte, error) {
+	exp, err := New(format)
+	if err != nil {
+		return nil, err
+	}
+	switch e := exp.(type) {
+	case *HTMLExporter:
+		return []byte(e.Render(history)), nil
+	case *TerminalExporter:
+		e.Color = false
+		return e.Render(history)
+	case Renderer:
+		return e.Render(history)
+	default:
+		return nil, fmt.Errorf("格式 %s 不支持直接生成内容", format)
+	}
+}
@@ -23,6 +23,28 @@ func NewHTMLExporter() *HTMLExporter {
 }
 
 func (e *HTMLExporter) Export(history *review.ReviewHistory) (string, error) {
+	outputDir := e.config.Output.Dir
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", fmt.Errorf("创建输出目录失败: %w", err)
+	}
+
+	filename := fmt.Sprintf("%s_review.html", time.Now().Format("20060102_150405"))
+	outputPath := filepath.Join(outputDir, filename)
+
+	if err := os.WriteFile(outputPath, []byte(e.Render(history)), 0644); err != nil {
+		return "", fmt.Errorf("保存评审报告失败: %w", err)
+	}
+
+	return outputPath, nil
+}
+
+// RenderEmail 生成适合邮件客户端的 HTML，样式内联到元素的 style 属性中
+func (e *HTMLExporter) RenderEmail(history *review.ReviewHistory) string {
+	return inlineCSS(e.Render(history), e.getCSS()+emailCSS)
+}
+
+// Render 生成 HTML 报告内容
+func (e *HTMLExporter) Render(history *review.ReviewHistory) string {
 	var b strings.Builder
 
 	// 添加 HTML 头部和样式
@@ -84,6 +106,11 @@ func (e *HTMLExporter) Export(history *review.ReviewHistory) (string, error) {
 					<div class="stat-label">删除行数</div>
 				</div>
 			</div>`)
+		if len(history.ReviewStats.IgnoredFiles) > 0 {
+			b.WriteString(`<p class="ignored-files">忽略文件：`)
+			b.WriteString(template.HTMLEscapeString(strings.Join(history.ReviewStats.IgnoredFiles, ", ")))
+			b.WriteString(`</p>`)
+		}
 
 		// 问题级别统计
 		if len(history.ReviewStats.IssuesByLevel) > 0 {
@@ -100,6 +127,27 @@ func (e *HTMLExporter) Export(history *review.ReviewHistory) (string, error) {
 		b.WriteString(`</div>`)
 	}
 
+	// 问题列表
+	if len(history.Findings) > 0 {
+		b.WriteString(`<div class="findings">
+		<h2>问题列表</h2>
+		<table>
+			<tr><th>级别</th><th>位置</th><th>类别</th><th>问题</th></tr>`)
+		for _, f := range history.Findings {
+			b.WriteString(fmt.Sprintf(`<tr><td><span class="issue-level %s">%s</span></td><td><code>%s</code></td><td>%s</td><td>%s</td></tr>`,
+				template.HTMLEscapeString(string(f.Severity)),
+				template.HTMLEscapeString(string(f.Severity)),
+				template.HTMLEscapeString(f.Location()),
+				template.HTMLEscapeString(f.Category),
+				template.HTMLEscapeString(f.Title)))
+		}
+		b.WriteString(`</table>
+		</div>`)
+	}
+	if history.Suppressed > 0 {
+		b.WriteString(fmt.Sprintf(`<p class="suppressed">已忽略 %d 个已知问题（行内注释或基线）</p>`, history.Suppressed))
+	}
+
 	// 评审结果
 	b.WriteString(`<div class="review-result">
 		<h2>评审详情</h2>
@@ -115,20 +163,7 @@ func (e *HTMLExporter) Export(history *review.ReviewHistory) (string, error) {
 		</div>
 	</div></body></html>`, time.Now().Format("2006-01-02 15:04:05")))
 
-	// 保存文件
-	outputDir := e.config.Output.Dir
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return "", fmt.Errorf("创建输出目录失败: %w", err)
-	}
-
-	filename := fmt.Sprintf("%s_review.html", time.Now().Format("20060102_150405"))
-	outputPath := filepath.Join(outputDir, filename)
-
-	if err := os.WriteFile(outputPath, []byte(b.String()), 0644); err != nil {
-		return "", fmt.Errorf("保存评审报告失败: %w", err)
-	}
-
-	return outputPath, nil
+	return b.String()
 }
 
 // getCSS 获取 CSS 样式
@@ -172,6 +207,7 @@ func (e *HTMLExporter) getCSS() string {
 		}
 		.stat-value { font-size: 2rem; font-weight: bold; }
 		.stat-label { color: #666; margin-top: 0.5rem; }
+		.ignored-files { color: #666; font-size: 0.9rem; }
 		.issues-by-level {
 			display: flex;
 			gap: 1rem;
@@ -187,6 +223,15 @@ func (e *HTMLExporter) getCSS() string {
 		.issue-level.严重 { background: #ffebe9; color: #cf222e; }
 		.issue-level.中等 { background: #fff8c5; color: #9a6700; }
 		.issue-level.低 { background: #ddf4ff; color: #0969da; }
+		.findings table { width: 100%; border-collapse: collapse; }
+		.findings th, .findings td {
+			border: 1px solid var(--border-color);
+			padding: 0.5rem;
+			text-align: left;
+		}
+		.findings th { background: var(--bg-color); }
+		.findings .issue-level { display: inline-block; padding: 0.1rem 0.5rem; }
+		.suppressed { color: #666; font-size: 0.9rem; }
 		.review-result { margin-top: 2rem; }
 		.markdown-body {
 			background: white;
@@ -0,0 +1,22 @@
+package exporter
+
+import (
+	"testing"
+
+	"github.com/icatw/cr-tool/pkg/review"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTMLExporter_RenderEmail(t *testing.T) {
+	history := &review.ReviewHistory{
+		ReviewStats: &review.ReviewStats{IssuesByLevel: map[string]int{"严重": 1}},
+		Findings:    []review.Finding{{File: "main.go", Line: 3, Severity: review.SeverityHigh, Title: "空指针"}},
+	}
+
+	got := NewHTMLExporter().RenderEmail(history)
+	assert.NotContains(t, got, "<style>")
+	assert.NotContains(t, got, "var(--")
+	assert.Contains(t, got, `<span class="issue-level 严重" style="`)
+	assert.Contains(t, got, "background: #ffebe9")
+	assert.Contains(t, got, `<div class="stat-item" style="background: #f6f8fa;`)
+}
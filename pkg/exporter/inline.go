@@ -0,0 +1,251 @@
+package exporter
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// emailCSS 邮件客户端普遍不支持 grid/flex 和阴影，用行内块元素代替
+const emailCSS = `
+	.container { box-shadow: none; }
+	.stats-grid { display: block; }
+	.stat-item { display: inline-block; width: 160px; margin: 0 8px 8px 0; }
+	.issues-by-level { display: block; }
+	.issue-level { display: inline-block; margin: 0 8px 8px 0; }
+`
+
+var (
+	cssComment  = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	cssVar      = regexp.MustCompile(`var\((--[\w-]+)\)`)
+	htmlTag     = regexp.MustCompile(`<(/?)([a-zA-Z][a-zA-Z0-9]*)([^>]*)>`)
+	styleBlock  = regexp.MustCompile(`(?s)<style[^>]*>.*?</style>`)
+	classAttr   = regexp.MustCompile(`\sclass="([^"]*)"`)
+	styleAttr   = regexp.MustCompile(`\sstyle="([^"]*)"`)
+	voidElement = map[string]bool{"br": true, "hr": true, "img": true, "meta": true, "link": true, "input": true}
+)
+
+// compound 简单选择器，如 td、.stat-item、.issue-level.严重
+type compound struct {
+	tag     string
+	classes []string
+}
+
+// cssRule 单条样式规则，只支持标签、类和后代选择器
+type cssRule struct {
+	selector    []compound
+	decls       []declaration
+	specificity int
+	order       int
+}
+
+// declaration 样式声明
+type declaration struct {
+	property string
+	value    string
+}
+
+// element HTML 元素
+type element struct {
+	tag     string
+	classes []string
+}
+
+// inlineCSS 将样式表内联到元素的 style 属性中并移除 <style>，
+// 元素已有的 style 属性优先级最高
+func inlineCSS(html, css string) string {
+	rules := parseCSS(css)
+	html = styleBlock.ReplaceAllString(html, "")
+
+	var (
+		b     strings.Builder
+		stack []element
+		last  int
+	)
+	for _, m := range htmlTag.FindAllStringSubmatchIndex(html, -1) {
+		b.WriteString(html[last:m[0]])
+		last = m[1]
+
+		closing := html[m[2]:m[3]] == "/"
+		tag := strings.ToLower(html[m[4]:m[5]])
+		attrs := html[m[6]:m[7]]
+
+		if closing {
+			for i := len(stack) - 1; i >= 0; i-- {
+				if stack[i].tag == tag {
+					stack = stack[:i]
+					break
+				}
+			}
+			b.WriteString(html[m[0]:m[1]])
+			continue
+		}
+
+		el := element{tag: tag}
+		if cm := classAttr.FindStringSubmatch(attrs); cm != nil {
+			el.classes = strings.Fields(cm[1])
+		}
+
+		var matched []cssRule
+		for _, r := range rules {
+			if r.matches(el, stack) {
+				matched = append(matched, r)
+			}
+		}
+		if len(matched) > 0 {
+			attrs = applyStyle(attrs, matched)
+		}
+		b.WriteString("<" + html[m[4]:m[5]] + attrs + ">")
+
+		if !voidElement[tag] && !strings.HasSuffix(attrs, "/") {
+			stack = append(stack, el)
+		}
+	}
+	b.WriteString(html[last:])
+	return b.String()
+}
+
+// applyStyle 合并匹配规则的声明并写入 style 属性
+func applyStyle(attrs string, rules []cssRule) string {
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].specificity != rules[j].specificity {
+			return rules[i].specificity < rules[j].specificity
+		}
+		return rules[i].order < rules[j].order
+	})
+
+	var decls []declaration
+	for _, r := range rules {
+		decls = append(decls, r.decls...)
+	}
+	existing := styleAttr.FindStringSubmatch(attrs)
+	if existing != nil {
+		decls = append(decls, parseDeclarations(existing[1], nil)...)
+		attrs = strings.Replace(attrs, existing[0], "", 1)
+	}
+
+	// 同名属性以最后一次为准，保留首次出现的顺序
+	values := make(map[string]string)
+	var order []string
+	for _, d := range decls {
+		if _, ok := values[d.property]; !ok {
+			order = append(order, d.property)
+		}
+		values[d.property] = d.value
+	}
+	parts := make([]string, 0, len(order))
+	for _, p := range order {
+		parts = append(parts, p+": "+values[p])
+	}
+
+	style := ` style="` + strings.ReplaceAll(strings.Join(parts, "; "), `"`, "'") + `"`
+	if strings.HasSuffix(attrs, "/") {
+		return strings.TrimSuffix(attrs, "/") + style + " /"
+	}
+	return attrs + style
+}
+
+// matches 判断元素是否匹配规则，stack 为元素的祖先
+func (r cssRule) matches(el element, ancestors []element) bool {
+	n := len(r.selector)
+	if !r.selector[n-1].matches(el) {
+		return false
+	}
+	i := n - 2
+	for j := len(ancestors) - 1; j >= 0 && i >= 0; j-- {
+		if r.selector[i].matches(ancestors[j]) {
+			i--
+		}
+	}
+	return i < 0
+}
+
+// matches 判断元素是否匹配简单选择器
+func (c compound) matches(el element) bool {
+	if c.tag != "" && c.tag != el.tag {
+		return false
+	}
+	for _, class := range c.classes {
+		found := false
+		for _, have := range el.classes {
+			if have == class {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// parseCSS 解析样式表，:root 中的变量会被展开，不支持的选择器会被跳过
+func parseCSS(css string) []cssRule {
+	css = cssComment.ReplaceAllString(css, "")
+	vars := make(map[string]string)
+
+	var rules []cssRule
+	for _, block := range strings.Split(css, "}") {
+		selectors, body, ok := strings.Cut(block, "{")
+		if !ok {
+			continue
+		}
+		selectors = strings.TrimSpace(selectors)
+		if selectors == ":root" {
+			for _, d := range parseDeclarations(body, nil) {
+				vars[d.property] = d.value
+			}
+			continue
+		}
+
+		decls := parseDeclarations(body, vars)
+		for _, sel := range strings.Split(selectors, ",") {
+			rule, ok := parseSelector(strings.TrimSpace(sel))
+			if !ok {
+				continue
+			}
+			rule.decls = decls
+			rule.order = len(rules)
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// parseSelector 解析后代选择器，包含伪类、属性或子代选择器时返回 false
+func parseSelector(sel string) (cssRule, bool) {
+	if sel == "" || strings.ContainsAny(sel, ":[>+~*@#") {
+		return cssRule{}, false
+	}
+	var rule cssRule
+	for _, part := range strings.Fields(sel) {
+		names := strings.Split(part, ".")
+		c := compound{tag: strings.ToLower(names[0]), classes: names[1:]}
+		rule.selector = append(rule.selector, c)
+		rule.specificity += 10 * len(c.classes)
+		if c.tag != "" {
+			rule.specificity++
+		}
+	}
+	return rule, true
+}
+
+// parseDeclarations 解析声明列表并展开变量
+func parseDeclarations(body string, vars map[string]string) []declaration {
+	var decls []declaration
+	for _, item := range strings.Split(body, ";") {
+		property, value, ok := strings.Cut(item, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if vars != nil {
+			value = cssVar.ReplaceAllStringFunc(value, func(v string) string {
+				return vars[cssVar.FindStringSubmatch(v)[1]]
+			})
+		}
+		decls = append(decls, declaration{property: strings.TrimSpace(property), value: value})
+	}
+	return decls
+}
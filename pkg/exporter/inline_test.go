@@ -0,0 +1,25 @@
+package exporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInlineCSS(t *testing.T) {
+	css := `
+		:root { --border: #ccc; }
+		p { color: red; margin: 0; }
+		.note { color: blue; }
+		.box p { border: 1px solid var(--border); }
+		a:hover { color: green; }
+	`
+	html := `<html><head><style>p { color: red; }</style></head><body>` +
+		`<p>a</p><div class="box"><p class="note" style="margin: 4px">b</p><br></div><p>c</p></body></html>`
+
+	got := inlineCSS(html, css)
+	assert.NotContains(t, got, "<style>")
+	assert.Contains(t, got, `<p style="color: red; margin: 0">a</p>`)
+	assert.Contains(t, got, `<p class="note" style="color: blue; margin: 4px; border: 1px solid #ccc">b</p>`)
+	assert.Contains(t, got, `</div><p style="color: red; margin: 0">c</p>`)
+}
@@ -0,0 +1,163 @@
+package exporter
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/icatw/cr-tool/pkg/config"
+	"github.com/icatw/cr-tool/pkg/review"
+)
+
+// JUnitExporter 将评审结果导出为 JUnit XML，每个评审的文件是一个测试用例，
+// 达到 output.junit_fail_on 级别的问题作为失败，其余问题记录在用例输出中
+type JUnitExporter struct {
+	config *config.Config
+}
+
+// NewJUnitExporter 创建 JUnit 导出器
+func NewJUnitExporter() *JUnitExporter {
+	return &JUnitExporter{
+		config: config.Get(),
+	}
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+type junitTestCase struct {
+	Name      string         `xml:"name,attr"`
+	ClassName string         `xml:"classname,attr"`
+	Failures  []junitFailure `xml:"failure"`
+	Skipped   *junitSkipped  `xml:"skipped"`
+	SystemOut string         `xml:"system-out,omitempty"`
+}
+
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+// Render 生成 JUnit XML
+func (e *JUnitExporter) Render(history *review.ReviewHistory) ([]byte, error) {
+	threshold := review.SeverityMedium
+	if e.config.Output.JUnitFailOn != "" {
+		severity, ok := review.ParseSeverity(e.config.Output.JUnitFailOn)
+		if !ok {
+			return nil, fmt.Errorf("无效的问题级别: %s", e.config.Output.JUnitFailOn)
+		}
+		threshold = severity
+	}
+
+	ignored := make(map[string]bool)
+	files := make(map[string]bool)
+	if history.ReviewStats != nil {
+		for _, name := range history.ReviewStats.IgnoredFiles {
+			ignored[name] = true
+		}
+		for name := range history.ReviewStats.FileChanges {
+			files[name] = true
+		}
+	}
+	findings := make(map[string][]review.Finding)
+	for _, f := range history.Findings {
+		files[f.File] = true
+		findings[f.File] = append(findings[f.File], f)
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		if !ignored[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	suite := junitTestSuite{Name: "cr-tool"}
+	if !history.DateTime.IsZero() {
+		suite.Timestamp = history.DateTime.Format("2006-01-02T15:04:05")
+	}
+	for _, name := range names {
+		tc := junitTestCase{Name: name, ClassName: "cr-tool"}
+		if name == "" {
+			// 模型没有给出文件的问题
+			tc.Name = "(其他)"
+		}
+		var out []string
+		for _, f := range findings[name] {
+			if f.Severity.Rank() >= threshold.Rank() {
+				tc.Failures = append(tc.Failures, junitFailure{
+					Message: findingMessage(f),
+					Type:    string(f.Severity),
+					Text:    f.Location() + " " + f.Title,
+				})
+			} else {
+				out = append(out, fmt.Sprintf("%s [%s] %s", f.Location(), f.Severity, strings.ReplaceAll(findingMessage(f), "\n", " ")))
+			}
+		}
+		tc.SystemOut = strings.Join(out, "\n")
+		if len(tc.Failures) > 0 {
+			suite.Failures++
+		}
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+	ignoredNames := make([]string, 0, len(ignored))
+	for name := range ignored {
+		ignoredNames = append(ignoredNames, name)
+	}
+	sort.Strings(ignoredNames)
+	for _, name := range ignoredNames {
+		suite.TestCases = append(suite.TestCases, junitTestCase{
+			Name:      name,
+			ClassName: "cr-tool",
+			Skipped:   &junitSkipped{Message: "已忽略的文件"},
+		})
+		suite.Skipped++
+	}
+	suite.Tests = len(suite.TestCases)
+
+	data, err := xml.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+// Export 将 JUnit XML 保存到输出目录
+func (e *JUnitExporter) Export(history *review.ReviewHistory) (string, error) {
+	data, err := e.Render(history)
+	if err != nil {
+		return "", err
+	}
+
+	outputDir := e.config.Output.Dir
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", fmt.Errorf("创建输出目录失败: %w", err)
+	}
+
+	filename := fmt.Sprintf("%s_review.junit.xml", time.Now().Format("20060102_150405"))
+	outputPath := filepath.Join(outputDir, filename)
+
+	if err := os.WriteFile(outputPath, data, 0644); err != nil {
+		return "", fmt.Errorf("保存评审报告失败: %w", err)
+	}
+
+	return outputPath, nil
+}
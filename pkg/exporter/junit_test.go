@@ -0,0 +1,33 @@
+package exporter
+
+import (
+	"testing"
+
+	"github.com/icatw/cr-tool/pkg/review"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJUnitExporter_Render(t *testing.T) {
+	history := &review.ReviewHistory{
+		ReviewStats: &review.ReviewStats{
+			FileChanges:  map[string]int{"a.go": 3, "b.go": 1, "vendor/x.go": 2},
+			IgnoredFiles: []string{"vendor/x.go"},
+		},
+		Findings: []review.Finding{
+			{File: "a.go", Line: 3, Severity: review.SeverityHigh, Title: "空指针", Detail: "未检查 nil"},
+			{File: "a.go", Line: 8, Severity: review.SeverityLow, Title: "命名"},
+			{File: "b.go", Line: 1, Severity: review.SeverityLow, Title: "注释"},
+		},
+	}
+
+	data, err := NewJUnitExporter().Render(history)
+	assert.NoError(t, err)
+	got := string(data)
+	assert.Contains(t, got, `<testsuite name="cr-tool" tests="3" failures="1" errors="0" skipped="1">`)
+	assert.Contains(t, got, `<testcase name="a.go" classname="cr-tool">`)
+	assert.Contains(t, got, `<failure message="空指针&#xA;未检查 nil" type="严重">a.go:3 空指针</failure>`)
+	assert.Contains(t, got, `<system-out>a.go:8 [低] 命名</system-out>`)
+	assert.Contains(t, got, `<testcase name="b.go" classname="cr-tool">`)
+	assert.Contains(t, got, `<testcase name="vendor/x.go" classname="cr-tool">`)
+	assert.Contains(t, got, `<skipped message="已忽略的文件"></skipped>`)
+}
@@ -0,0 +1,25 @@
+package exporter
+
+import (
+	"os"
+	"testing"
+
+	"github.com/icatw/cr-tool/pkg/config"
+)
+
+// TestMain 将报告导出到临时目录
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "cr-exporter")
+	if err != nil {
+		panic(err)
+	}
+	config.Set(&config.Config{
+		Output: config.OutputConfig{
+			Dir:    dir,
+			Format: []string{"markdown"},
+		},
+	})
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
@@ -22,6 +22,28 @@ func NewMarkdownExporter() *MarkdownExporter {
 }
 
 func (e *MarkdownExporter) Export(history *review.ReviewHistory) (string, error) {
+	data, err := e.Render(history)
+	if err != nil {
+		return "", err
+	}
+
+	outputDir := e.config.Output.Dir
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", fmt.Errorf("创建输出目录失败: %w", err)
+	}
+
+	filename := fmt.Sprintf("%s_review.md", time.Now().Format("20060102_150405"))
+	outputPath := filepath.Join(outputDir, filename)
+
+	if err := os.WriteFile(outputPath, data, 0644); err != nil {
+		return "", fmt.Errorf("保存评审报告失败: %w", err)
+	}
+
+	return outputPath, nil
+}
+
+// Render 生成 Markdown 报告内容
+func (e *MarkdownExporter) Render(history *review.ReviewHistory) ([]byte, error) {
 	var md strings.Builder
 
 	// 添加标题
@@ -41,7 +63,11 @@ func (e *MarkdownExporter) Export(history *review.ReviewHistory) (string, error)
 		md.WriteString("## 变更统计\n\n")
 		md.WriteString(fmt.Sprintf("- 变更文件数: %d\n", history.ReviewStats.FilesChanged))
 		md.WriteString(fmt.Sprintf("- 新增行数: %d\n", history.ReviewStats.LinesAdded))
-		md.WriteString(fmt.Sprintf("- 删除行数: %d\n\n", history.ReviewStats.LinesDeleted))
+		md.WriteString(fmt.Sprintf("- 删除行数: %d\n", history.ReviewStats.LinesDeleted))
+		if len(history.ReviewStats.IgnoredFiles) > 0 {
+			md.WriteString(fmt.Sprintf("- 忽略文件: %s\n", strings.Join(history.ReviewStats.IgnoredFiles, ", ")))
+		}
+		md.WriteString("\n")
 
 		if len(history.ReviewStats.IssuesByLevel) > 0 {
 			md.WriteString("### 问题级别统计\n\n")
@@ -52,22 +78,30 @@ func (e *MarkdownExporter) Export(history *review.ReviewHistory) (string, error)
 		}
 	}
 
+	// 问题列表
+	if len(history.Findings) > 0 {
+		md.WriteString("## 问题列表\n\n")
+		md.WriteString("| 级别 | 位置 | 类别 | 问题 |\n")
+		md.WriteString("|------|------|------|------|\n")
+		for _, f := range history.Findings {
+			md.WriteString(fmt.Sprintf("| %s | `%s` | %s | %s |\n",
+				f.Severity, f.Location(), f.Category, escapeTableCell(f.Title)))
+		}
+		md.WriteString("\n")
+	}
+	if history.Suppressed > 0 {
+		md.WriteString(fmt.Sprintf("> 已忽略 %d 个已知问题（行内注释或基线）\n\n", history.Suppressed))
+	}
+
 	// 评审结果
 	md.WriteString("## 评审详情\n\n")
 	md.WriteString(history.ReviewResult)
 
-	// 保存文件
-	outputDir := e.config.Output.Dir
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return "", fmt.Errorf("创建输出目录失败: %w", err)
-	}
-
-	filename := fmt.Sprintf("%s_review.md", time.Now().Format("20060102_150405"))
-	outputPath := filepath.Join(outputDir, filename)
-
-	if err := os.WriteFile(outputPath, []byte(md.String()), 0644); err != nil {
-		return "", fmt.Errorf("保存评审报告失败: %w", err)
-	}
+	return []byte(md.String()), nil
+}
 
-	return outputPath, nil
+// escapeTableCell 转义 Markdown 表格单元格中的特殊字符
+func escapeTableCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
 }
@@ -26,12 +26,10 @@ func NewPDFExporter() *PDFExporter {
 }
 
 func (e *PDFExporter) Export(history *review.ReviewHistory) (string, error) {
-	// 首先生成 HTML
-	htmlPath, err := e.htmlExporter.Export(history)
+	pdfData, err := e.Render(history)
 	if err != nil {
-		return "", fmt.Errorf("生成 HTML 失败: %w", err)
+		return "", err
 	}
-	defer os.Remove(htmlPath) // 清理临时 HTML 文件
 
 	// 创建输出目录
 	outputDir := e.config.Output.Dir
@@ -43,6 +41,27 @@ func (e *PDFExporter) Export(history *review.ReviewHistory) (string, error) {
 	filename := fmt.Sprintf("%s_review.pdf", time.Now().Format("20060102_150405"))
 	outputPath := filepath.Join(outputDir, filename)
 
+	// 保存 PDF 文件
+	if err := os.WriteFile(outputPath, pdfData, 0644); err != nil {
+		return "", fmt.Errorf("保存 PDF 文件失败: %w", err)
+	}
+
+	return outputPath, nil
+}
+
+// Render 生成 PDF 内容，HTML 写入临时目录供浏览器打开，完成后删除
+func (e *PDFExporter) Render(history *review.ReviewHistory) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "cr-pdf-")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	htmlPath := filepath.Join(dir, "review.html")
+	if err := os.WriteFile(htmlPath, []byte(e.htmlExporter.Render(history)), 0644); err != nil {
+		return nil, fmt.Errorf("生成 HTML 失败: %w", err)
+	}
+
 	// 创建 Chrome 实例
 	ctx, cancel := chromedp.NewContext(context.Background())
 	defer cancel()
@@ -70,13 +89,7 @@ func (e *PDFExporter) Export(history *review.ReviewHistory) (string, error) {
 			return nil
 		}),
 	); err != nil {
-		return "", fmt.Errorf("生成 PDF 失败: %w", err)
-	}
-
-	// 保存 PDF 文件
-	if err := os.WriteFile(outputPath, pdfData, 0644); err != nil {
-		return "", fmt.Errorf("保存 PDF 文件失败: %w", err)
+		return nil, fmt.Errorf("生成 PDF 失败: %w", err)
 	}
-
-	return outputPath, nil
+	return pdfData, nil
 }
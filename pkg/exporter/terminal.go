@@ -0,0 +1,255 @@
+package exporter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/icatw/cr-tool/pkg/config"
+	"github.com/icatw/cr-tool/pkg/review"
+	"github.com/icatw/cr-tool/pkg/term"
+)
+
+// ANSI 样式
+const (
+	ansiBold      = "1"
+	ansiDim       = "2"
+	ansiUnderline = "4"
+	ansiRed       = "31"
+	ansiGreen     = "32"
+	ansiYellow    = "33"
+	ansiBlue      = "34"
+	ansiMagenta   = "35"
+	ansiCyan      = "36"
+)
+
+var (
+	mdHeading    = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	mdListItem   = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+(.*)$`)
+	mdRule       = regexp.MustCompile(`^\s*(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+	mdBold       = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	mdInlineCode = regexp.MustCompile("`([^`]+)`")
+)
+
+// severityStyles 问题级别徽标的颜色
+var severityStyles = map[review.Severity]string{
+	review.SeverityHigh:   ansiRed,
+	review.SeverityMedium: ansiYellow,
+	review.SeverityLow:    ansiBlue,
+}
+
+// TerminalExporter 在终端中显示评审结果，按文件分组列出问题并渲染评审详情中的 Markdown
+type TerminalExporter struct {
+	config *config.Config
+	// Color 是否输出 ANSI 颜色，设置了 NO_COLOR 环境变量时默认关闭
+	Color bool
+	// Width 折行宽度
+	Width int
+}
+
+// NewTerminalExporter 创建终端导出器
+func NewTerminalExporter() *TerminalExporter {
+	return &TerminalExporter{
+		config: config.Get(),
+		Color:  os.Getenv("NO_COLOR") == "",
+		Width:  80,
+	}
+}
+
+// Render 生成终端显示的内容
+func (e *TerminalExporter) Render(history *review.ReviewHistory) ([]byte, error) {
+	width := e.Width
+	if width < 40 {
+		width = 40
+	}
+	var b strings.Builder
+
+	b.WriteString(e.paint("代码评审报告", ansiBold, ansiMagenta) + "\n")
+	if g := history.GitInfo; g != nil && (g.Branch != "" || g.CommitHash != "") {
+		commit := g.CommitHash
+		if len(commit) > 8 {
+			commit = commit[:8]
+		}
+		b.WriteString(e.paint(fmt.Sprintf("%s @ %s  %s", g.Branch, commit, g.Author), ansiDim) + "\n")
+	}
+	if s := history.ReviewStats; s != nil {
+		b.WriteString(fmt.Sprintf("%d 个文件  %s  %s\n", s.FilesChanged,
+			e.paint(fmt.Sprintf("+%d", s.LinesAdded), ansiGreen), e.paint(fmt.Sprintf("-%d", s.LinesDeleted), ansiRed)))
+	}
+
+	// 问题按文件分组，文件内按行号排序
+	if len(history.Findings) > 0 {
+		groups := make(map[string][]review.Finding)
+		var files []string
+		for _, f := range history.Findings {
+			if _, ok := groups[f.File]; !ok {
+				files = append(files, f.File)
+			}
+			groups[f.File] = append(groups[f.File], f)
+		}
+
+		b.WriteString("\n" + e.paint(fmt.Sprintf("问题（%d）", len(history.Findings)), ansiBold) + "\n")
+		for _, file := range files {
+			findings := groups[file]
+			sort.SliceStable(findings, func(i, j int) bool { return findings[i].Line < findings[j].Line })
+			name := file
+			if name == "" {
+				name = "(其他)"
+			}
+			b.WriteString("\n" + e.paint(name, ansiBold, ansiUnderline) + "\n")
+			for _, f := range findings {
+				b.WriteString(e.finding(f, width))
+			}
+		}
+	}
+	if history.Suppressed > 0 {
+		b.WriteString("\n" + e.paint(fmt.Sprintf("已忽略 %d 个已知问题（行内注释或基线）", history.Suppressed), ansiDim) + "\n")
+	}
+
+	if strings.TrimSpace(history.ReviewResult) != "" {
+		b.WriteString("\n" + e.paint(strings.Repeat("─", width), ansiDim) + "\n")
+		b.WriteString(e.markdown(history.ReviewResult, width))
+	}
+	return []byte(b.String()), nil
+}
+
+// Export 将不带颜色的终端内容保存到输出目录
+func (e *TerminalExporter) Export(history *review.ReviewHistory) (string, error) {
+	plain := *e
+	plain.Color = false
+	data, err := plain.Render(history)
+	if err != nil {
+		return "", err
+	}
+
+	outputDir := e.config.Output.Dir
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", fmt.Errorf("创建输出目录失败: %w", err)
+	}
+
+	filename := fmt.Sprintf("%s_review.txt", time.Now().Format("20060102_150405"))
+	outputPath := filepath.Join(outputDir, filename)
+
+	if err := os.WriteFile(outputPath, data, 0644); err != nil {
+		return "", fmt.Errorf("保存评审报告失败: %w", err)
+	}
+
+	return outputPath, nil
+}
+
+// finding 单个问题：级别徽标、行号和标题，说明和建议缩进显示
+func (e *TerminalExporter) finding(f review.Finding, width int) string {
+	badge := "[" + string(f.Severity) + "]"
+	if e.Color {
+		color, ok := severityStyles[f.Severity]
+		if !ok {
+			color = ansiDim
+		}
+		// 不换行空格，避免折行时被去掉
+		badge = e.paint("\u00a0"+string(f.Severity)+"\u00a0", ansiBold, "7", color)
+	}
+	location := ""
+	if f.Line > 0 {
+		location = e.paint(fmt.Sprintf("L%d", f.Line), ansiDim) + " "
+	}
+	title := f.Title
+	if f.Category != "" {
+		title += " " + e.paint("("+f.Category+")", ansiDim)
+	}
+
+	var b strings.Builder
+	b.WriteString(term.Wrap(badge+" "+location+title, width, "  ", "      ") + "\n")
+	if f.Detail != "" {
+		b.WriteString(term.Wrap(e.inline(f.Detail), width, "      ", "      ") + "\n")
+	}
+	if f.Suggestion != "" {
+		b.WriteString(term.Wrap(e.paint("建议：", ansiGreen)+e.inline(f.Suggestion), width, "      ", "      ") + "\n")
+	}
+	return b.String()
+}
+
+// markdown 渲染评审详情：标题、列表、引用和代码块，段落按宽度折行
+func (e *TerminalExporter) markdown(text string, width int) string {
+	var (
+		b      strings.Builder
+		inCode bool
+	)
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inCode = !inCode
+			if inCode {
+				lang := strings.TrimPrefix(trimmed, "```")
+				b.WriteString(e.paint("  ┌ "+lang, ansiDim) + "\n")
+			} else {
+				b.WriteString(e.paint("  └", ansiDim) + "\n")
+			}
+			continue
+		}
+		if inCode {
+			// 代码不折行，保持原样
+			b.WriteString(e.paint("  │ ", ansiDim) + e.paint(strings.ReplaceAll(line, "\t", "    "), ansiCyan) + "\n")
+			continue
+		}
+
+		switch {
+		case trimmed == "":
+			b.WriteString("\n")
+		case mdRule.MatchString(line):
+			b.WriteString(e.paint(strings.Repeat("─", width), ansiDim) + "\n")
+		case mdHeading.MatchString(trimmed):
+			m := mdHeading.FindStringSubmatch(trimmed)
+			color := ansiBlue
+			if len(m[1]) <= 2 {
+				color = ansiMagenta
+			}
+			heading := mdBold.ReplaceAllString(m[2], "$1")
+			b.WriteString(term.Wrap(e.paint(heading, ansiBold, color), width, "", "") + "\n")
+		case strings.HasPrefix(trimmed, "|"):
+			// 表格不折行
+			b.WriteString(e.inline(line) + "\n")
+		case strings.HasPrefix(trimmed, ">"):
+			quote := strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))
+			prefix := e.paint("│ ", ansiDim)
+			b.WriteString(term.Wrap(e.paint(e.inline(quote), ansiDim), width, prefix, prefix) + "\n")
+		case mdListItem.MatchString(line):
+			m := mdListItem.FindStringSubmatch(line)
+			indent := strings.Repeat(" ", len(strings.ReplaceAll(m[1], "\t", "  ")))
+			bullet := m[2]
+			if bullet == "-" || bullet == "*" || bullet == "+" {
+				bullet = "•"
+			}
+			first := indent + e.paint(bullet, ansiCyan) + " "
+			rest := indent + strings.Repeat(" ", term.Width(bullet)+1)
+			b.WriteString(term.Wrap(e.inline(m[3]), width, first, rest) + "\n")
+		default:
+			b.WriteString(term.Wrap(e.inline(trimmed), width, "", "") + "\n")
+		}
+	}
+	return b.String()
+}
+
+// inline 渲染行内的粗体和代码
+func (e *TerminalExporter) inline(s string) string {
+	s = mdBold.ReplaceAllStringFunc(s, func(m string) string {
+		return e.paint(mdBold.FindStringSubmatch(m)[1], ansiBold)
+	})
+	if !e.Color {
+		return s
+	}
+	return mdInlineCode.ReplaceAllStringFunc(s, func(m string) string {
+		return e.paint(mdInlineCode.FindStringSubmatch(m)[1], ansiCyan)
+	})
+}
+
+// paint 为文本添加 ANSI 样式，关闭颜色时原样返回
+func (e *TerminalExporter) paint(s string, codes ...string) string {
+	if !e.Color || s == "" {
+		return s
+	}
+	return "\x1b[" + strings.Join(codes, ";") + "m" + s + "\x1b[0m"
+}
@@ -0,0 +1,54 @@
+package exporter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/icatw/cr-tool/pkg/review"
+	"github.com/icatw/cr-tool/pkg/term"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerminalExporter_Render(t *testing.T) {
+	history := &review.ReviewHistory{
+		GitInfo:     &review.GitInfo{Branch: "main", CommitHash: "0123456789abcdef", Author: "dev"},
+		ReviewStats: &review.ReviewStats{FilesChanged: 2, LinesAdded: 10, LinesDeleted: 3},
+		Findings: []review.Finding{
+			{File: "b.go", Line: 9, Severity: review.SeverityLow, Title: "命名"},
+			{File: "a.go", Line: 12, Severity: review.SeverityMedium, Title: "循环中分配", Category: "performance"},
+			{File: "b.go", Line: 2, Severity: review.SeverityHigh, Title: "空指针", Detail: "调用前没有检查 `err` 是否为空，返回值可能为 nil 导致程序崩溃", Suggestion: "先判断错误"},
+		},
+		ReviewResult: "## 总结\n\n整体 **不错**。\n\n- 第一点说明比较长的内容需要折行显示在终端里面\n\n```go\nif err != nil {\n\treturn err\n}\n```",
+	}
+
+	exp := NewTerminalExporter()
+	exp.Color = false
+	exp.Width = 40
+	data, err := exp.Render(history)
+	assert.NoError(t, err)
+	got := string(data)
+	assert.NotContains(t, got, "\x1b[")
+	assert.Contains(t, got, "main @ 01234567  dev")
+	assert.Contains(t, got, "2 个文件  +10  -3")
+	assert.Less(t, strings.Index(got, "\nb.go\n"), strings.Index(got, "\na.go\n"), "按问题出现的顺序分组")
+	assert.Less(t, strings.Index(got, "L2 空指针"), strings.Index(got, "L9 命名"), "文件内按行号排序")
+	assert.Contains(t, got, "  [中等] L12 循环中分配 (performance)\n")
+	assert.Contains(t, got, "      建议：先判断错误\n")
+	assert.Contains(t, got, "整体 不错。")
+	assert.Contains(t, got, "  │     return err\n")
+	for _, line := range strings.Split(got, "\n") {
+		if !strings.Contains(line, "│") {
+			assert.LessOrEqual(t, term.Width(line), 40, line)
+		}
+	}
+	assert.Contains(t, got, "• 第一点说明比较长的内容需要折行显示在终\n  端里面")
+
+	exp.Color = true
+	data, err = exp.Render(history)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "\x1b[1;7;31m\u00a0严重\u00a0\x1b[0m")
+	assert.Contains(t, string(data), "\x1b[36merr\x1b[0m")
+
+	t.Setenv("NO_COLOR", "1")
+	assert.False(t, NewTerminalExporter().Color)
+}
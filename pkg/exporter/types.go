@@ -8,6 +8,11 @@ type Exporter interface {
 	Export(history *review.ReviewHistory) (string, error)
 }
 
+// Renderer 可以在内存中生成报告内容而不保存文件的导出器
+type Renderer interface {
+	Render(history *review.ReviewHistory) ([]byte, error)
+}
+
 // Format 导出格式
 type Format string
 
@@ -15,4 +20,24 @@ const (
 	FormatMarkdown Format = "markdown"
 	FormatHTML     Format = "html"
 	FormatPDF      Format = "pdf"
+	FormatJUnit    Format = "junit"
+	FormatTerminal Format = "terminal"
+
+	// 注解格式
+	FormatGitHub      Format = "github"
+	FormatGitLab      Format = "gitlab"
+	FormatRDJSON      Format = "rdjson"
+	FormatCheckstyle  Format = "checkstyle"
+	FormatErrorformat Format = "errorformat"
 )
+
+// Streamed 命令行是否将该格式的内容直接打印到标准输出而不是保存文件，
+// 终端格式和注解格式直接输出，便于接入 CI 注解和编辑器
+func (f Format) Streamed() bool {
+	switch f {
+	case FormatTerminal, FormatGitHub, FormatGitLab, FormatRDJSON, FormatCheckstyle, FormatErrorformat:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,191 @@
+// Package fix 让模型为评审问题生成补丁，校验补丁能干净地应用后写入工作区，
+// 并在校验命令失败时回滚
+package fix
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/icatw/cr-tool/pkg/diff"
+	"github.com/icatw/cr-tool/pkg/review"
+)
+
+// Patch 一个问题的修复补丁
+type Patch struct {
+	Finding review.Finding
+	// Diff 重新计算行数后的 unified diff
+	Diff string
+
+	root    string
+	changes []change
+}
+
+// change 补丁对单个文件的修改
+type change struct {
+	path    string
+	old     []byte
+	existed bool
+	content string
+}
+
+// Generate 读取问题所在文件的当前内容，请求模型生成补丁并校验。
+// reviewed 为评审时该文件的 diff，不为空时先核对问题所在的行是否已经变化
+func Generate(reviewer *review.Reviewer, root string, f review.Finding, reviewed *diff.File) (*Patch, error) {
+	if f.File == "" {
+		return nil, errors.New("该问题没有对应的文件")
+	}
+	path := filepath.Join(root, filepath.FromSlash(f.File))
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件失败: %w", err)
+	}
+	// 整个文件都会发送给模型，与追问附加文件使用相同的大小上限
+	if info.Size() > review.MaxContextFileSize {
+		return nil, fmt.Errorf("文件 %s 超过大小限制: %d > %d bytes", f.File, info.Size(), review.MaxContextFileSize)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件失败: %w", err)
+	}
+	if reviewed != nil && f.Line > 0 {
+		end := f.EndLine
+		if end < f.Line {
+			end = f.Line
+		}
+		if err := reviewed.CheckLines(string(data), f.Line, end); err != nil {
+			return nil, err
+		}
+	}
+
+	content, _, err := reviewer.Patch(f, string(data))
+	if err != nil {
+		return nil, err
+	}
+	return Prepare(root, f, content)
+}
+
+// Prepare 解析补丁，并在工作区的当前内容上校验能否干净地应用。
+// 补丁只能修改问题所在的文件，不能修改 .git 目录
+func Prepare(root string, f review.Finding, content string) (*Patch, error) {
+	target := cleanPath(f.File)
+	content = diff.Recount(content)
+	files := diff.Parse(content)
+	p := &Patch{Finding: f, Diff: content, root: root}
+	for _, file := range files {
+		if len(file.Hunks) == 0 {
+			continue
+		}
+		if file.IsDeleted() {
+			return nil, fmt.Errorf("补丁不能删除文件 %s", file.Name())
+		}
+		name := cleanPath(file.Name())
+		if name == "." || filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") ||
+			name == ".git" || strings.HasPrefix(name, ".git/") {
+			return nil, fmt.Errorf("补丁中的路径无效: %s", file.Name())
+		}
+		if name != target {
+			return nil, fmt.Errorf("补丁修改了问题之外的文件: %s", file.Name())
+		}
+
+		c := change{path: filepath.Join(root, filepath.FromSlash(name))}
+		data, err := os.ReadFile(c.path)
+		switch {
+		case err == nil:
+			c.old, c.existed = data, true
+		case !os.IsNotExist(err):
+			return nil, fmt.Errorf("读取文件失败: %w", err)
+		}
+		if c.content, err = file.Apply(string(data)); err != nil {
+			return nil, fmt.Errorf("补丁无法应用到 %s: %w", name, err)
+		}
+		p.changes = append(p.changes, c)
+	}
+	if len(p.changes) == 0 {
+		return nil, errors.New("补丁中没有变更")
+	}
+	return p, nil
+}
+
+// cleanPath 规范化相对仓库根目录的路径，统一使用 / 分隔
+func cleanPath(name string) string {
+	return filepath.ToSlash(filepath.Clean(filepath.FromSlash(name)))
+}
+
+// Files 返回补丁修改的文件，相对仓库根目录
+func (p *Patch) Files() []string {
+	var files []string
+	for _, c := range p.changes {
+		rel, err := filepath.Rel(p.root, c.path)
+		if err != nil {
+			rel = c.path
+		}
+		files = append(files, filepath.ToSlash(rel))
+	}
+	return files
+}
+
+// Apply 将补丁写入工作区，返回撤销修改的函数。
+// 写入前再次核对文件内容，避免覆盖生成补丁之后发生的修改
+func (p *Patch) Apply() (func() error, error) {
+	for _, c := range p.changes {
+		data, err := os.ReadFile(c.path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if (err == nil) != c.existed || string(data) != string(c.old) {
+			return nil, fmt.Errorf("文件 %s 在生成补丁后发生了变化", c.path)
+		}
+	}
+
+	var applied []change
+	rollback := func() error {
+		var errs []error
+		for i := len(applied) - 1; i >= 0; i-- {
+			c := applied[i]
+			if c.existed {
+				errs = append(errs, writeFile(c.path, c.old))
+			} else {
+				errs = append(errs, os.Remove(c.path))
+			}
+		}
+		return errors.Join(errs...)
+	}
+	for _, c := range p.changes {
+		if !c.existed {
+			if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+				rollback()
+				return nil, err
+			}
+		}
+		if err := writeFile(c.path, []byte(c.content)); err != nil {
+			rollback()
+			return nil, err
+		}
+		applied = append(applied, c)
+	}
+	return rollback, nil
+}
+
+// writeFile 写入文件，保留已有文件的权限
+func writeFile(path string, data []byte) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	return os.WriteFile(path, data, mode)
+}
+
+// Verify 在 root 目录下通过 shell 执行校验命令，返回命令输出
+func Verify(root, command string) (string, error) {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Dir = root
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return string(out), fmt.Errorf("校验命令执行失败: %w", err)
+	}
+	return string(out), nil
+}
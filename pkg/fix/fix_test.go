@@ -0,0 +1,90 @@
+package fix
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/icatw/cr-tool/pkg/review"
+	"github.com/stretchr/testify/assert"
+)
+
+const patch = `--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@
+ package main
+-var x = 1
++var x = 2
+`
+
+func TestApply(t *testing.T) {
+	root := t.TempDir()
+	original := "package main\nvar x = 1\n\nfunc main() {}\n"
+	path := filepath.Join(root, "main.go")
+	assert.NoError(t, os.WriteFile(path, []byte(original), 0600))
+
+	f := review.Finding{File: "main.go", Line: 2, Title: "魔法数字"}
+	p, err := Prepare(root, f, patch)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"main.go"}, p.Files())
+
+	rollback, err := p.Apply()
+	assert.NoError(t, err)
+	data, _ := os.ReadFile(path)
+	assert.Equal(t, "package main\nvar x = 2\n\nfunc main() {}\n", string(data))
+	info, _ := os.Stat(path)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// 应用后文件已变化，再次应用会失败
+	_, err = p.Apply()
+	assert.Error(t, err)
+
+	assert.NoError(t, rollback())
+	data, _ = os.ReadFile(path)
+	assert.Equal(t, original, string(data))
+}
+
+func TestPrepareInvalid(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\nvar x = 3\n\n"), 0644))
+	f := review.Finding{File: "main.go"}
+
+	_, err := Prepare(root, f, patch)
+	assert.Error(t, err)
+	_, err = Prepare(root, f, "--- a/../x.go\n+++ b/../x.go\n@@ -0,0 +1 @@\n+x\n")
+	assert.Error(t, err)
+	_, err = Prepare(root, f, "没有补丁")
+	assert.Error(t, err)
+}
+
+func TestPrepareOtherFile(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\nvar x = 1\n\n"), 0644))
+	f := review.Finding{File: "main.go", Line: 2}
+
+	// 补丁只能修改问题所在的文件
+	_, err := Prepare(root, f, patch+"--- /dev/null\n+++ b/util/util.go\n@@ -0,0 +1 @@\n+package util\n")
+	assert.ErrorContains(t, err, "util/util.go")
+	_, err = Prepare(root, f, "--- /dev/null\n+++ b/.git/hooks/pre-commit\n@@ -0,0 +1 @@\n+rm -rf /\n")
+	assert.ErrorContains(t, err, "路径无效")
+	_, err = os.Stat(filepath.Join(root, "util"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestVerify(t *testing.T) {
+	_, err := Verify(t.TempDir(), "true")
+	assert.NoError(t, err)
+	out, err := Verify(t.TempDir(), "echo broken && false")
+	assert.Error(t, err)
+	assert.Contains(t, out, "broken")
+}
+
+func TestGenerateTooLarge(t *testing.T) {
+	root := t.TempDir()
+	large := make([]byte, review.MaxContextFileSize+1)
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "large.go"), large, 0644))
+
+	// 超过大小限制时不会请求模型
+	_, err := Generate(nil, root, review.Finding{File: "large.go"}, nil)
+	assert.ErrorContains(t, err, "超过大小限制")
+}
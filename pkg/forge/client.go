@@ -0,0 +1,135 @@
+package forge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// defaultTimeout API 请求超时时间
+const defaultTimeout = 30 * time.Second
+
+// client 平台 REST API 客户端
+type client struct {
+	// name 平台名称，用于错误信息
+	name    string
+	baseURL string
+	header  http.Header
+	// query 附加到每个请求的查询参数，用于通过 access_token 参数认证的平台
+	query url.Values
+	// pageParam、pageSize 分页参数名称和每页数量
+	pageParam string
+	pageSize  int
+	http      *http.Client
+}
+
+// newClient 创建 API 客户端
+func newClient(name, baseURL string, header http.Header) *client {
+	return &client{
+		name:      name,
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		header:    header,
+		pageParam: "per_page",
+		pageSize:  100,
+		http:      &http.Client{Timeout: defaultTimeout},
+	}
+}
+
+// do 发送 JSON 请求，out 不为空时解析响应
+func (c *client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("序列化请求失败: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	data, err := c.raw(ctx, method, path, reader, http.Header{"Accept": {"application/json"}})
+	if err != nil {
+		return err
+	}
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("解析 %s 响应失败: %w", c.name, err)
+		}
+	}
+	return nil
+}
+
+// raw 发送请求并返回响应内容
+func (c *client) raw(ctx context.Context, method, path string, body io.Reader, header http.Header) ([]byte, error) {
+	// 错误信息中只使用 path，避免泄露查询参数中的 token
+	target := c.baseURL + path
+	if len(c.query) > 0 {
+		sep := "?"
+		if strings.Contains(path, "?") {
+			sep = "&"
+		}
+		target += sep + c.query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	for k, v := range c.header {
+		req.Header[k] = v
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("请求 %s API 失败 (%s %s): %w", c.name, method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 响应失败: %w", c.name, err)
+	}
+	if resp.StatusCode >= 300 {
+		msg := string(bytes.TrimSpace(data))
+		if len(msg) > 500 {
+			msg = msg[:500]
+		}
+		return nil, fmt.Errorf("%s API 错误 (%s %s): %d %s", c.name, method, path, resp.StatusCode, msg)
+	}
+	return data, nil
+}
+
+// pages 依次请求分页接口，直到返回的数量不足一页
+func pages[T any](ctx context.Context, c *client, path string) ([]T, error) {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+
+	var all []T
+	for page := 1; ; page++ {
+		var items []T
+		if err := c.do(ctx, http.MethodGet, fmt.Sprintf("%s%s%s=%d&page=%d", path, sep, c.pageParam, c.pageSize, page), nil, &items); err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+		if len(items) < c.pageSize {
+			return all, nil
+		}
+	}
+}
@@ -0,0 +1,417 @@
+// Package forge 对接代码托管平台，将评审结果发布到 PR/MR
+package forge
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/icatw/cr-tool/pkg/config"
+	"github.com/icatw/cr-tool/pkg/diff"
+	"github.com/icatw/cr-tool/pkg/review"
+)
+
+// Forge 代码托管平台的 PR/MR 操作
+type Forge interface {
+	// Name 平台名称
+	Name() string
+	// PullRequest 获取 PR/MR 信息
+	PullRequest(ctx context.Context, number int) (*PullRequest, error)
+	// Diff 获取 PR/MR 的 diff
+	Diff(ctx context.Context, pr *PullRequest) (string, error)
+	// SetStatus 设置提交状态
+	SetStatus(ctx context.Context, pr *PullRequest, state, description string) error
+	// Publish 发布行内评论、总结评论和提交状态
+	Publish(ctx context.Context, pr *PullRequest, history *review.ReviewHistory, diffContent string) (*Result, error)
+}
+
+// Names 支持的平台
+var Names = []string{"github", "gitlab", "gitee", "gitea"}
+
+// New 根据名称和配置创建平台客户端
+func New(name string, cfg *config.Config) (Forge, error) {
+	switch strings.ToLower(name) {
+	case "github":
+		return NewGitHub(cfg.GitHub)
+	case "gitlab":
+		return NewGitLab(cfg.GitLab)
+	case "gitee":
+		return NewGitee(cfg.Gitee)
+	case "gitea":
+		return NewGitea(cfg.Gitea)
+	default:
+		return nil, fmt.Errorf("不支持的平台: %s，可选 %s", name, strings.Join(Names, "/"))
+	}
+}
+
+// Detect 根据 CI 环境变量判断所在平台，无法判断时返回空
+func Detect() string {
+	switch {
+	case os.Getenv("GITEA_ACTIONS") == "true":
+		return "gitea"
+	case os.Getenv("GITHUB_ACTIONS") == "true":
+		return "github"
+	case os.Getenv("GITLAB_CI") == "true":
+		return "gitlab"
+	default:
+		return ""
+	}
+}
+
+// NumberFromEnv 从 CI 环境变量中获取 PR/MR 编号：
+// GitHub/Gitea Actions 的 GITHUB_REF (refs/pull/<n>/merge)，GitLab CI 的 CI_MERGE_REQUEST_IID
+func NumberFromEnv() int {
+	if iid, err := strconv.Atoi(os.Getenv("CI_MERGE_REQUEST_IID")); err == nil {
+		return iid
+	}
+	ref := os.Getenv("GITHUB_REF")
+	if !strings.HasPrefix(ref, "refs/pull/") {
+		return 0
+	}
+	number, _ := strconv.Atoi(strings.Split(strings.TrimPrefix(ref, "refs/pull/"), "/")[0])
+	return number
+}
+
+// 提交状态
+const (
+	StatePending = "pending"
+	StateSuccess = "success"
+	StateFailure = "failure"
+	StateError   = "error"
+)
+
+// 评论中的隐藏标记，重新评审时据此找到之前发布的评论
+const (
+	summaryMarker  = "<!-- cr-tool:summary -->"
+	resolvedSuffix = " resolved"
+)
+
+// fingerprintMarker 匹配 <!-- cr-tool:fingerprint=xxx --> 和 <!-- cr-tool:fingerprint=xxx resolved -->
+var fingerprintMarker = regexp.MustCompile(`<!-- cr-tool:fingerprint=([\w-]+)( resolved)? -->`)
+
+// PullRequest PR/MR 信息
+type PullRequest struct {
+	Number  int
+	Title   string
+	Author  string
+	URL     string
+	HeadRef string
+	BaseRef string
+	HeadSHA string
+	BaseSHA string
+	// StartSHA GitLab 定位行内评论需要的 start_sha
+	StartSHA string
+}
+
+// GitInfo 用 PR 信息代替本地仓库的 Git 信息
+func (pr *PullRequest) GitInfo(diffContent string) *review.GitInfo {
+	info := &review.GitInfo{
+		Branch:        pr.HeadRef,
+		CommitHash:    pr.HeadSHA,
+		CommitMessage: pr.Title,
+		Author:        pr.Author,
+	}
+	for _, f := range diff.Parse(diffContent) {
+		if name := f.Name(); name != "" {
+			info.ChangedFiles = append(info.ChangedFiles, name)
+		}
+	}
+	return info
+}
+
+// fileDiff 按文件返回变更的平台接口中单个文件的 diff
+type fileDiff struct {
+	OldPath     string `json:"old_path"`
+	NewPath     string `json:"new_path"`
+	NewFile     bool   `json:"new_file"`
+	DeletedFile bool   `json:"deleted_file"`
+	Diff        string `json:"diff"`
+}
+
+// joinDiffs 为每个文件补上 diff 头，拼接为 git diff 格式
+func joinDiffs(files []fileDiff) string {
+	var b strings.Builder
+	for _, d := range files {
+		if d.Diff == "" {
+			continue
+		}
+		oldName, newName := "a/"+d.OldPath, "b/"+d.NewPath
+		if d.NewFile {
+			oldName = diff.DevNull
+		}
+		if d.DeletedFile {
+			newName = diff.DevNull
+		}
+		b.WriteString(fmt.Sprintf("diff --git a/%s b/%s\n--- %s\n+++ %s\n", d.OldPath, d.NewPath, oldName, newName))
+		b.WriteString(d.Diff)
+		if !strings.HasSuffix(d.Diff, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// InlineComment 落在 diff 行上的评论
+type InlineComment struct {
+	// Key 问题指纹，同一次评审中指纹重复时追加序号
+	Key     string
+	Finding review.Finding
+	Path    string
+	Line    int
+	// OldPath 重命名前的路径，OldLine 上下文行在旧文件中的行号，新增行为 0
+	OldPath  string
+	OldLine  int
+	Position int
+	Body     string
+}
+
+// BotComment 之前发布的行内评论
+type BotComment struct {
+	ID       string
+	Key      string
+	Body     string
+	Resolved bool
+	// Outdated 评论所在的行已不在最新 diff 中
+	Outdated bool
+}
+
+// Plan 行内评论的变更计划
+type Plan struct {
+	Create []InlineComment
+	// Update 评论 ID 到新内容
+	Update map[string]InlineComment
+	// Resolve 对应问题已消失的评论
+	Resolve []BotComment
+}
+
+// Result 发布结果
+type Result struct {
+	Created  int
+	Updated  int
+	Resolved int
+	// Outside 无法定位到 diff 行、只出现在总结评论中的问题数
+	Outside     int
+	State       string
+	Description string
+}
+
+// commenter 各平台评论接口的差异部分，发布流程由 publish 统一实现
+type commenter interface {
+	// botComments 获取之前发布的行内评论
+	botComments(ctx context.Context, pr *PullRequest) ([]BotComment, error)
+	// createComments 创建行内评论
+	createComments(ctx context.Context, pr *PullRequest, comments []InlineComment) error
+	// updateComment 修改行内评论，c 为修改前的评论
+	updateComment(ctx context.Context, pr *PullRequest, c BotComment, body string) error
+	// resolveComment 将问题已消失的评论标记为已修复
+	resolveComment(ctx context.Context, pr *PullRequest, c BotComment) error
+	// upsertSummary 更新或创建总结评论
+	upsertSummary(ctx context.Context, pr *PullRequest, body string) error
+	SetStatus(ctx context.Context, pr *PullRequest, state, description string) error
+}
+
+// publish 发布评审结果：新问题创建行内评论，仍存在的问题更新原评论，
+// 已消失的问题将原评论标记为已修复，总结评论只保留一条
+func publish(ctx context.Context, f commenter, failOn string, pr *PullRequest, history *review.ReviewHistory, diffContent string) (*Result, error) {
+	inline, outside := Inline(history, diffContent)
+
+	existing, err := f.botComments(ctx, pr)
+	if err != nil {
+		return nil, err
+	}
+	plan := NewPlan(existing, inline)
+	result := &Result{Outside: len(outside)}
+	result.State, result.Description = State(history, failOn)
+
+	byID := make(map[string]BotComment, len(existing))
+	for _, c := range existing {
+		byID[c.ID] = c
+	}
+	ids := make([]string, 0, len(plan.Update))
+	for id := range plan.Update {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if err := f.updateComment(ctx, pr, byID[id], plan.Update[id].Body); err != nil {
+			return nil, err
+		}
+		result.Updated++
+	}
+
+	for _, c := range plan.Resolve {
+		if err := f.resolveComment(ctx, pr, c); err != nil {
+			return nil, err
+		}
+		result.Resolved++
+	}
+
+	if len(plan.Create) > 0 {
+		if err := f.createComments(ctx, pr, plan.Create); err != nil {
+			return nil, err
+		}
+		result.Created = len(plan.Create)
+	}
+
+	if err := f.upsertSummary(ctx, pr, SummaryBody(pr, history, outside, result)); err != nil {
+		return nil, err
+	}
+	if err := f.SetStatus(ctx, pr, result.State, result.Description); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Inline 将问题映射到 diff 行，返回可以作为行内评论的问题和无法定位的问题
+func Inline(history *review.ReviewHistory, diffContent string) ([]InlineComment, []review.Finding) {
+	files := make(map[string]*diff.File)
+	for _, f := range diff.Parse(diffContent) {
+		files[f.Name()] = f
+	}
+
+	var (
+		inline  []InlineComment
+		outside []review.Finding
+		seen    = make(map[string]int)
+	)
+	for _, f := range history.Findings {
+		file, ok := files[f.File]
+		if !ok || f.Line <= 0 {
+			outside = append(outside, f)
+			continue
+		}
+		line, ok := file.LineAt(f.Line)
+		if !ok {
+			outside = append(outside, f)
+			continue
+		}
+
+		oldPath := file.OldName
+		if oldPath == "" || file.IsNew() {
+			oldPath = file.Name()
+		}
+
+		key := f.Fingerprint()
+		if n := seen[key]; n > 0 {
+			key = fmt.Sprintf("%s-%d", key, n)
+		}
+		seen[f.Fingerprint()]++
+
+		inline = append(inline, InlineComment{
+			Key:      key,
+			Finding:  f,
+			Path:     file.Name(),
+			OldPath:  oldPath,
+			Line:     f.Line,
+			OldLine:  line.OldLine,
+			Position: line.Position,
+			Body:     CommentBody(f, key),
+		})
+	}
+	return inline, outside
+}
+
+// NewPlan 对比之前的评论和本次的问题：
+// 仍存在的问题更新原评论，新问题创建评论，已消失的问题将原评论标记为已修复
+func NewPlan(existing []BotComment, current []InlineComment) *Plan {
+	plan := &Plan{Update: make(map[string]InlineComment)}
+
+	// 同一指纹有多条评论时优先使用未过期的
+	byKey := make(map[string]BotComment)
+	for _, c := range existing {
+		if old, ok := byKey[c.Key]; !ok || (old.Outdated && !c.Outdated) {
+			byKey[c.Key] = c
+		}
+	}
+
+	present := make(map[string]bool)
+	for _, ic := range current {
+		present[ic.Key] = true
+		old, ok := byKey[ic.Key]
+		if !ok || old.Outdated {
+			plan.Create = append(plan.Create, ic)
+			continue
+		}
+		if old.Body != ic.Body {
+			plan.Update[old.ID] = ic
+		}
+	}
+
+	for _, c := range existing {
+		if !present[c.Key] && !c.Resolved {
+			plan.Resolve = append(plan.Resolve, c)
+		}
+	}
+	return plan
+}
+
+// CommentBody 行内评论内容
+func CommentBody(f review.Finding, key string) string {
+	var b strings.Builder
+	title := f.Title
+	if f.Category != "" {
+		title = f.Category + " · " + title
+	}
+	b.WriteString(fmt.Sprintf("**[%s] %s**\n", f.Severity, title))
+	if f.Detail != "" {
+		b.WriteString("\n" + f.Detail + "\n")
+	}
+	if f.Suggestion != "" {
+		b.WriteString("\n**建议**：" + f.Suggestion + "\n")
+	}
+	b.WriteString(fmt.Sprintf("\n<!-- cr-tool:fingerprint=%s -->", key))
+	return b.String()
+}
+
+// ResolvedBody 将评论改为已修复
+func ResolvedBody(c BotComment, sha string) string {
+	body := fingerprintMarker.ReplaceAllString(c.Body, "")
+	firstLine, _, _ := strings.Cut(strings.TrimSpace(body), "\n")
+	return fmt.Sprintf("~~%s~~\n\n✅ 已在 %s 中修复\n\n<!-- cr-tool:fingerprint=%s%s -->",
+		strings.Trim(firstLine, "*"), shortSHA(sha), c.Key, resolvedSuffix)
+}
+
+// ParseBotComment 解析评论中的标记，不是本工具发布的评论返回 false
+func ParseBotComment(id, body string) (BotComment, bool) {
+	m := fingerprintMarker.FindStringSubmatch(body)
+	if m == nil {
+		return BotComment{}, false
+	}
+	return BotComment{ID: id, Key: m[1], Body: body, Resolved: m[2] != ""}, true
+}
+
+// State 根据问题级别计算提交状态和说明
+func State(history *review.ReviewHistory, failOn string) (string, string) {
+	threshold, ok := review.ParseSeverity(failOn)
+	if !ok {
+		threshold = review.SeverityHigh
+	}
+
+	counts := make(map[review.Severity]int)
+	failed := 0
+	for _, f := range history.Findings {
+		counts[f.Severity]++
+		if f.Severity.Rank() >= threshold.Rank() {
+			failed++
+		}
+	}
+
+	description := fmt.Sprintf("严重 %d · 中等 %d · 低 %d",
+		counts[review.SeverityHigh], counts[review.SeverityMedium], counts[review.SeverityLow])
+	if failed > 0 {
+		return StateFailure, fmt.Sprintf("%d 个%s及以上的问题（%s）", failed, threshold, description)
+	}
+	return StateSuccess, description
+}
+
+// shortSHA 返回提交的短哈希
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,545 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/icatw/cr-tool/pkg/config"
+	"github.com/icatw/cr-tool/pkg/review"
+	"github.com/stretchr/testify/assert"
+)
+
+const testDiff = `diff --git a/auth/login.go b/auth/login.go
+--- a/auth/login.go
++++ b/auth/login.go
+@@ -10,3 +10,4 @@ func Login() {
+ 	user := find()
+-	check(user)
++	save(user.Password)
++	log(user)
+ 	return
+`
+
+func testHistory() *review.ReviewHistory {
+	return &review.ReviewHistory{
+		ReviewResult: "发现两个问题",
+		Findings: []review.Finding{
+			{File: "auth/login.go", Line: 11, Severity: review.SeverityHigh, Category: "安全", Title: "密码明文存储"},
+			{File: "auth/login.go", Line: 12, Severity: review.SeverityLow, Title: "日志包含用户信息"},
+			{File: "auth/other.go", Line: 3, Severity: review.SeverityMedium, Title: "不在 diff 中"},
+		},
+	}
+}
+
+func TestInline(t *testing.T) {
+	inline, outside := Inline(testHistory(), testDiff)
+	assert.Len(t, inline, 2)
+	assert.Equal(t, 3, inline[0].Position)
+	assert.Equal(t, 4, inline[1].Position)
+	assert.Contains(t, inline[0].Body, "**[严重] 安全 · 密码明文存储**")
+	assert.Contains(t, inline[0].Body, "<!-- cr-tool:fingerprint="+inline[0].Key+" -->")
+	assert.Len(t, outside, 1)
+
+	// 同一指纹重复出现时使用不同的 key
+	h := testHistory()
+	h.Findings[1] = h.Findings[0]
+	h.Findings[1].Line = 12
+	inline, _ = Inline(h, testDiff)
+	assert.Equal(t, inline[0].Key+"-1", inline[1].Key)
+}
+
+func TestNewPlan(t *testing.T) {
+	inline, _ := Inline(testHistory(), testDiff)
+	plan := NewPlan(nil, inline)
+	assert.Len(t, plan.Create, 2)
+
+	existing := []BotComment{
+		{ID: "1", Key: inline[0].Key, Body: inline[0].Body},
+		{ID: "2", Key: inline[1].Key, Body: "旧内容"},
+		{ID: "3", Key: "gone", Body: "x"},
+		{ID: "4", Key: "fixed", Body: "y", Resolved: true},
+	}
+	plan = NewPlan(existing, inline)
+	assert.Empty(t, plan.Create)
+	assert.Equal(t, map[string]InlineComment{"2": inline[1]}, plan.Update)
+	assert.Equal(t, []BotComment{existing[2]}, plan.Resolve)
+
+	// 过期的评论不再复用
+	existing[0].Outdated = true
+	plan = NewPlan(existing[:1], inline[:1])
+	assert.Len(t, plan.Create, 1)
+}
+
+func TestParseBotComment(t *testing.T) {
+	c, ok := ParseBotComment("7", "**[低] x**\n\n<!-- cr-tool:fingerprint=abc -->")
+	assert.True(t, ok)
+	assert.Equal(t, "abc", c.Key)
+	assert.False(t, c.Resolved)
+
+	resolved := ResolvedBody(c, "0123456789")
+	assert.Equal(t, "~~[低] x~~\n\n✅ 已在 0123456 中修复\n\n<!-- cr-tool:fingerprint=abc resolved -->", resolved)
+	c, ok = ParseBotComment("7", resolved)
+	assert.True(t, ok)
+	assert.True(t, c.Resolved)
+
+	_, ok = ParseBotComment("8", "普通评论")
+	assert.False(t, ok)
+}
+
+func TestState(t *testing.T) {
+	state, desc := State(testHistory(), "严重")
+	assert.Equal(t, StateFailure, state)
+	assert.Contains(t, desc, "1 个严重及以上的问题")
+
+	h := testHistory()
+	h.Findings = h.Findings[1:]
+	state, _ = State(h, "")
+	assert.Equal(t, StateSuccess, state)
+	state, _ = State(h, "medium")
+	assert.Equal(t, StateFailure, state)
+}
+
+// fakeGitHub 内存中的 GitHub API
+type fakeGitHub struct {
+	mu             sync.Mutex
+	nextID         int64
+	reviewComments map[int64]string
+	issueComments  map[int64]string
+	reviews        int
+	statuses       []string
+}
+
+func newFakeGitHub(t *testing.T) (*fakeGitHub, *httptest.Server) {
+	f := &fakeGitHub{reviewComments: map[int64]string{}, issueComments: map[int64]string{}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		path := r.URL.Path
+		switch {
+		case r.Method == http.MethodGet && path == "/repos/o/r/pulls/5":
+			if r.Header.Get("Accept") == "application/vnd.github.v3.diff" {
+				w.Write([]byte(testDiff))
+				return
+			}
+			w.Write([]byte(`{"number":5,"title":"登录","html_url":"https://github.test/o/r/pull/5","user":{"login":"alice"},
+				"head":{"ref":"feature","sha":"abcdef123456"},"base":{"ref":"main","sha":"000"}}`))
+		case r.Method == http.MethodGet && path == "/repos/o/r/pulls/5/comments":
+			writeComments(w, f.reviewComments, true)
+		case r.Method == http.MethodGet && path == "/repos/o/r/issues/5/comments":
+			writeComments(w, f.issueComments, false)
+		case r.Method == http.MethodPost && path == "/repos/o/r/pulls/5/reviews":
+			f.reviews++
+			assert.Equal(t, "abcdef123456", body["commit_id"])
+			for _, c := range body["comments"].([]interface{}) {
+				f.nextID++
+				f.reviewComments[f.nextID] = c.(map[string]interface{})["body"].(string)
+			}
+		case r.Method == http.MethodPatch && strings.HasPrefix(path, "/repos/o/r/pulls/comments/"):
+			id, _ := strconv.ParseInt(strings.TrimPrefix(path, "/repos/o/r/pulls/comments/"), 10, 64)
+			f.reviewComments[id] = body["body"].(string)
+		case r.Method == http.MethodPost && path == "/repos/o/r/issues/5/comments":
+			f.nextID++
+			f.issueComments[f.nextID] = body["body"].(string)
+		case r.Method == http.MethodPatch && strings.HasPrefix(path, "/repos/o/r/issues/comments/"):
+			id, _ := strconv.ParseInt(strings.TrimPrefix(path, "/repos/o/r/issues/comments/"), 10, 64)
+			f.issueComments[id] = body["body"].(string)
+		case r.Method == http.MethodPost && path == "/repos/o/r/statuses/abcdef123456":
+			f.statuses = append(f.statuses, body["state"].(string))
+		default:
+			t.Errorf("未预期的请求: %s %s", r.Method, path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return f, server
+}
+
+func writeComments(w http.ResponseWriter, comments map[int64]string, withPosition bool) {
+	var list []map[string]interface{}
+	for id, body := range comments {
+		c := map[string]interface{}{"id": id, "body": body}
+		if withPosition {
+			c["position"] = 1
+		}
+		list = append(list, c)
+	}
+	json.NewEncoder(w).Encode(list)
+}
+
+func TestGitHubPublish(t *testing.T) {
+	fake, server := newFakeGitHub(t)
+	gh, err := NewGitHub(config.ForgeConfig{Token: "token", BaseURL: server.URL, Repo: "o/r"})
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	pr, err := gh.PullRequest(ctx, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, "feature", pr.HeadRef)
+	assert.Equal(t, []string{"auth/login.go"}, pr.GitInfo(testDiff).ChangedFiles)
+
+	diffContent, err := gh.Diff(ctx, pr)
+	assert.NoError(t, err)
+	assert.Equal(t, testDiff, diffContent)
+
+	result, err := gh.Publish(ctx, pr, testHistory(), diffContent)
+	assert.NoError(t, err)
+	assert.Equal(t, &Result{Created: 2, Outside: 1, State: StateFailure, Description: "1 个严重及以上的问题（严重 1 · 中等 1 · 低 1）"}, result)
+	assert.Equal(t, 1, fake.reviews)
+	assert.Len(t, fake.issueComments, 1)
+
+	// 重新评审：严重问题已修复，低级别问题仍存在
+	h := testHistory()
+	h.Findings = h.Findings[1:]
+	result, err = gh.Publish(ctx, pr, h, diffContent)
+	assert.NoError(t, err)
+	assert.Equal(t, &Result{Resolved: 1, Outside: 1, State: StateSuccess, Description: "严重 0 · 中等 1 · 低 1"}, result)
+	assert.Equal(t, 1, fake.reviews)
+	assert.Len(t, fake.reviewComments, 2)
+	assert.Len(t, fake.issueComments, 1)
+	assert.Equal(t, []string{StateFailure, StateSuccess}, fake.statuses)
+
+	var resolved int
+	for _, body := range fake.reviewComments {
+		if strings.Contains(body, "✅ 已在 abcdef1 中修复") {
+			resolved++
+		}
+	}
+	assert.Equal(t, 1, resolved)
+	for _, body := range fake.issueComments {
+		assert.True(t, strings.HasPrefix(body, summaryMarker))
+		assert.Contains(t, body, "1 个问题已修复")
+		assert.Contains(t, body, fmt.Sprintf("`%s` %s", "auth/other.go:3", "不在 diff 中"))
+	}
+}
+
+// fakeGitLab 内存中的 GitLab API
+type fakeGitLab struct {
+	mu          sync.Mutex
+	nextID      int64
+	discussions map[string]*fakeDiscussion
+	notes       map[int64]string
+	positions   []map[string]interface{}
+	statuses    []string
+}
+
+type fakeDiscussion struct {
+	noteID   int64
+	body     string
+	resolved bool
+}
+
+func newFakeGitLab(t *testing.T) (*fakeGitLab, *httptest.Server) {
+	f := &fakeGitLab{discussions: map[string]*fakeDiscussion{}, notes: map[int64]string{}}
+	const mr = "/projects/group%2Fapp/merge_requests/7"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		assert.Equal(t, "token", r.Header.Get("Private-Token"))
+
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		path := r.URL.EscapedPath()
+		switch {
+		case r.Method == http.MethodGet && path == mr:
+			w.Write([]byte(`{"iid":7,"title":"登录","web_url":"https://gitlab.test/group/app/-/merge_requests/7",
+				"source_branch":"feature","target_branch":"main","author":{"username":"alice"},
+				"diff_refs":{"base_sha":"000","head_sha":"abcdef123456","start_sha":"111"}}`))
+		case r.Method == http.MethodGet && path == mr+"/diffs":
+			hunks := testDiff[strings.Index(testDiff, "@@"):]
+			json.NewEncoder(w).Encode([]map[string]interface{}{
+				{"old_path": "auth/login.go", "new_path": "auth/login.go", "diff": hunks},
+			})
+		case r.Method == http.MethodGet && path == mr+"/discussions":
+			var list []map[string]interface{}
+			for id, d := range f.discussions {
+				list = append(list, map[string]interface{}{
+					"id":    id,
+					"notes": []map[string]interface{}{{"id": d.noteID, "body": d.body, "resolved": d.resolved}},
+				})
+			}
+			json.NewEncoder(w).Encode(list)
+		case r.Method == http.MethodPost && path == mr+"/discussions":
+			f.nextID++
+			f.discussions[fmt.Sprintf("d%d", f.nextID)] = &fakeDiscussion{noteID: f.nextID, body: body["body"].(string)}
+			f.positions = append(f.positions, body["position"].(map[string]interface{}))
+		case r.Method == http.MethodPut && strings.HasPrefix(path, mr+"/discussions/"):
+			id, note, _ := strings.Cut(strings.TrimPrefix(path, mr+"/discussions/"), "/notes/")
+			d := f.discussions[id]
+			if note != "" {
+				d.body = body["body"].(string)
+			} else {
+				d.resolved = r.URL.Query().Get("resolved") == "true"
+			}
+		case r.Method == http.MethodGet && path == mr+"/notes":
+			writeComments(w, f.notes, false)
+		case r.Method == http.MethodPost && path == mr+"/notes":
+			f.nextID++
+			f.notes[f.nextID] = body["body"].(string)
+		case r.Method == http.MethodPut && strings.HasPrefix(path, mr+"/notes/"):
+			id, _ := strconv.ParseInt(strings.TrimPrefix(path, mr+"/notes/"), 10, 64)
+			f.notes[id] = body["body"].(string)
+		case r.Method == http.MethodPost && path == "/projects/group%2Fapp/statuses/abcdef123456":
+			assert.Equal(t, "cr-tool", r.URL.Query().Get("name"))
+			f.statuses = append(f.statuses, r.URL.Query().Get("state"))
+		default:
+			t.Errorf("未预期的请求: %s %s", r.Method, path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return f, server
+}
+
+func TestGitLabPublish(t *testing.T) {
+	fake, server := newFakeGitLab(t)
+	gl, err := NewGitLab(config.ForgeConfig{Token: "token", BaseURL: server.URL, Repo: "group/app"})
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	pr, err := gl.PullRequest(ctx, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, "111", pr.StartSHA)
+
+	diffContent, err := gl.Diff(ctx, pr)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(diffContent, "diff --git a/auth/login.go b/auth/login.go\n--- a/auth/login.go\n+++ b/auth/login.go\n@@"))
+
+	result, err := gl.Publish(ctx, pr, testHistory(), diffContent)
+	assert.NoError(t, err)
+	assert.Equal(t, &Result{Created: 2, Outside: 1, State: StateFailure, Description: "1 个严重及以上的问题（严重 1 · 中等 1 · 低 1）"}, result)
+	assert.Equal(t, map[string]interface{}{
+		"position_type": "text", "base_sha": "000", "start_sha": "111", "head_sha": "abcdef123456",
+		"old_path": "auth/login.go", "new_path": "auth/login.go", "new_line": float64(11),
+	}, fake.positions[0])
+
+	// 严重问题修复后，对应的讨论被解决
+	h := testHistory()
+	h.Findings = h.Findings[1:]
+	result, err = gl.Publish(ctx, pr, h, diffContent)
+	assert.NoError(t, err)
+	assert.Equal(t, &Result{Resolved: 1, Outside: 1, State: StateSuccess, Description: "严重 0 · 中等 1 · 低 1"}, result)
+
+	var resolved int
+	for _, d := range fake.discussions {
+		if d.resolved {
+			resolved++
+			assert.Contains(t, d.body, "✅ 已在 abcdef1 中修复")
+		}
+	}
+	assert.Equal(t, 1, resolved)
+	assert.Len(t, fake.notes, 1)
+	assert.Equal(t, []string{"failed", "success"}, fake.statuses)
+}
+
+// fakeGitea 内存中的 Gitea API，行内评论和普通评论共用 ID 空间
+type fakeGitea struct {
+	mu            sync.Mutex
+	nextID        int64
+	reviews       map[int64][]int64
+	comments      map[int64]string
+	issueComments []int64
+	statuses      []string
+}
+
+func newFakeGitea(t *testing.T) (*fakeGitea, *httptest.Server) {
+	f := &fakeGitea{reviews: map[int64][]int64{}, comments: map[int64]string{}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		assert.Equal(t, "token token", r.Header.Get("Authorization"))
+
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		path := r.URL.Path
+		switch {
+		case r.Method == http.MethodGet && path == "/repos/o/r/pulls/5":
+			w.Write([]byte(`{"number":5,"title":"登录","html_url":"https://gitea.test/o/r/pulls/5","user":{"login":"alice"},
+				"head":{"ref":"feature","sha":"abcdef123456"},"base":{"ref":"main","sha":"000"}}`))
+		case r.Method == http.MethodGet && path == "/repos/o/r/pulls/5.diff":
+			w.Write([]byte(testDiff))
+		case r.Method == http.MethodGet && path == "/repos/o/r/pulls/5/reviews":
+			assert.Equal(t, "50", r.URL.Query().Get("limit"))
+			var list []map[string]interface{}
+			for id := range f.reviews {
+				list = append(list, map[string]interface{}{"id": id})
+			}
+			json.NewEncoder(w).Encode(list)
+		case r.Method == http.MethodGet && strings.HasPrefix(path, "/repos/o/r/pulls/5/reviews/"):
+			id, _ := strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(path, "/repos/o/r/pulls/5/reviews/"), "/comments"), 10, 64)
+			selected := map[int64]string{}
+			for _, c := range f.reviews[id] {
+				selected[c] = f.comments[c]
+			}
+			writeComments(w, selected, false)
+		case r.Method == http.MethodPost && path == "/repos/o/r/pulls/5/reviews":
+			f.nextID++
+			review := f.nextID
+			for _, c := range body["comments"].([]interface{}) {
+				comment := c.(map[string]interface{})
+				assert.NotZero(t, comment["new_position"])
+				f.nextID++
+				f.comments[f.nextID] = comment["body"].(string)
+				f.reviews[review] = append(f.reviews[review], f.nextID)
+			}
+		case r.Method == http.MethodPatch && strings.HasPrefix(path, "/repos/o/r/issues/comments/"):
+			id, _ := strconv.ParseInt(strings.TrimPrefix(path, "/repos/o/r/issues/comments/"), 10, 64)
+			f.comments[id] = body["body"].(string)
+		case r.Method == http.MethodGet && path == "/repos/o/r/issues/5/comments":
+			selected := map[int64]string{}
+			for _, c := range f.issueComments {
+				selected[c] = f.comments[c]
+			}
+			writeComments(w, selected, false)
+		case r.Method == http.MethodPost && path == "/repos/o/r/issues/5/comments":
+			f.nextID++
+			f.comments[f.nextID] = body["body"].(string)
+			f.issueComments = append(f.issueComments, f.nextID)
+		case r.Method == http.MethodPost && path == "/repos/o/r/statuses/abcdef123456":
+			f.statuses = append(f.statuses, body["state"].(string))
+		default:
+			t.Errorf("未预期的请求: %s %s", r.Method, path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return f, server
+}
+
+func TestGiteaPublish(t *testing.T) {
+	_, err := NewGitea(config.ForgeConfig{Token: "token", Repo: "o/r"})
+	assert.Error(t, err, "Gitea 没有默认的 API 地址")
+
+	fake, server := newFakeGitea(t)
+	f, err := New("gitea", &config.Config{Gitea: config.ForgeConfig{Token: "token", BaseURL: server.URL, Repo: "o/r"}})
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	pr, err := f.PullRequest(ctx, 5)
+	assert.NoError(t, err)
+	diffContent, err := f.Diff(ctx, pr)
+	assert.NoError(t, err)
+	assert.Equal(t, testDiff, diffContent)
+
+	result, err := f.Publish(ctx, pr, testHistory(), diffContent)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Created)
+
+	h := testHistory()
+	h.Findings = h.Findings[1:]
+	result, err = f.Publish(ctx, pr, h, diffContent)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Resolved)
+	assert.Zero(t, result.Created)
+	assert.Len(t, fake.reviews, 1)
+	assert.Len(t, fake.issueComments, 1)
+	assert.Equal(t, []string{StateFailure, StateSuccess}, fake.statuses)
+	assert.Contains(t, fake.comments[fake.issueComments[0]], "1 个问题已修复")
+}
+
+// fakeGitee 内存中的 Gitee API
+type fakeGitee struct {
+	mu       sync.Mutex
+	nextID   int64
+	comments map[int64]map[string]interface{}
+}
+
+func newFakeGitee(t *testing.T) (*fakeGitee, *httptest.Server) {
+	f := &fakeGitee{comments: map[int64]map[string]interface{}{}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		assert.Equal(t, "token", r.URL.Query().Get("access_token"))
+
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		path := r.URL.Path
+		switch {
+		case r.Method == http.MethodGet && path == "/repos/o/r/pulls/5":
+			w.Write([]byte(`{"number":5,"title":"登录","html_url":"https://gitee.test/o/r/pulls/5","user":{"login":"alice"},
+				"head":{"ref":"feature","sha":"abcdef123456"},"base":{"ref":"main","sha":"000"}}`))
+		case r.Method == http.MethodGet && path == "/repos/o/r/pulls/5/files":
+			hunks := testDiff[strings.Index(testDiff, "@@"):]
+			json.NewEncoder(w).Encode([]map[string]interface{}{
+				{"filename": "auth/login.go", "patch": map[string]interface{}{"old_path": "auth/login.go", "new_path": "auth/login.go", "diff": hunks}},
+			})
+		case r.Method == http.MethodGet && path == "/repos/o/r/pulls/5/comments":
+			var list []map[string]interface{}
+			for id, c := range f.comments {
+				list = append(list, map[string]interface{}{"id": id, "body": c["body"], "comment_type": c["comment_type"]})
+			}
+			json.NewEncoder(w).Encode(list)
+		case r.Method == http.MethodPost && path == "/repos/o/r/pulls/5/comments":
+			f.nextID++
+			body["comment_type"] = "pr_comment"
+			if body["path"] != nil {
+				assert.Equal(t, "abcdef123456", body["commit_id"])
+				body["comment_type"] = "diff_comment"
+			}
+			f.comments[f.nextID] = body
+		case r.Method == http.MethodPatch && strings.HasPrefix(path, "/repos/o/r/pulls/comments/"):
+			id, _ := strconv.ParseInt(strings.TrimPrefix(path, "/repos/o/r/pulls/comments/"), 10, 64)
+			f.comments[id]["body"] = body["body"]
+		default:
+			t.Errorf("未预期的请求: %s %s", r.Method, path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return f, server
+}
+
+func TestGiteePublish(t *testing.T) {
+	fake, server := newFakeGitee(t)
+	f, err := New("gitee", &config.Config{Gitee: config.ForgeConfig{Token: "token", BaseURL: server.URL, Repo: "o/r"}})
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	pr, err := f.PullRequest(ctx, 5)
+	assert.NoError(t, err)
+	diffContent, err := f.Diff(ctx, pr)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(diffContent, "diff --git a/auth/login.go b/auth/login.go\n"))
+
+	result, err := f.Publish(ctx, pr, testHistory(), diffContent)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Created)
+	assert.Len(t, fake.comments, 3)
+
+	h := testHistory()
+	h.Findings = h.Findings[1:]
+	result, err = f.Publish(ctx, pr, h, diffContent)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Resolved)
+	assert.Len(t, fake.comments, 3)
+
+	var summaries int
+	for _, c := range fake.comments {
+		if c["comment_type"] == "pr_comment" {
+			summaries++
+			assert.Contains(t, c["body"], "**状态**：✅ 通过")
+		}
+	}
+	assert.Equal(t, 1, summaries)
+}
+
+func TestNew(t *testing.T) {
+	_, err := New("bitbucket", &config.Config{})
+	assert.Error(t, err)
+
+	t.Setenv("GITHUB_REF", "refs/pull/42/merge")
+	t.Setenv("CI_MERGE_REQUEST_IID", "")
+	assert.Equal(t, 42, NumberFromEnv())
+	t.Setenv("CI_MERGE_REQUEST_IID", "7")
+	assert.Equal(t, 7, NumberFromEnv())
+}
@@ -0,0 +1,183 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/icatw/cr-tool/pkg/config"
+	"github.com/icatw/cr-tool/pkg/review"
+	"github.com/icatw/cr-tool/pkg/text"
+)
+
+// Gitea 私有部署 Gitea 的 PR 集成
+type Gitea struct {
+	config config.ForgeConfig
+	client *client
+	repo   string
+}
+
+// NewGitea 创建 Gitea 客户端
+// token 未配置时读取 GITEA_TOKEN；在 Gitea Actions 中运行时，
+// token、repo 和 base_url 还会依次读取 GITHUB_TOKEN、GITHUB_REPOSITORY 和 GITHUB_API_URL
+func NewGitea(cfg config.ForgeConfig) (*Gitea, error) {
+	if cfg.Token == "" {
+		cfg.Token = os.Getenv("GITEA_TOKEN")
+	}
+	if os.Getenv("GITEA_ACTIONS") == "true" {
+		if cfg.Token == "" {
+			cfg.Token = os.Getenv("GITHUB_TOKEN")
+		}
+		if cfg.Repo == "" {
+			cfg.Repo = os.Getenv("GITHUB_REPOSITORY")
+		}
+		if cfg.BaseURL == "" {
+			cfg.BaseURL = os.Getenv("GITHUB_API_URL")
+		}
+	}
+	if cfg.StatusContext == "" {
+		cfg.StatusContext = "cr-tool"
+	}
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("未配置 Gitea token (gitea.token 或 GITEA_TOKEN)")
+	}
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("未配置 Gitea API 地址 (gitea.base_url)，例如 https://gitea.example.com/api/v1")
+	}
+	if strings.Count(cfg.Repo, "/") != 1 {
+		return nil, fmt.Errorf("无效的 Gitea 仓库 %q，格式应为 owner/name", cfg.Repo)
+	}
+
+	c := newClient("Gitea", cfg.BaseURL, http.Header{"Authorization": {"token " + cfg.Token}})
+	c.pageParam, c.pageSize = "limit", 50
+	return &Gitea{config: cfg, client: c, repo: cfg.Repo}, nil
+}
+
+// giteaReview PR review
+type giteaReview struct {
+	ID int64 `json:"id"`
+}
+
+// giteaComment 评论
+type giteaComment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+// Name 平台名称
+func (g *Gitea) Name() string {
+	return "gitea"
+}
+
+// PullRequest 获取 PR 信息，响应格式与 GitHub 相同
+func (g *Gitea) PullRequest(ctx context.Context, number int) (*PullRequest, error) {
+	var pull githubPull
+	if err := g.client.do(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/pulls/%d", g.repo, number), nil, &pull); err != nil {
+		return nil, err
+	}
+	return &PullRequest{
+		Number:  pull.Number,
+		Title:   pull.Title,
+		Author:  pull.User.Login,
+		URL:     pull.HTMLURL,
+		HeadRef: pull.Head.Ref,
+		BaseRef: pull.Base.Ref,
+		HeadSHA: pull.Head.SHA,
+		BaseSHA: pull.Base.SHA,
+	}, nil
+}
+
+// Diff 获取 PR 的 diff
+func (g *Gitea) Diff(ctx context.Context, pr *PullRequest) (string, error) {
+	data, err := g.client.raw(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/pulls/%d.diff", g.repo, pr.Number), nil, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// SetStatus 设置提交状态
+func (g *Gitea) SetStatus(ctx context.Context, pr *PullRequest, state, description string) error {
+	return g.client.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/statuses/%s", g.repo, pr.HeadSHA), map[string]string{
+		"state":       state,
+		"context":     g.config.StatusContext,
+		"description": text.Truncate(description, 255),
+		"target_url":  pr.URL,
+	}, nil)
+}
+
+// Publish 发布评审结果，所有新的行内评论合并为一次 PR review
+// Gitea API 无法解决评论会话，已消失的问题通过修改评论内容标记为已修复
+func (g *Gitea) Publish(ctx context.Context, pr *PullRequest, history *review.ReviewHistory, diffContent string) (*Result, error) {
+	return publish(ctx, g, g.config.FailOn, pr, history, diffContent)
+}
+
+// botComments 获取之前发布的行内评论，Gitea 只能按 review 逐个获取
+func (g *Gitea) botComments(ctx context.Context, pr *PullRequest) ([]BotComment, error) {
+	reviews, err := pages[giteaReview](ctx, g.client, fmt.Sprintf("/repos/%s/pulls/%d/reviews", g.repo, pr.Number))
+	if err != nil {
+		return nil, err
+	}
+	var result []BotComment
+	for _, r := range reviews {
+		var comments []giteaComment
+		if err := g.client.do(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/pulls/%d/reviews/%d/comments", g.repo, pr.Number, r.ID), nil, &comments); err != nil {
+			return nil, err
+		}
+		for _, c := range comments {
+			if bc, ok := ParseBotComment(strconv.FormatInt(c.ID, 10), c.Body); ok {
+				result = append(result, bc)
+			}
+		}
+	}
+	return result, nil
+}
+
+// createComments 以一次 PR review 发布所有新的行内评论，Gitea 使用新文件中的行号定位
+func (g *Gitea) createComments(ctx context.Context, pr *PullRequest, comments []InlineComment) error {
+	items := make([]map[string]interface{}, 0, len(comments))
+	for _, ic := range comments {
+		items = append(items, map[string]interface{}{
+			"path":         ic.Path,
+			"new_position": ic.Line,
+			"body":         ic.Body,
+		})
+	}
+	return g.client.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/pulls/%d/reviews", g.repo, pr.Number), map[string]interface{}{
+		"commit_id": pr.HeadSHA,
+		"event":     "COMMENT",
+		"body":      fmt.Sprintf("cr-tool 发现 %d 个新问题", len(comments)),
+		"comments":  items,
+	}, nil)
+}
+
+// updateComment 修改行内评论，Gitea 的行内评论和普通评论使用同一个接口修改
+func (g *Gitea) updateComment(ctx context.Context, pr *PullRequest, c BotComment, body string) error {
+	return g.client.do(ctx, http.MethodPatch, fmt.Sprintf("/repos/%s/issues/comments/%s", g.repo, c.ID),
+		map[string]string{"body": body}, nil)
+}
+
+// resolveComment 将评论内容改为已修复
+func (g *Gitea) resolveComment(ctx context.Context, pr *PullRequest, c BotComment) error {
+	return g.updateComment(ctx, pr, c, ResolvedBody(c, pr.HeadSHA))
+}
+
+// upsertSummary 更新或创建总结评论
+func (g *Gitea) upsertSummary(ctx context.Context, pr *PullRequest, body string) error {
+	// 评论列表接口不分页，一次返回全部评论
+	var comments []giteaComment
+	if err := g.client.do(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/issues/%d/comments", g.repo, pr.Number), nil, &comments); err != nil {
+		return err
+	}
+	for _, c := range comments {
+		if isSummary(c.Body) {
+			return g.client.do(ctx, http.MethodPatch, fmt.Sprintf("/repos/%s/issues/comments/%d", g.repo, c.ID),
+				map[string]string{"body": body}, nil)
+		}
+	}
+	return g.client.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/issues/%d/comments", g.repo, pr.Number),
+		map[string]string{"body": body}, nil)
+}
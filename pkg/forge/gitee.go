@@ -0,0 +1,174 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/icatw/cr-tool/pkg/config"
+	"github.com/icatw/cr-tool/pkg/review"
+)
+
+// Gitee Gitee 的 PR 集成
+type Gitee struct {
+	config config.ForgeConfig
+	client *client
+	repo   string
+}
+
+// NewGitee 创建 Gitee 客户端，token 未配置时读取 GITEE_TOKEN
+func NewGitee(cfg config.ForgeConfig) (*Gitee, error) {
+	if cfg.Token == "" {
+		cfg.Token = os.Getenv("GITEE_TOKEN")
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "https://gitee.com/api/v5"
+	}
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("未配置 Gitee token (gitee.token 或 GITEE_TOKEN)")
+	}
+	if strings.Count(cfg.Repo, "/") != 1 {
+		return nil, fmt.Errorf("无效的 Gitee 仓库 %q，格式应为 owner/name", cfg.Repo)
+	}
+
+	// Gitee 通过 access_token 查询参数认证
+	c := newClient("Gitee", cfg.BaseURL, nil)
+	c.query = url.Values{"access_token": {cfg.Token}}
+	return &Gitee{config: cfg, client: c, repo: cfg.Repo}, nil
+}
+
+// giteeFile PR 中单个文件的变更
+type giteeFile struct {
+	Filename string   `json:"filename"`
+	Patch    fileDiff `json:"patch"`
+}
+
+// giteeComment PR 评论，行内评论的 comment_type 为 diff_comment
+type giteeComment struct {
+	ID          int64  `json:"id"`
+	Body        string `json:"body"`
+	CommentType string `json:"comment_type"`
+}
+
+// Name 平台名称
+func (g *Gitee) Name() string {
+	return "gitee"
+}
+
+// PullRequest 获取 PR 信息，响应格式与 GitHub 相同
+func (g *Gitee) PullRequest(ctx context.Context, number int) (*PullRequest, error) {
+	var pull githubPull
+	if err := g.client.do(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/pulls/%d", g.repo, number), nil, &pull); err != nil {
+		return nil, err
+	}
+	return &PullRequest{
+		Number:  pull.Number,
+		Title:   pull.Title,
+		Author:  pull.User.Login,
+		URL:     pull.HTMLURL,
+		HeadRef: pull.Head.Ref,
+		BaseRef: pull.Base.Ref,
+		HeadSHA: pull.Head.SHA,
+		BaseSHA: pull.Base.SHA,
+	}, nil
+}
+
+// Diff 获取 PR 的变更并拼接为 git diff 格式
+func (g *Gitee) Diff(ctx context.Context, pr *PullRequest) (string, error) {
+	var files []giteeFile
+	if err := g.client.do(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/pulls/%d/files", g.repo, pr.Number), nil, &files); err != nil {
+		return "", err
+	}
+	diffs := make([]fileDiff, 0, len(files))
+	for _, f := range files {
+		d := f.Patch
+		if d.NewPath == "" {
+			d.NewPath = f.Filename
+		}
+		if d.OldPath == "" {
+			d.OldPath = d.NewPath
+		}
+		diffs = append(diffs, d)
+	}
+	return joinDiffs(diffs), nil
+}
+
+// SetStatus Gitee 没有提交状态接口，评审结论只体现在总结评论中
+func (g *Gitee) SetStatus(ctx context.Context, pr *PullRequest, state, description string) error {
+	return nil
+}
+
+// Publish 发布评审结果，每个新问题创建一条行内评论
+// Gitee API 无法解决评论，已消失的问题通过修改评论内容标记为已修复
+func (g *Gitee) Publish(ctx context.Context, pr *PullRequest, history *review.ReviewHistory, diffContent string) (*Result, error) {
+	return publish(ctx, g, g.config.FailOn, pr, history, diffContent)
+}
+
+// botComments 获取之前发布的行内评论
+func (g *Gitee) botComments(ctx context.Context, pr *PullRequest) ([]BotComment, error) {
+	comments, err := g.comments(ctx, pr)
+	if err != nil {
+		return nil, err
+	}
+	var result []BotComment
+	for _, c := range comments {
+		if c.CommentType != "diff_comment" {
+			continue
+		}
+		if bc, ok := ParseBotComment(strconv.FormatInt(c.ID, 10), c.Body); ok {
+			result = append(result, bc)
+		}
+	}
+	return result, nil
+}
+
+// createComments 逐条创建行内评论，Gitee 使用 diff 中的位置定位
+func (g *Gitee) createComments(ctx context.Context, pr *PullRequest, comments []InlineComment) error {
+	for _, ic := range comments {
+		err := g.client.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/pulls/%d/comments", g.repo, pr.Number), map[string]interface{}{
+			"body":      ic.Body,
+			"commit_id": pr.HeadSHA,
+			"path":      ic.Path,
+			"position":  ic.Position,
+		}, nil)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateComment 修改评论
+func (g *Gitee) updateComment(ctx context.Context, pr *PullRequest, c BotComment, body string) error {
+	return g.client.do(ctx, http.MethodPatch, fmt.Sprintf("/repos/%s/pulls/comments/%s", g.repo, c.ID),
+		map[string]string{"body": body}, nil)
+}
+
+// resolveComment 将评论内容改为已修复
+func (g *Gitee) resolveComment(ctx context.Context, pr *PullRequest, c BotComment) error {
+	return g.updateComment(ctx, pr, c, ResolvedBody(c, pr.HeadSHA))
+}
+
+// upsertSummary 更新或创建总结评论，总结评论是不带文件位置的 PR 评论
+func (g *Gitee) upsertSummary(ctx context.Context, pr *PullRequest, body string) error {
+	comments, err := g.comments(ctx, pr)
+	if err != nil {
+		return err
+	}
+	for _, c := range comments {
+		if c.CommentType != "diff_comment" && isSummary(c.Body) {
+			return g.updateComment(ctx, pr, BotComment{ID: strconv.FormatInt(c.ID, 10)}, body)
+		}
+	}
+	return g.client.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/pulls/%d/comments", g.repo, pr.Number),
+		map[string]string{"body": body}, nil)
+}
+
+// comments 获取 PR 的全部评论
+func (g *Gitee) comments(ctx context.Context, pr *PullRequest) ([]giteeComment, error) {
+	return pages[giteeComment](ctx, g.client, fmt.Sprintf("/repos/%s/pulls/%d/comments", g.repo, pr.Number))
+}
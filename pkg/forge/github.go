@@ -0,0 +1,193 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/icatw/cr-tool/pkg/config"
+	"github.com/icatw/cr-tool/pkg/review"
+	"github.com/icatw/cr-tool/pkg/text"
+)
+
+// GitHub GitHub 及 GitHub Enterprise 的 PR 集成
+type GitHub struct {
+	config config.ForgeConfig
+	client *client
+	repo   string
+}
+
+// NewGitHub 创建 GitHub 客户端
+// token、repo 和 base_url 未配置时依次读取 GITHUB_TOKEN、GITHUB_REPOSITORY 和 GITHUB_API_URL，
+// 与 GitHub Actions 的环境变量一致
+func NewGitHub(cfg config.ForgeConfig) (*GitHub, error) {
+	if cfg.Token == "" {
+		cfg.Token = os.Getenv("GITHUB_TOKEN")
+	}
+	if cfg.Repo == "" {
+		cfg.Repo = os.Getenv("GITHUB_REPOSITORY")
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = os.Getenv("GITHUB_API_URL")
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "https://api.github.com"
+	}
+	if cfg.StatusContext == "" {
+		cfg.StatusContext = "cr-tool"
+	}
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("未配置 GitHub token (github.token 或 GITHUB_TOKEN)")
+	}
+	if strings.Count(cfg.Repo, "/") != 1 {
+		return nil, fmt.Errorf("无效的 GitHub 仓库 %q，格式应为 owner/name", cfg.Repo)
+	}
+
+	header := http.Header{
+		"Authorization":        {"Bearer " + cfg.Token},
+		"X-Github-Api-Version": {"2022-11-28"},
+	}
+	return &GitHub{
+		config: cfg,
+		client: newClient("GitHub", cfg.BaseURL, header),
+		repo:   cfg.Repo,
+	}, nil
+}
+
+// githubPull PR 接口的响应
+type githubPull struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	HTMLURL string `json:"html_url"`
+	User    struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	Head struct {
+		Ref string `json:"ref"`
+		SHA string `json:"sha"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+		SHA string `json:"sha"`
+	} `json:"base"`
+}
+
+// githubComment 评论接口的响应
+type githubComment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+	// Position 评论所在行已不在最新 diff 中时为 null
+	Position *int `json:"position"`
+}
+
+// PullRequest 获取 PR 信息
+func (g *GitHub) PullRequest(ctx context.Context, number int) (*PullRequest, error) {
+	var pull githubPull
+	if err := g.client.do(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/pulls/%d", g.repo, number), nil, &pull); err != nil {
+		return nil, err
+	}
+	return &PullRequest{
+		Number:  pull.Number,
+		Title:   pull.Title,
+		Author:  pull.User.Login,
+		URL:     pull.HTMLURL,
+		HeadRef: pull.Head.Ref,
+		BaseRef: pull.Base.Ref,
+		HeadSHA: pull.Head.SHA,
+		BaseSHA: pull.Base.SHA,
+	}, nil
+}
+
+// Diff 获取 PR 的 diff
+func (g *GitHub) Diff(ctx context.Context, pr *PullRequest) (string, error) {
+	data, err := g.client.raw(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/pulls/%d", g.repo, pr.Number), nil,
+		http.Header{"Accept": {"application/vnd.github.v3.diff"}})
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// SetStatus 设置提交状态
+func (g *GitHub) SetStatus(ctx context.Context, pr *PullRequest, state, description string) error {
+	return g.client.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/statuses/%s", g.repo, pr.HeadSHA), map[string]string{
+		"state":       state,
+		"context":     g.config.StatusContext,
+		"description": text.Truncate(description, 140),
+	}, nil)
+}
+
+// Name 平台名称
+func (g *GitHub) Name() string {
+	return "github"
+}
+
+// Publish 发布评审结果，所有新的行内评论合并为一次 PR review
+// GitHub REST API 无法解决评论会话，已消失的问题通过修改评论内容标记为已修复
+func (g *GitHub) Publish(ctx context.Context, pr *PullRequest, history *review.ReviewHistory, diffContent string) (*Result, error) {
+	return publish(ctx, g, g.config.FailOn, pr, history, diffContent)
+}
+
+// botComments 获取之前发布的行内评论
+func (g *GitHub) botComments(ctx context.Context, pr *PullRequest) ([]BotComment, error) {
+	comments, err := pages[githubComment](ctx, g.client, fmt.Sprintf("/repos/%s/pulls/%d/comments", g.repo, pr.Number))
+	if err != nil {
+		return nil, err
+	}
+	var result []BotComment
+	for _, c := range comments {
+		if bc, ok := ParseBotComment(strconv.FormatInt(c.ID, 10), c.Body); ok {
+			bc.Outdated = c.Position == nil
+			result = append(result, bc)
+		}
+	}
+	return result, nil
+}
+
+// createComments 以一次 PR review 发布所有新的行内评论
+func (g *GitHub) createComments(ctx context.Context, pr *PullRequest, comments []InlineComment) error {
+	items := make([]map[string]interface{}, 0, len(comments))
+	for _, ic := range comments {
+		items = append(items, map[string]interface{}{
+			"path":     ic.Path,
+			"position": ic.Position,
+			"body":     ic.Body,
+		})
+	}
+	return g.client.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/pulls/%d/reviews", g.repo, pr.Number), map[string]interface{}{
+		"commit_id": pr.HeadSHA,
+		"event":     "COMMENT",
+		"body":      fmt.Sprintf("cr-tool 发现 %d 个新问题", len(comments)),
+		"comments":  items,
+	}, nil)
+}
+
+// updateComment 修改行内评论
+func (g *GitHub) updateComment(ctx context.Context, pr *PullRequest, c BotComment, body string) error {
+	return g.client.do(ctx, http.MethodPatch, fmt.Sprintf("/repos/%s/pulls/comments/%s", g.repo, c.ID),
+		map[string]string{"body": body}, nil)
+}
+
+// resolveComment 将评论内容改为已修复
+func (g *GitHub) resolveComment(ctx context.Context, pr *PullRequest, c BotComment) error {
+	return g.updateComment(ctx, pr, c, ResolvedBody(c, pr.HeadSHA))
+}
+
+// upsertSummary 更新或创建总结评论
+func (g *GitHub) upsertSummary(ctx context.Context, pr *PullRequest, body string) error {
+	comments, err := pages[githubComment](ctx, g.client, fmt.Sprintf("/repos/%s/issues/%d/comments", g.repo, pr.Number))
+	if err != nil {
+		return err
+	}
+	for _, c := range comments {
+		if isSummary(c.Body) {
+			return g.client.do(ctx, http.MethodPatch, fmt.Sprintf("/repos/%s/issues/comments/%d", g.repo, c.ID),
+				map[string]string{"body": body}, nil)
+		}
+	}
+	return g.client.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/issues/%d/comments", g.repo, pr.Number),
+		map[string]string{"body": body}, nil)
+}
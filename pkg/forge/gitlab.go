@@ -0,0 +1,242 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/icatw/cr-tool/pkg/config"
+	"github.com/icatw/cr-tool/pkg/review"
+	"github.com/icatw/cr-tool/pkg/text"
+)
+
+// GitLab GitLab 及私有部署 GitLab 的 MR 集成
+type GitLab struct {
+	config config.ForgeConfig
+	client *client
+	// project URL 编码后的项目路径或项目 ID
+	project string
+}
+
+// NewGitLab 创建 GitLab 客户端
+// token、repo 和 base_url 未配置时依次读取 GITLAB_TOKEN、CI_PROJECT_ID 和 CI_API_V4_URL，
+// 可以直接在 GitLab CI 的合并请求流水线中运行
+func NewGitLab(cfg config.ForgeConfig) (*GitLab, error) {
+	if cfg.Token == "" {
+		cfg.Token = os.Getenv("GITLAB_TOKEN")
+	}
+	if cfg.Repo == "" {
+		cfg.Repo = os.Getenv("CI_PROJECT_ID")
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = os.Getenv("CI_API_V4_URL")
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "https://gitlab.com/api/v4"
+	}
+	if cfg.StatusContext == "" {
+		cfg.StatusContext = "cr-tool"
+	}
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("未配置 GitLab token (gitlab.token 或 GITLAB_TOKEN)")
+	}
+	if cfg.Repo == "" {
+		return nil, fmt.Errorf("未配置 GitLab 项目 (gitlab.repo 或 CI_PROJECT_ID)")
+	}
+
+	return &GitLab{
+		config:  cfg,
+		client:  newClient("GitLab", cfg.BaseURL, http.Header{"Private-Token": {cfg.Token}}),
+		project: url.PathEscape(cfg.Repo),
+	}, nil
+}
+
+// gitlabMR MR 接口的响应
+type gitlabMR struct {
+	IID          int    `json:"iid"`
+	Title        string `json:"title"`
+	WebURL       string `json:"web_url"`
+	SourceBranch string `json:"source_branch"`
+	TargetBranch string `json:"target_branch"`
+	SHA          string `json:"sha"`
+	Author       struct {
+		Username string `json:"username"`
+	} `json:"author"`
+	DiffRefs struct {
+		BaseSHA  string `json:"base_sha"`
+		HeadSHA  string `json:"head_sha"`
+		StartSHA string `json:"start_sha"`
+	} `json:"diff_refs"`
+}
+
+// gitlabNote 评论
+type gitlabNote struct {
+	ID       int64  `json:"id"`
+	Body     string `json:"body"`
+	System   bool   `json:"system"`
+	Resolved bool   `json:"resolved"`
+}
+
+// gitlabDiscussion 讨论，行内评论的第一条 note 为本工具发布的内容
+type gitlabDiscussion struct {
+	ID    string       `json:"id"`
+	Notes []gitlabNote `json:"notes"`
+}
+
+// PullRequest 获取 MR 信息
+func (g *GitLab) PullRequest(ctx context.Context, iid int) (*PullRequest, error) {
+	var mr gitlabMR
+	if err := g.client.do(ctx, http.MethodGet, fmt.Sprintf("/projects/%s/merge_requests/%d", g.project, iid), nil, &mr); err != nil {
+		return nil, err
+	}
+	pr := &PullRequest{
+		Number:   mr.IID,
+		Title:    mr.Title,
+		Author:   mr.Author.Username,
+		URL:      mr.WebURL,
+		HeadRef:  mr.SourceBranch,
+		BaseRef:  mr.TargetBranch,
+		HeadSHA:  mr.DiffRefs.HeadSHA,
+		BaseSHA:  mr.DiffRefs.BaseSHA,
+		StartSHA: mr.DiffRefs.StartSHA,
+	}
+	if pr.HeadSHA == "" {
+		pr.HeadSHA = mr.SHA
+	}
+	return pr, nil
+}
+
+// Diff 获取 MR 的变更并拼接为 git diff 格式
+func (g *GitLab) Diff(ctx context.Context, pr *PullRequest) (string, error) {
+	diffs, err := pages[fileDiff](ctx, g.client, fmt.Sprintf("/projects/%s/merge_requests/%d/diffs", g.project, pr.Number))
+	if err != nil {
+		return "", err
+	}
+	return joinDiffs(diffs), nil
+}
+
+// SetStatus 设置提交状态
+func (g *GitLab) SetStatus(ctx context.Context, pr *PullRequest, state, description string) error {
+	// GitLab 的提交状态没有 failure/error，统一为 failed
+	switch state {
+	case StateFailure, StateError:
+		state = "failed"
+	}
+	query := url.Values{
+		"state":       {state},
+		"name":        {g.config.StatusContext},
+		"description": {text.Truncate(description, 255)},
+	}
+	if pr.URL != "" {
+		query.Set("target_url", pr.URL)
+	}
+	return g.client.do(ctx, http.MethodPost, fmt.Sprintf("/projects/%s/statuses/%s?%s", g.project, pr.HeadSHA, query.Encode()), nil, nil)
+}
+
+// Name 平台名称
+func (g *GitLab) Name() string {
+	return "gitlab"
+}
+
+// Publish 发布评审结果，每个新问题创建一个行内讨论，已消失问题的讨论会被解决
+func (g *GitLab) Publish(ctx context.Context, pr *PullRequest, history *review.ReviewHistory, diffContent string) (*Result, error) {
+	return publish(ctx, g, g.config.FailOn, pr, history, diffContent)
+}
+
+// createComments 为每个问题创建一个行内讨论
+func (g *GitLab) createComments(ctx context.Context, pr *PullRequest, comments []InlineComment) error {
+	for _, ic := range comments {
+		position := map[string]interface{}{
+			"position_type": "text",
+			"base_sha":      pr.BaseSHA,
+			"start_sha":     pr.StartSHA,
+			"head_sha":      pr.HeadSHA,
+			"old_path":      ic.OldPath,
+			"new_path":      ic.Path,
+			"new_line":      ic.Line,
+		}
+		// 上下文行需要同时指定新旧行号
+		if ic.OldLine > 0 {
+			position["old_line"] = ic.OldLine
+		}
+		err := g.client.do(ctx, http.MethodPost, fmt.Sprintf("/projects/%s/merge_requests/%d/discussions", g.project, pr.Number),
+			map[string]interface{}{"body": ic.Body, "position": position}, nil)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateComment 修改讨论的第一条评论，之前已解决的问题再次出现时重新打开讨论
+func (g *GitLab) updateComment(ctx context.Context, pr *PullRequest, c BotComment, body string) error {
+	discussion, note, _ := strings.Cut(c.ID, "/")
+	if err := g.editNote(ctx, pr, discussion, note, body); err != nil {
+		return err
+	}
+	if c.Resolved {
+		return g.resolveDiscussion(ctx, pr, discussion, false)
+	}
+	return nil
+}
+
+// resolveComment 将评论改为已修复并解决讨论
+func (g *GitLab) resolveComment(ctx context.Context, pr *PullRequest, c BotComment) error {
+	discussion, note, _ := strings.Cut(c.ID, "/")
+	if err := g.editNote(ctx, pr, discussion, note, ResolvedBody(c, pr.HeadSHA)); err != nil {
+		return err
+	}
+	return g.resolveDiscussion(ctx, pr, discussion, true)
+}
+
+// botComments 获取之前发布的行内讨论，ID 为 "讨论ID/noteID"
+func (g *GitLab) botComments(ctx context.Context, pr *PullRequest) ([]BotComment, error) {
+	discussions, err := pages[gitlabDiscussion](ctx, g.client, fmt.Sprintf("/projects/%s/merge_requests/%d/discussions", g.project, pr.Number))
+	if err != nil {
+		return nil, err
+	}
+	var result []BotComment
+	for _, d := range discussions {
+		if len(d.Notes) == 0 {
+			continue
+		}
+		note := d.Notes[0]
+		if bc, ok := ParseBotComment(fmt.Sprintf("%s/%d", d.ID, note.ID), note.Body); ok {
+			result = append(result, bc)
+		}
+	}
+	return result, nil
+}
+
+// editNote 修改讨论中的评论
+func (g *GitLab) editNote(ctx context.Context, pr *PullRequest, discussion, note, body string) error {
+	return g.client.do(ctx, http.MethodPut,
+		fmt.Sprintf("/projects/%s/merge_requests/%d/discussions/%s/notes/%s", g.project, pr.Number, discussion, note),
+		map[string]string{"body": body}, nil)
+}
+
+// resolveDiscussion 解决或重新打开讨论
+func (g *GitLab) resolveDiscussion(ctx context.Context, pr *PullRequest, discussion string, resolved bool) error {
+	return g.client.do(ctx, http.MethodPut,
+		fmt.Sprintf("/projects/%s/merge_requests/%d/discussions/%s?resolved=%t", g.project, pr.Number, discussion, resolved),
+		nil, nil)
+}
+
+// upsertSummary 更新或创建总结评论
+func (g *GitLab) upsertSummary(ctx context.Context, pr *PullRequest, body string) error {
+	notes, err := pages[gitlabNote](ctx, g.client, fmt.Sprintf("/projects/%s/merge_requests/%d/notes", g.project, pr.Number))
+	if err != nil {
+		return err
+	}
+	for _, n := range notes {
+		if !n.System && isSummary(n.Body) {
+			return g.client.do(ctx, http.MethodPut, fmt.Sprintf("/projects/%s/merge_requests/%d/notes/%d", g.project, pr.Number, n.ID),
+				map[string]string{"body": body}, nil)
+		}
+	}
+	return g.client.do(ctx, http.MethodPost, fmt.Sprintf("/projects/%s/merge_requests/%d/notes", g.project, pr.Number),
+		map[string]string{"body": body}, nil)
+}
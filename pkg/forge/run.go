@@ -0,0 +1,64 @@
+package forge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/icatw/cr-tool/pkg/config"
+	"github.com/icatw/cr-tool/pkg/review"
+)
+
+// ReviewFunc 评审 PR/MR 的 diff
+type ReviewFunc func(pr *PullRequest, diffContent string) (*review.ReviewHistory, error)
+
+// ConfigFor 返回配置中平台对应的配置项，不支持的平台返回 nil
+func ConfigFor(cfg *config.Config, name string) *config.ForgeConfig {
+	switch name {
+	case "github":
+		return &cfg.GitHub
+	case "gitlab":
+		return &cfg.GitLab
+	case "gitee":
+		return &cfg.Gitee
+	case "gitea":
+		return &cfg.Gitea
+	default:
+		return nil
+	}
+}
+
+// ReviewPullRequest 获取 PR/MR 的 diff 进行评审，并将结果发布到平台
+// 没有需要评审的文件时提交状态设为成功，返回的 Result 为 nil
+func ReviewPullRequest(ctx context.Context, f Forge, number int, reviewFn ReviewFunc) (*PullRequest, *Result, error) {
+	pr, err := f.PullRequest(ctx, number)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := f.SetStatus(ctx, pr, StatePending, "代码评审中"); err != nil {
+		log.Printf("设置提交状态失败: %v", err)
+	}
+
+	diffContent, err := f.Diff(ctx, pr)
+	if err != nil {
+		return pr, nil, err
+	}
+
+	history, err := reviewFn(pr, diffContent)
+	if errors.Is(err, review.ErrEmptyDiff) {
+		return pr, nil, f.SetStatus(ctx, pr, StateSuccess, "没有需要评审的文件")
+	}
+	if err != nil {
+		if statusErr := f.SetStatus(ctx, pr, StateError, "代码评审失败"); statusErr != nil {
+			log.Printf("设置提交状态失败: %v", statusErr)
+		}
+		return pr, nil, fmt.Errorf("代码评审失败: %w", err)
+	}
+	if history.GitInfo == nil {
+		history.GitInfo = pr.GitInfo(diffContent)
+	}
+
+	result, err := f.Publish(ctx, pr, history, diffContent)
+	return pr, result, err
+}
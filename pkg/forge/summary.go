@@ -0,0 +1,61 @@
+package forge
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/icatw/cr-tool/pkg/review"
+	"github.com/icatw/cr-tool/pkg/text"
+)
+
+// maxCommentBytes 评论内容上限，低于各平台的限制（GitHub 65536 字符）
+const maxCommentBytes = 60000
+
+// SummaryBody 总结评论内容
+func SummaryBody(pr *PullRequest, history *review.ReviewHistory, outside []review.Finding, result *Result) string {
+	var b strings.Builder
+	b.WriteString(summaryMarker + "\n")
+	b.WriteString("## 代码评审结果\n\n")
+
+	counts := make(map[review.Severity]int)
+	for _, f := range history.Findings {
+		counts[f.Severity]++
+	}
+	b.WriteString("| 严重 | 中等 | 低 |\n|---|---|---|\n")
+	b.WriteString(fmt.Sprintf("| %d | %d | %d |\n\n",
+		counts[review.SeverityHigh], counts[review.SeverityMedium], counts[review.SeverityLow]))
+
+	if result.State == StateFailure {
+		b.WriteString(fmt.Sprintf("**状态**：❌ 未通过，%s\n\n", result.Description))
+	} else {
+		b.WriteString("**状态**：✅ 通过\n\n")
+	}
+	b.WriteString(fmt.Sprintf("本次新增 %d 条行内评论，更新 %d 条，%d 个问题已修复。", result.Created, result.Updated, result.Resolved))
+	if history.Suppressed > 0 {
+		b.WriteString(fmt.Sprintf("另有 %d 个已知问题被忽略。", history.Suppressed))
+	}
+	b.WriteString("\n")
+
+	if len(outside) > 0 {
+		b.WriteString("\n### 未能定位到改动行的问题\n\n")
+		for _, f := range outside {
+			b.WriteString(fmt.Sprintf("- **[%s]** `%s` %s\n", f.Severity, f.Location(), f.Title))
+		}
+	}
+
+	footer := fmt.Sprintf("\n> 由 cr-tool 生成 · 提交 %s\n", shortSHA(pr.HeadSHA))
+	if result := strings.TrimSpace(history.ReviewResult); result != "" {
+		const open, close = "\n<details><summary>评审详情</summary>\n\n", "\n\n</details>\n"
+		room := maxCommentBytes - b.Len() - len(footer) - len(open) - len(close)
+		if room > 0 {
+			b.WriteString(open + text.Truncate(result, room) + close)
+		}
+	}
+	b.WriteString(footer)
+	return b.String()
+}
+
+// isSummary 是否为本工具发布的总结评论
+func isSummary(body string) bool {
+	return strings.HasPrefix(strings.TrimSpace(body), summaryMarker)
+}
@@ -0,0 +1,29 @@
+package glob
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/main.go", false},
+		{"vendor/*", "vendor/a.go", true},
+		{"vendor/*", "vendor/pkg/a.go", false},
+		{"vendor/**", "vendor/pkg/a.go", true},
+		{"**/*.sql", "a.sql", true},
+		{"**/*.sql", "db/migrations/a.sql", true},
+		{"auth/**/*.go", "auth/jwt.go", true},
+		{"auth/**/*.go", "auth/token/jwt.go", true},
+		{"auth/**/*.go", "pkg/auth/jwt.go", false},
+		{"a/**/b/**/c", "a/x/b/y/z/c", true},
+	}
+
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
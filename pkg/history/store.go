@@ -0,0 +1,234 @@
+// Package history 持久化保存评审记录，并支持按条件查询
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/icatw/cr-tool/pkg/review"
+)
+
+// ErrNotFound 评审记录不存在
+var ErrNotFound = errors.New("评审记录不存在")
+
+// Record 评审记录，在评审结果的基础上保存原始 diff
+type Record struct {
+	review.ReviewHistory
+	Diff string `json:"diff,omitempty"`
+	// Decisions 通过 cr triage 记录的问题处理决定
+	Decisions []Decision `json:"decisions,omitempty"`
+	// Conversation 通过 cr ask 和 cr chat 追问的问答记录
+	Conversation []review.Message `json:"conversation,omitempty"`
+}
+
+// NewRecord 创建评审记录
+func NewRecord(history *review.ReviewHistory, diffContent string) *Record {
+	return &Record{
+		ReviewHistory: *history,
+		Diff:          diffContent,
+	}
+}
+
+// Branch 返回记录的分支，没有 Git 信息时为空
+func (r *Record) Branch() string {
+	if r.GitInfo == nil {
+		return ""
+	}
+	return r.GitInfo.Branch
+}
+
+// Author 返回记录的作者，没有 Git 信息时为空
+func (r *Record) Author() string {
+	if r.GitInfo == nil {
+		return ""
+	}
+	return r.GitInfo.Author
+}
+
+// MaxSeverity 返回记录中最严重的问题级别
+func (r *Record) MaxSeverity() review.Severity {
+	var max review.Severity
+	for _, f := range r.Findings {
+		if f.Severity.Rank() > max.Rank() {
+			max = f.Severity
+		}
+	}
+	return max
+}
+
+// Filter 查询条件，零值表示不限制
+type Filter struct {
+	Branch      string
+	Author      string
+	Since       time.Time
+	Until       time.Time
+	MinSeverity review.Severity
+	Limit       int
+}
+
+// Match 判断记录是否满足查询条件
+func (f Filter) Match(r *Record) bool {
+	if f.Branch != "" && r.Branch() != f.Branch {
+		return false
+	}
+	if f.Author != "" && !strings.Contains(strings.ToLower(r.Author()), strings.ToLower(f.Author)) {
+		return false
+	}
+	if !f.Since.IsZero() && r.DateTime.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && r.DateTime.After(f.Until) {
+		return false
+	}
+	if f.MinSeverity != "" && r.MaxSeverity().Rank() < f.MinSeverity.Rank() {
+		return false
+	}
+	return true
+}
+
+// Store 评审记录存储，每条记录保存为目录下的一个 JSON 文件
+type Store struct {
+	dir string
+}
+
+// Open 打开评审记录存储，目录不存在时自动创建
+func Open(dir string) (*Store, error) {
+	if strings.HasPrefix(dir, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("获取用户目录失败: %w", err)
+		}
+		dir = filepath.Join(home, dir[2:])
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建历史记录目录失败: %w", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Dir 返回存储目录
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Save 保存评审记录，同一记录重复保存时覆盖原文件
+func (s *Store) Save(r *Record) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化评审记录失败: %w", err)
+	}
+	if err := os.WriteFile(s.path(r), data, 0644); err != nil {
+		return fmt.Errorf("保存评审记录失败: %w", err)
+	}
+	return nil
+}
+
+// Get 按 ID 或 ID 前缀获取评审记录，同一 ID 有多条记录时返回最新的一条
+func (s *Store) Get(id string) (*Record, error) {
+	records, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range records {
+		if id != "" && strings.HasPrefix(r.ID, id) {
+			return r, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+}
+
+// Latest 返回最新的评审记录
+func (s *Store) Latest() (*Record, error) {
+	records, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, ErrNotFound
+	}
+	return records[0], nil
+}
+
+// List 按时间倒序列出满足条件的评审记录
+func (s *Store) List(filter Filter) ([]*Record, error) {
+	records, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*Record, 0, len(records))
+	for _, r := range records {
+		if !filter.Match(r) {
+			continue
+		}
+		result = append(result, r)
+		if filter.Limit > 0 && len(result) >= filter.Limit {
+			break
+		}
+	}
+	return result, nil
+}
+
+// Prune 删除早于指定时间的记录，并最多保留 keep 条最新记录，返回删除的数量
+// before 为零值或 keep 小于等于 0 时对应条件不生效
+func (s *Store) Prune(before time.Time, keep int) (int, error) {
+	records, err := s.load()
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for i, r := range records {
+		expired := !before.IsZero() && r.DateTime.Before(before)
+		overflow := keep > 0 && i >= keep
+		if !expired && !overflow {
+			continue
+		}
+		if err := os.Remove(s.path(r)); err != nil && !os.IsNotExist(err) {
+			return removed, fmt.Errorf("删除评审记录失败: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// path 返回记录对应的文件路径
+func (s *Store) path(r *Record) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%s_%s.json", r.DateTime.Format("20060102_150405"), r.ID))
+}
+
+// load 读取全部记录，按时间倒序排列
+func (s *Store) load() ([]*Record, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取历史记录目录失败: %w", err)
+	}
+
+	records := make([]*Record, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal(data, &r); err != nil {
+			continue
+		}
+		records = append(records, &r)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].DateTime.After(records[j].DateTime)
+	})
+	return records, nil
+}
@@ -0,0 +1,99 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/icatw/cr-tool/pkg/review"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRecord(id, branch, author string, at time.Time, severities ...review.Severity) *Record {
+	h := &review.ReviewHistory{
+		ID:       id,
+		GitInfo:  &review.GitInfo{Branch: branch, Author: author},
+		DateTime: at,
+	}
+	for _, s := range severities {
+		h.Findings = append(h.Findings, review.Finding{File: "main.go", Severity: s, Title: string(s)})
+	}
+	return NewRecord(h, "diff --git a/main.go b/main.go\n")
+}
+
+func TestStore(t *testing.T) {
+	store, err := Open(t.TempDir())
+	assert.NoError(t, err)
+
+	now := time.Now()
+	records := []*Record{
+		newRecord("aaaa1111", "main", "Alice", now.Add(-72*time.Hour), review.SeverityLow),
+		newRecord("bbbb2222", "feature/x", "Bob", now.Add(-24*time.Hour), review.SeverityHigh),
+		newRecord("cccc3333", "main", "Bob", now.Add(-time.Hour)),
+	}
+	for _, r := range records {
+		assert.NoError(t, store.Save(r))
+	}
+
+	got, err := store.Get("bbbb")
+	assert.NoError(t, err)
+	assert.Equal(t, "feature/x", got.Branch())
+	assert.Equal(t, "diff --git a/main.go b/main.go\n", got.Diff)
+
+	_, err = store.Get("dddd")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	latest, err := store.Latest()
+	assert.NoError(t, err)
+	assert.Equal(t, "cccc3333", latest.ID)
+
+	list, err := store.List(Filter{Branch: "main"})
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+	assert.Equal(t, "cccc3333", list[0].ID)
+
+	list, err = store.List(Filter{Author: "bob", Since: now.Add(-48 * time.Hour)})
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+
+	list, err = store.List(Filter{MinSeverity: review.SeverityMedium})
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, "bbbb2222", list[0].ID)
+
+	removed, err := store.Prune(now.Add(-48*time.Hour), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	removed, err = store.Prune(time.Time{}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	list, err = store.List(Filter{})
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, "cccc3333", list[0].ID)
+}
+
+func TestDecisions(t *testing.T) {
+	store, err := Open(t.TempDir())
+	assert.NoError(t, err)
+	r := newRecord("aaaa1111", "main", "Alice", time.Now(), review.SeverityHigh, review.SeverityLow)
+	high, low := r.Findings[0], r.Findings[1]
+
+	r.Decide(high, StatusFalsePositive, false)
+	r.Decide(high, StatusAccepted, true)
+	r.Decide(low, StatusWontFix, false)
+	r.Decide(low, "", false)
+	assert.NoError(t, store.Save(r))
+
+	got, err := store.Get("aaaa")
+	assert.NoError(t, err)
+	assert.Len(t, got.Decisions, 1)
+	d, ok := got.Decision(high)
+	assert.True(t, ok)
+	assert.Equal(t, StatusAccepted, d.Status)
+	assert.True(t, d.Applied)
+	_, ok = got.Decision(low)
+	assert.False(t, ok)
+	assert.Equal(t, "待处理", Status("").Label())
+}
@@ -0,0 +1,72 @@
+package history
+
+import (
+	"time"
+
+	"github.com/icatw/cr-tool/pkg/review"
+)
+
+// Status 问题的处理结果
+type Status string
+
+const (
+	StatusAccepted      Status = "accepted"
+	StatusFalsePositive Status = "false_positive"
+	StatusWontFix       Status = "wont_fix"
+)
+
+// Label 处理结果的显示名称
+func (s Status) Label() string {
+	switch s {
+	case StatusAccepted:
+		return "接受"
+	case StatusFalsePositive:
+		return "误报"
+	case StatusWontFix:
+		return "不修复"
+	default:
+		return "待处理"
+	}
+}
+
+// Decision 对问题的处理决定，通过指纹和行号对应到问题
+type Decision struct {
+	Fingerprint string `json:"fingerprint"`
+	Line        int    `json:"line,omitempty"`
+	Status      Status `json:"status"`
+	// Applied 是否已将修复应用到工作区
+	Applied   bool      `json:"applied,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Decision 返回问题的处理决定
+func (r *Record) Decision(f review.Finding) (Decision, bool) {
+	fingerprint := f.Fingerprint()
+	for _, d := range r.Decisions {
+		if d.Fingerprint == fingerprint && d.Line == f.Line {
+			return d, true
+		}
+	}
+	return Decision{}, false
+}
+
+// Decide 记录问题的处理决定，status 为空时清除决定
+func (r *Record) Decide(f review.Finding, status Status, applied bool) {
+	fingerprint := f.Fingerprint()
+	for i, d := range r.Decisions {
+		if d.Fingerprint == fingerprint && d.Line == f.Line {
+			r.Decisions = append(r.Decisions[:i], r.Decisions[i+1:]...)
+			break
+		}
+	}
+	if status == "" {
+		return
+	}
+	r.Decisions = append(r.Decisions, Decision{
+		Fingerprint: fingerprint,
+		Line:        f.Line,
+		Status:      status,
+		Applied:     applied,
+		UpdatedAt:   time.Now(),
+	})
+}
@@ -0,0 +1,156 @@
+// Package ignore 实现 gitignore 风格的文件忽略规则
+package ignore
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/icatw/cr-tool/pkg/glob"
+)
+
+// FileName 仓库级忽略文件的默认名称
+const FileName = ".crignore"
+
+// rule 单条忽略规则
+type rule struct {
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// Matcher 忽略规则匹配器，规则按顺序生效，后面的规则覆盖前面的规则
+type Matcher struct {
+	rules []rule
+}
+
+// New 根据规则列表创建匹配器
+func New(patterns []string) *Matcher {
+	m := &Matcher{}
+	m.Add(patterns...)
+	return m
+}
+
+// Load 读取忽略文件并创建匹配器，文件不存在时返回空匹配器
+func Load(filename string) (*Matcher, error) {
+	patterns, err := ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return New(patterns), nil
+}
+
+// ReadFile 读取忽略文件中的规则，文件不存在时返回空列表
+func ReadFile(filename string) ([]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("读取忽略文件失败: %w", err)
+	}
+	defer file.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		patterns = append(patterns, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取忽略文件失败: %w", err)
+	}
+	return patterns, nil
+}
+
+// Add 追加规则，空行和 # 开头的注释会被跳过
+func (m *Matcher) Add(patterns ...string) {
+	for _, p := range patterns {
+		if r, ok := parseRule(p); ok {
+			m.rules = append(m.rules, r)
+		}
+	}
+}
+
+// Match 判断路径是否被忽略
+// 与 git 一致，父目录被忽略时其中的文件无法再通过 ! 规则重新包含
+func (m *Matcher) Match(name string) bool {
+	name = strings.Trim(path.Clean(strings.ReplaceAll(name, "\\", "/")), "/")
+	if name == "" || name == "." || len(m.rules) == 0 {
+		return false
+	}
+
+	parts := strings.Split(name, "/")
+	for i := 1; i < len(parts); i++ {
+		if m.match(strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+	return m.match(name, false)
+}
+
+// match 使用最后一条命中的规则判断单个路径
+func (m *Matcher) match(name string, isDir bool) bool {
+	ignored := false
+	for _, r := range m.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		if r.matches(name) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// matches 判断规则是否匹配路径
+func (r rule) matches(name string) bool {
+	if r.anchored {
+		return glob.Match(r.pattern, name)
+	}
+	return glob.Match(r.pattern, path.Base(name))
+}
+
+// parseRule 解析单条规则
+func parseRule(line string) (rule, bool) {
+	line = trimTrailingSpaces(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule{}, false
+	}
+
+	var r rule
+	switch {
+	case strings.HasPrefix(line, "!"):
+		r.negate = true
+		line = line[1:]
+	case strings.HasPrefix(line, `\!`), strings.HasPrefix(line, `\#`):
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	// 开头或中间包含 / 的规则相对仓库根目录匹配
+	if strings.Contains(line, "/") {
+		r.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return rule{}, false
+	}
+
+	r.pattern = line
+	return r, true
+}
+
+// trimTrailingSpaces 去掉未转义的行尾空格
+func trimTrailingSpaces(line string) string {
+	line = strings.TrimRight(line, "\r")
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	return strings.ReplaceAll(line, `\ `, " ")
+}
@@ -0,0 +1,64 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	m := New([]string{
+		"# 注释",
+		"vendor/",
+		"*.md",
+		"!README.md",
+		"/build",
+		"docs/**/*.png",
+		"generated/*",
+		"!generated/keep.go",
+		"logs/",
+		"!logs/important.log",
+	})
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"vendor/a.go", true},
+		{"third_party/vendor/pkg/a.go", true},
+		{"vendor.go", false},
+		{"CHANGELOG.md", true},
+		{"docs/guide.md", true},
+		{"README.md", false},
+		{"build/out.js", true},
+		{"cmd/build/main.go", false},
+		{"docs/img/a/b.png", true},
+		{"docs/b.png", true},
+		{"generated/x.go", true},
+		{"generated/keep.go", false},
+		{"logs/important.log", true},
+		{"main.go", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, m.Match(tt.name), tt.name)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, FileName)
+	assert.NoError(t, os.WriteFile(file, []byte("*.pb.go\n\n  \nmocks/\n"), 0644))
+
+	m, err := Load(file)
+	assert.NoError(t, err)
+	assert.True(t, m.Match("api/v1/user.pb.go"))
+	assert.True(t, m.Match("internal/mocks/repo.go"))
+	assert.False(t, m.Match("internal/repo.go"))
+
+	m, err = Load(filepath.Join(dir, "missing"))
+	assert.NoError(t, err)
+	assert.False(t, m.Match("main.go"))
+}
@@ -0,0 +1,67 @@
+package lsp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// GitDiff 返回文件相对 HEAD 未提交的改动（包括暂存区）和文件在仓库中的路径，
+// 未跟踪的文件作为新增文件返回完整内容
+func GitDiff(path string) (string, string, error) {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	root, err := git(filepath.Dir(path), "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", "", fmt.Errorf("文件不在 Git 仓库中: %w", err)
+	}
+	root = strings.TrimSpace(root)
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return "", "", err
+	}
+	rel = filepath.ToSlash(rel)
+
+	if _, err := git(root, "ls-files", "--error-unmatch", "--", rel); err != nil {
+		// 未跟踪的文件，git diff --no-index 有差异时退出码为 1
+		out, err := git(root, "diff", "--no-index", "--", "/dev/null", rel)
+		var exitErr *exec.ExitError
+		if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+			return "", "", err
+		}
+		return out, rel, nil
+	}
+
+	out, err := git(root, "diff", "HEAD", "--", rel)
+	if err != nil {
+		return "", "", err
+	}
+	return out, rel, nil
+}
+
+// git 在 dir 中执行 git 命令并返回标准输出
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return string(out), err
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return string(out), fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return string(out), fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return string(out), nil
+}
@@ -0,0 +1,429 @@
+// Package lsp 实现 Language Server Protocol 服务端（stdio 传输），
+// 保存文件时评审未提交的改动，并将问题作为诊断信息发布到编辑器
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/icatw/cr-tool/pkg/review"
+)
+
+// 诊断级别
+const (
+	severityError       = 1
+	severityWarning     = 2
+	severityInformation = 3
+)
+
+// Options 服务依赖的外部操作，测试时可以替换为本地实现
+type Options struct {
+	// Review 评审 diff
+	Review func(diffContent string) (*review.ReviewHistory, error)
+	// Diff 返回文件未提交的改动和文件在 diff 中的路径，默认为 GitDiff
+	Diff func(path string) (string, string, error)
+	// Debounce 保存后等待的时间，期间再次保存会重新计时
+	Debounce time.Duration
+}
+
+// Position 文档中的位置，行和列都从 0 开始
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range 文档中的范围，不包含 End
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Diagnostic 诊断信息
+type Diagnostic struct {
+	Range    Range  `json:"range"`
+	Severity int    `json:"severity"`
+	Code     string `json:"code,omitempty"`
+	Source   string `json:"source"`
+	Message  string `json:"message"`
+}
+
+// TextEdit 文本修改
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+// CodeAction 代码操作
+type CodeAction struct {
+	Title       string       `json:"title"`
+	Kind        string       `json:"kind"`
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
+	Edit        struct {
+		Changes map[string][]TextEdit `json:"changes"`
+	} `json:"edit"`
+}
+
+// document 已打开的文档
+type document struct {
+	findings []review.Finding
+	// dirty 评审后文档又被修改，问题的位置可能已经变化，不再提供自动修复
+	dirty bool
+	// seq 保存次数，评审结束时 seq 已变化说明结果过期
+	seq   int
+	timer *time.Timer
+	// lastDiff 上次评审的 diff，未变化时不重复评审
+	lastDiff string
+}
+
+// Server LSP 服务端
+type Server struct {
+	opts Options
+	conn *conn
+
+	mu       sync.Mutex
+	docs     map[string]*document
+	shutdown bool
+	// reviewMu 同一时间只进行一次评审
+	reviewMu sync.Mutex
+}
+
+// NewServer 创建服务端
+func NewServer(opts Options) *Server {
+	if opts.Diff == nil {
+		opts.Diff = GitDiff
+	}
+	if opts.Debounce <= 0 {
+		opts.Debounce = 500 * time.Millisecond
+	}
+	return &Server{opts: opts, docs: make(map[string]*document)}
+}
+
+// Serve 处理 r 中的消息并将响应写入 w，直到收到 exit 通知或输入结束
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	s.conn = newConn(r, w)
+	defer s.stopTimers()
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		data, err := s.conn.read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("读取消息失败: %w", err)
+		}
+
+		var msg message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			s.conn.reply(json.RawMessage("null"), nil, &rpcError{codeParseError, "无法解析的消息: " + err.Error()})
+			continue
+		}
+		if msg.Method == "exit" {
+			return nil
+		}
+		if msg.ID == nil {
+			s.handleNotification(msg)
+			continue
+		}
+		result, rpcErr := s.handleRequest(msg)
+		if err := s.conn.reply(msg.ID, result, rpcErr); err != nil {
+			return fmt.Errorf("写入响应失败: %w", err)
+		}
+	}
+}
+
+// handleRequest 处理请求
+func (s *Server) handleRequest(msg message) (interface{}, *rpcError) {
+	s.mu.Lock()
+	shutdown := s.shutdown
+	s.mu.Unlock()
+	if shutdown {
+		return nil, &rpcError{codeInvalidRequest, "服务已关闭"}
+	}
+
+	switch msg.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				// change 为增量同步，只用于判断文档是否被修改
+				"textDocumentSync": map[string]interface{}{
+					"openClose": true,
+					"change":    2,
+					"save":      map[string]bool{"includeText": false},
+				},
+				"codeActionProvider": map[string]interface{}{"codeActionKinds": []string{"quickfix"}},
+			},
+			"serverInfo": map[string]string{"name": "cr-tool"},
+		}, nil
+	case "shutdown":
+		s.mu.Lock()
+		s.shutdown = true
+		s.mu.Unlock()
+		return nil, nil
+	case "textDocument/codeAction":
+		var params struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+			} `json:"textDocument"`
+			Range Range `json:"range"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &rpcError{codeInvalidParams, "无效的参数: " + err.Error()}
+		}
+		return s.codeActions(params.TextDocument.URI, params.Range), nil
+	default:
+		return nil, &rpcError{codeMethodNotFound, "不支持的方法: " + msg.Method}
+	}
+}
+
+// handleNotification 处理通知，未知的通知直接忽略
+func (s *Server) handleNotification(msg message) {
+	var params struct {
+		TextDocument struct {
+			URI string `json:"uri"`
+		} `json:"textDocument"`
+	}
+	json.Unmarshal(msg.Params, &params)
+	uri := params.TextDocument.URI
+	if uri == "" {
+		return
+	}
+
+	switch msg.Method {
+	case "textDocument/didOpen":
+		s.mu.Lock()
+		s.document(uri)
+		s.mu.Unlock()
+	case "textDocument/didChange":
+		s.mu.Lock()
+		s.document(uri).dirty = true
+		s.mu.Unlock()
+	case "textDocument/didSave":
+		s.schedule(uri)
+	case "textDocument/didClose":
+		s.mu.Lock()
+		if doc, ok := s.docs[uri]; ok {
+			if doc.timer != nil {
+				doc.timer.Stop()
+			}
+			delete(s.docs, uri)
+		}
+		s.mu.Unlock()
+		s.publish(uri, nil)
+	}
+}
+
+// document 获取或创建文档，调用方需持有锁
+func (s *Server) document(uri string) *document {
+	doc, ok := s.docs[uri]
+	if !ok {
+		doc = &document{}
+		s.docs[uri] = doc
+	}
+	return doc
+}
+
+// schedule 保存后等待 Debounce 再评审，期间再次保存会重新计时
+func (s *Server) schedule(uri string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	doc := s.document(uri)
+	doc.seq++
+	seq := doc.seq
+	if doc.timer != nil {
+		doc.timer.Stop()
+	}
+	doc.timer = time.AfterFunc(s.opts.Debounce, func() { s.review(uri, seq) })
+}
+
+// stopTimers 停止所有等待中的评审
+func (s *Server) stopTimers() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, doc := range s.docs {
+		if doc.timer != nil {
+			doc.timer.Stop()
+		}
+	}
+}
+
+// current 评审结果是否仍然有效，调用方需持有锁
+func (s *Server) current(uri string, seq int) (*document, bool) {
+	doc, ok := s.docs[uri]
+	return doc, ok && doc.seq == seq
+}
+
+// review 评审文件未提交的改动并发布诊断
+func (s *Server) review(uri string, seq int) {
+	s.reviewMu.Lock()
+	defer s.reviewMu.Unlock()
+
+	s.mu.Lock()
+	doc, ok := s.current(uri, seq)
+	var lastDiff string
+	if ok {
+		lastDiff = doc.lastDiff
+	}
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	path, err := uriToPath(uri)
+	if err != nil {
+		s.logError(err)
+		return
+	}
+	diffContent, name, err := s.opts.Diff(path)
+	if err != nil {
+		s.logError(fmt.Errorf("获取 %s 的改动失败: %w", path, err))
+		return
+	}
+
+	var findings []review.Finding
+	switch {
+	case strings.TrimSpace(diffContent) == "":
+		// 没有未提交的改动，清除诊断
+	case diffContent == lastDiff:
+		s.mu.Lock()
+		if doc, ok := s.current(uri, seq); ok {
+			doc.dirty = false
+			findings = doc.findings
+		}
+		s.mu.Unlock()
+	default:
+		history, err := s.opts.Review(diffContent)
+		if err != nil && !errors.Is(err, review.ErrEmptyDiff) {
+			s.logError(fmt.Errorf("评审 %s 失败: %w", name, err))
+			return
+		}
+		if history != nil {
+			for _, f := range history.Findings {
+				if f.File == name {
+					findings = append(findings, f)
+				}
+			}
+		}
+	}
+
+	s.mu.Lock()
+	doc, ok = s.current(uri, seq)
+	if ok {
+		doc.findings, doc.lastDiff, doc.dirty = findings, diffContent, false
+	}
+	s.mu.Unlock()
+	if ok {
+		s.publish(uri, findings)
+	}
+}
+
+// publish 发布文档的诊断信息
+func (s *Server) publish(uri string, findings []review.Finding) {
+	diagnostics := make([]Diagnostic, 0, len(findings))
+	for _, f := range findings {
+		diagnostics = append(diagnostics, diagnostic(f))
+	}
+	s.conn.notify("textDocument/publishDiagnostics", map[string]interface{}{
+		"uri":         uri,
+		"diagnostics": diagnostics,
+	})
+}
+
+// logError 在编辑器的输出面板中记录错误
+func (s *Server) logError(err error) {
+	s.conn.notify("window/logMessage", map[string]interface{}{"type": 1, "message": "cr-tool: " + err.Error()})
+}
+
+// codeActions 返回范围内有修复代码的问题对应的快速修复
+func (s *Server) codeActions(uri string, r Range) []CodeAction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	actions := []CodeAction{}
+	doc, ok := s.docs[uri]
+	if !ok || doc.dirty {
+		return actions
+	}
+	for _, f := range doc.findings {
+		if f.Fix == "" || f.Line <= 0 {
+			continue
+		}
+		d := diagnostic(f)
+		if d.Range.End.Line <= r.Start.Line || d.Range.Start.Line > r.End.Line {
+			continue
+		}
+		newText := f.Fix
+		if !strings.HasSuffix(newText, "\n") {
+			newText += "\n"
+		}
+		action := CodeAction{Title: "cr-tool 修复：" + f.Title, Kind: "quickfix", Diagnostics: []Diagnostic{d}}
+		action.Edit.Changes = map[string][]TextEdit{uri: {{Range: d.Range, NewText: newText}}}
+		actions = append(actions, action)
+	}
+	return actions
+}
+
+// diagnostic 将问题转换为诊断信息，范围为 Line 到 EndLine 的整行
+func diagnostic(f review.Finding) Diagnostic {
+	start := f.Line - 1
+	if start < 0 {
+		start = 0
+	}
+	end := f.EndLine
+	if end < f.Line {
+		end = f.Line
+	}
+	if end <= start {
+		end = start + 1
+	}
+
+	severity := severityInformation
+	switch f.Severity {
+	case review.SeverityHigh:
+		severity = severityError
+	case review.SeverityMedium:
+		severity = severityWarning
+	}
+
+	message := f.Title
+	if f.Detail != "" {
+		message += "\n" + f.Detail
+	}
+	if f.Suggestion != "" {
+		message += "\n建议：" + f.Suggestion
+	}
+	return Diagnostic{
+		Range:    Range{Start: Position{Line: start}, End: Position{Line: end}},
+		Severity: severity,
+		Code:     f.Category,
+		Source:   "cr-tool",
+		Message:  message,
+	}
+}
+
+// windowsDrive 匹配 file URI 中的 Windows 盘符，如 /C:/
+var windowsDrive = regexp.MustCompile(`^/[A-Za-z]:/`)
+
+// uriToPath 将 file URI 转换为本地路径
+func uriToPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return "", fmt.Errorf("不支持的文档地址: %s", uri)
+	}
+	path := u.Path
+	if runtime.GOOS == "windows" && windowsDrive.MatchString(path) {
+		path = path[1:]
+	}
+	return path, nil
+}
@@ -0,0 +1,195 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/icatw/cr-tool/pkg/review"
+	"github.com/stretchr/testify/assert"
+)
+
+// client 测试用的 LSP 客户端
+type client struct {
+	t    *testing.T
+	in   *io.PipeWriter
+	conn *conn
+	id   int
+}
+
+func newClient(t *testing.T, s *Server) (*client, chan error) {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Serve(context.Background(), serverIn, serverOut)
+		serverOut.Close()
+	}()
+	return &client{t: t, in: clientOut, conn: newConn(clientIn, clientOut)}, done
+}
+
+func (c *client) notify(method string, params interface{}) {
+	assert.NoError(c.t, c.conn.notify(method, params))
+}
+
+// call 发送请求并返回响应，期间收到的通知会被忽略
+func (c *client) call(method string, params interface{}) map[string]interface{} {
+	c.id++
+	data, _ := json.Marshal(params)
+	assert.NoError(c.t, c.conn.write(&message{ID: json.RawMessage(fmt.Sprint(c.id)), Method: method, Params: data}))
+	for {
+		msg := c.next()
+		if msg["method"] == nil {
+			return msg
+		}
+	}
+}
+
+// next 读取下一条消息
+func (c *client) next() map[string]interface{} {
+	data, err := c.conn.read()
+	assert.NoError(c.t, err)
+	var msg map[string]interface{}
+	assert.NoError(c.t, json.Unmarshal(data, &msg))
+	return msg
+}
+
+func TestServe(t *testing.T) {
+	var reviews int32
+	s := NewServer(Options{
+		Debounce: 20 * time.Millisecond,
+		Diff: func(path string) (string, string, error) {
+			return "diff --git a/main.go b/main.go\n+fmt.Println(x)\n", "main.go", nil
+		},
+		Review: func(diffContent string) (*review.ReviewHistory, error) {
+			atomic.AddInt32(&reviews, 1)
+			return &review.ReviewHistory{Findings: []review.Finding{
+				{File: "main.go", Line: 3, EndLine: 4, Severity: review.SeverityHigh, Category: "correctness", Title: "未处理错误", Suggestion: "检查 err", Fix: "\tif err != nil {\n\t\treturn err\n\t}"},
+				{File: "main.go", Line: 10, Severity: review.SeverityLow, Title: "命名不清晰"},
+				{File: "other.go", Line: 1, Severity: review.SeverityMedium, Title: "其他文件"},
+			}}, nil
+		},
+	})
+	c, done := newClient(t, s)
+	uri := "file:///repo/main.go"
+	doc := map[string]interface{}{"textDocument": map[string]string{"uri": uri}}
+
+	caps := c.call("initialize", map[string]interface{}{})["result"].(map[string]interface{})["capabilities"].(map[string]interface{})
+	assert.Equal(t, true, caps["textDocumentSync"].(map[string]interface{})["openClose"])
+	assert.NotNil(t, caps["codeActionProvider"])
+	c.notify("initialized", map[string]interface{}{})
+	c.notify("textDocument/didOpen", doc)
+
+	// 连续保存只评审一次
+	c.notify("textDocument/didSave", doc)
+	c.notify("textDocument/didSave", doc)
+	msg := c.next()
+	assert.Equal(t, "textDocument/publishDiagnostics", msg["method"])
+	params := msg["params"].(map[string]interface{})
+	assert.Equal(t, uri, params["uri"])
+	diagnostics := params["diagnostics"].([]interface{})
+	assert.Len(t, diagnostics, 2, "只发布当前文件的问题")
+	first := diagnostics[0].(map[string]interface{})
+	assert.Equal(t, float64(severityError), first["severity"])
+	assert.Equal(t, "correctness", first["code"])
+	assert.Equal(t, "未处理错误\n建议：检查 err", first["message"])
+	assert.Equal(t, map[string]interface{}{
+		"start": map[string]interface{}{"line": float64(2), "character": float64(0)},
+		"end":   map[string]interface{}{"line": float64(4), "character": float64(0)},
+	}, first["range"])
+	assert.Equal(t, float64(severityInformation), diagnostics[1].(map[string]interface{})["severity"])
+	assert.Equal(t, int32(1), atomic.LoadInt32(&reviews))
+
+	// 快速修复替换 Line 到 EndLine 的整行
+	actions := c.call("textDocument/codeAction", map[string]interface{}{
+		"textDocument": map[string]string{"uri": uri},
+		"range":        Range{Start: Position{Line: 3}, End: Position{Line: 3}},
+	})["result"].([]interface{})
+	assert.Len(t, actions, 1)
+	action := actions[0].(map[string]interface{})
+	assert.Equal(t, "quickfix", action["kind"])
+	edits := action["edit"].(map[string]interface{})["changes"].(map[string]interface{})[uri].([]interface{})
+	assert.Equal(t, "\tif err != nil {\n\t\treturn err\n\t}\n", edits[0].(map[string]interface{})["newText"])
+
+	none := c.call("textDocument/codeAction", map[string]interface{}{
+		"textDocument": map[string]string{"uri": uri},
+		"range":        Range{Start: Position{Line: 9}, End: Position{Line: 9}},
+	})["result"].([]interface{})
+	assert.Empty(t, none, "没有修复代码的问题不提供快速修复")
+
+	// 修改后位置可能变化，不再提供快速修复
+	c.notify("textDocument/didChange", doc)
+	actions = c.call("textDocument/codeAction", map[string]interface{}{
+		"textDocument": map[string]string{"uri": uri},
+		"range":        Range{Start: Position{Line: 3}, End: Position{Line: 3}},
+	})["result"].([]interface{})
+	assert.Empty(t, actions)
+
+	// diff 未变化时不重复评审，但重新发布诊断
+	c.notify("textDocument/didSave", doc)
+	msg = c.next()
+	assert.Len(t, msg["params"].(map[string]interface{})["diagnostics"], 2)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&reviews))
+
+	c.notify("textDocument/didClose", doc)
+	msg = c.next()
+	assert.Empty(t, msg["params"].(map[string]interface{})["diagnostics"])
+
+	errResp := c.call("textDocument/hover", doc)
+	assert.Equal(t, float64(codeMethodNotFound), errResp["error"].(map[string]interface{})["code"])
+
+	resp := c.call("shutdown", nil)
+	assert.Contains(t, resp, "result")
+	assert.Nil(t, resp["result"])
+	errResp = c.call("textDocument/codeAction", doc)
+	assert.Equal(t, float64(codeInvalidRequest), errResp["error"].(map[string]interface{})["code"])
+
+	c.notify("exit", nil)
+	assert.NoError(t, <-done)
+}
+
+func TestGitDiff(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("没有安装 git")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		assert.NoError(t, err, string(out))
+	}
+	run("init", "-q")
+	run("config", "user.email", "test@example.com")
+	run("config", "user.name", "test")
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "pkg"), 0755))
+	tracked := filepath.Join(dir, "pkg", "a.go")
+	assert.NoError(t, os.WriteFile(tracked, []byte("package pkg\n"), 0644))
+	run("add", ".")
+	run("commit", "-q", "-m", "init")
+
+	diffContent, name, err := GitDiff(tracked)
+	assert.NoError(t, err)
+	assert.Equal(t, "pkg/a.go", name)
+	assert.Empty(t, diffContent)
+
+	assert.NoError(t, os.WriteFile(tracked, []byte("package pkg\n\nvar x = 1\n"), 0644))
+	diffContent, _, err = GitDiff(tracked)
+	assert.NoError(t, err)
+	assert.Contains(t, diffContent, "+var x = 1")
+
+	untracked := filepath.Join(dir, "b.go")
+	assert.NoError(t, os.WriteFile(untracked, []byte("package main\n"), 0644))
+	diffContent, name, err = GitDiff(untracked)
+	assert.NoError(t, err)
+	assert.Equal(t, "b.go", name)
+	assert.True(t, strings.Contains(diffContent, "+package main"))
+}
@@ -0,0 +1,210 @@
+// Package mcp 实现 Model Context Protocol 服务端（stdio 传输），
+// 将评审能力以工具的形式提供给支持 MCP 的 AI 编码助手
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// 支持的协议版本，第一个为默认版本
+var protocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// JSON-RPC 错误码
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// Tool MCP 工具
+type Tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+	// Handler 执行工具，返回 error 时作为工具执行失败返回给客户端
+	Handler func(ctx context.Context, args json.RawMessage) (*ToolResult, error) `json:"-"`
+}
+
+// Content 工具返回的内容
+type Content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// ToolResult 工具执行结果
+type ToolResult struct {
+	Content []Content `json:"content"`
+	// StructuredContent 结构化结果，便于客户端直接解析
+	StructuredContent interface{} `json:"structuredContent,omitempty"`
+	IsError           bool        `json:"isError,omitempty"`
+}
+
+// TextResult 创建文本结果，structured 不为空时同时返回结构化结果
+func TextResult(text string, structured interface{}) *ToolResult {
+	return &ToolResult{Content: []Content{{Type: "text", Text: text}}, StructuredContent: structured}
+}
+
+// Server MCP 服务端
+type Server struct {
+	name    string
+	version string
+	tools   map[string]Tool
+}
+
+// NewServer 创建服务端
+func NewServer(name, version string) *Server {
+	return &Server{name: name, version: version, tools: make(map[string]Tool)}
+}
+
+// AddTool 注册工具
+func (s *Server) AddTool(t Tool) {
+	s.tools[t.Name] = t
+}
+
+// request JSON-RPC 请求，ID 为空时是通知
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// response JSON-RPC 响应
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcError JSON-RPC 错误
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Serve 从 r 逐行读取 JSON-RPC 消息并将响应写入 w，直到输入结束或 ctx 结束
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	reader := bufio.NewReader(r)
+	enc := json.NewEncoder(w)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			if resp := s.handle(ctx, line); resp != nil {
+				if err := enc.Encode(resp); err != nil {
+					return fmt.Errorf("写入响应失败: %w", err)
+				}
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("读取请求失败: %w", err)
+		}
+	}
+}
+
+// handle 处理一条消息，通知不需要响应时返回 nil
+func (s *Server) handle(ctx context.Context, line []byte) *response {
+	var req request
+	if err := json.Unmarshal(line, &req); err != nil {
+		return &response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{codeParseError, "无法解析的消息: " + err.Error()}}
+	}
+	if req.ID == nil {
+		// 通知（如 notifications/initialized）无需响应
+		return nil
+	}
+	resp := &response{JSONRPC: "2.0", ID: req.ID}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		resp.Error = &rpcError{codeInvalidRequest, "无效的 JSON-RPC 请求"}
+		return resp
+	}
+
+	result, rpcErr := s.dispatch(ctx, req)
+	if rpcErr != nil {
+		resp.Error = rpcErr
+	} else {
+		resp.Result = result
+	}
+	return resp
+}
+
+// dispatch 按方法处理请求
+func (s *Server) dispatch(ctx context.Context, req request) (interface{}, *rpcError) {
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		json.Unmarshal(req.Params, &params)
+		version := protocolVersions[0]
+		for _, v := range protocolVersions {
+			if v == params.ProtocolVersion {
+				version = v
+			}
+		}
+		return map[string]interface{}{
+			"protocolVersion": version,
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+			"serverInfo":      map[string]string{"name": s.name, "version": s.version},
+		}, nil
+	case "ping":
+		return map[string]interface{}{}, nil
+	case "tools/list":
+		names := make([]string, 0, len(s.tools))
+		for name := range s.tools {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		tools := make([]Tool, 0, len(names))
+		for _, name := range names {
+			tools = append(tools, s.tools[name])
+		}
+		return map[string]interface{}{"tools": tools}, nil
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{codeInvalidParams, "无效的参数: " + err.Error()}
+		}
+		tool, ok := s.tools[params.Name]
+		if !ok {
+			return nil, &rpcError{codeInvalidParams, "未知的工具: " + params.Name}
+		}
+		if len(params.Arguments) == 0 {
+			params.Arguments = json.RawMessage("{}")
+		}
+		return s.call(ctx, tool, params.Arguments), nil
+	default:
+		return nil, &rpcError{codeMethodNotFound, "不支持的方法: " + req.Method}
+	}
+}
+
+// call 执行工具，错误和 panic 作为工具执行失败返回，由 AI 助手决定如何处理
+func (s *Server) call(ctx context.Context, tool Tool, args json.RawMessage) (result *ToolResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = &ToolResult{Content: []Content{{Type: "text", Text: fmt.Sprintf("工具执行异常: %v", r)}}, IsError: true}
+		}
+	}()
+
+	result, err := tool.Handler(ctx, args)
+	if err != nil {
+		return &ToolResult{Content: []Content{{Type: "text", Text: err.Error()}}, IsError: true}
+	}
+	return result
+}
@@ -0,0 +1,98 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testServer() *Server {
+	s := NewServer("cr-tool", "1.0")
+	s.AddTool(Tool{
+		Name:        "echo",
+		Description: "原样返回",
+		InputSchema: map[string]interface{}{"type": "object"},
+		Handler: func(ctx context.Context, args json.RawMessage) (*ToolResult, error) {
+			var params struct {
+				Text string `json:"text"`
+			}
+			json.Unmarshal(args, &params)
+			if params.Text == "" {
+				return nil, errors.New("text 不能为空")
+			}
+			return TextResult(params.Text, map[string]string{"text": params.Text}), nil
+		},
+	})
+	s.AddTool(Tool{
+		Name: "panic",
+		Handler: func(ctx context.Context, args json.RawMessage) (*ToolResult, error) {
+			panic("崩溃")
+		},
+	})
+	return s
+}
+
+// exchange 发送消息并按行解析响应
+func exchange(t *testing.T, s *Server, messages ...string) []map[string]interface{} {
+	var out bytes.Buffer
+	assert.NoError(t, s.Serve(context.Background(), strings.NewReader(strings.Join(messages, "\n")), &out))
+
+	var responses []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var resp map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(line), &resp))
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+func TestServe(t *testing.T) {
+	responses := exchange(t, testServer(),
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"test"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":"a","method":"tools/call","params":{"name":"echo","arguments":{"text":"你好"}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"echo","arguments":{}}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"panic"}}`,
+		`{"jsonrpc":"2.0","id":6,"method":"tools/call","params":{"name":"missing"}}`,
+		`{"jsonrpc":"2.0","id":7,"method":"resources/list"}`,
+		`not json`,
+	)
+	assert.Len(t, responses, 8, "通知不需要响应")
+
+	initResult := responses[0]["result"].(map[string]interface{})
+	assert.Equal(t, "2024-11-05", initResult["protocolVersion"])
+	assert.Equal(t, "cr-tool", initResult["serverInfo"].(map[string]interface{})["name"])
+
+	tools := responses[1]["result"].(map[string]interface{})["tools"].([]interface{})
+	assert.Len(t, tools, 2)
+	assert.Equal(t, "echo", tools[0].(map[string]interface{})["name"])
+
+	assert.Equal(t, "a", responses[2]["id"])
+	echo := responses[2]["result"].(map[string]interface{})
+	assert.Equal(t, "你好", echo["content"].([]interface{})[0].(map[string]interface{})["text"])
+	assert.Equal(t, map[string]interface{}{"text": "你好"}, echo["structuredContent"])
+	assert.Nil(t, echo["isError"])
+
+	failed := responses[3]["result"].(map[string]interface{})
+	assert.Equal(t, true, failed["isError"])
+	assert.Contains(t, failed["content"].([]interface{})[0].(map[string]interface{})["text"], "text 不能为空")
+	assert.Equal(t, true, responses[4]["result"].(map[string]interface{})["isError"])
+
+	assert.Equal(t, float64(codeInvalidParams), responses[5]["error"].(map[string]interface{})["code"])
+	assert.Equal(t, float64(codeMethodNotFound), responses[6]["error"].(map[string]interface{})["code"])
+	assert.Equal(t, float64(codeParseError), responses[7]["error"].(map[string]interface{})["code"])
+}
+
+func TestInitializeUnknownVersion(t *testing.T) {
+	responses := exchange(t, testServer(), `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"1999-01-01"}}`)
+	assert.Equal(t, protocolVersions[0], responses[0]["result"].(map[string]interface{})["protocolVersion"])
+}
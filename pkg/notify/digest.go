@@ -0,0 +1,205 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/icatw/cr-tool/pkg/review"
+	"github.com/icatw/cr-tool/pkg/text"
+)
+
+// DigestEntry 摘要中的一次评审
+type DigestEntry struct {
+	ID        string         `json:"id"`
+	Branch    string         `json:"branch,omitempty"`
+	Author    string         `json:"author,omitempty"`
+	Commit    string         `json:"commit,omitempty"`
+	Counts    map[string]int `json:"counts,omitempty"`
+	ReportURL string         `json:"report_url,omitempty"`
+	Channels  []string       `json:"channels"`
+	DateTime  time.Time      `json:"datetime"`
+}
+
+// NewDigestEntry 根据通知内容创建摘要条目
+func NewDigestEntry(n *Notification, channels []string) DigestEntry {
+	sort.Strings(channels)
+	entry := DigestEntry{
+		ID:        n.History.ID,
+		Counts:    make(map[string]int),
+		ReportURL: n.ReportURL,
+		Channels:  channels,
+		DateTime:  n.History.DateTime,
+	}
+	if g := n.History.GitInfo; g != nil {
+		entry.Branch = g.Branch
+		entry.Author = g.Author
+		entry.Commit = g.CommitMessage
+	}
+	for _, c := range n.SeverityCounts() {
+		entry.Counts[string(c.Severity)] = c.Count
+	}
+	return entry
+}
+
+// DigestQueue 待发送的摘要，保存在本地文件中
+type DigestQueue struct {
+	path string
+}
+
+// NewDigestQueue 创建摘要队列
+func NewDigestQueue(path string) *DigestQueue {
+	return &DigestQueue{path: path}
+}
+
+// Add 追加摘要条目
+func (q *DigestQueue) Add(entry DigestEntry) error {
+	if q.path == "" {
+		return fmt.Errorf("未配置摘要文件 (notify.digest_file)")
+	}
+	entries, err := q.load()
+	if err != nil {
+		return err
+	}
+	return q.save(append(entries, entry))
+}
+
+// Flush 按渠道汇总发送摘要，发送成功的条目从队列中移除，返回发送的消息数
+func (q *DigestQueue) Flush(ctx context.Context, channels map[string]Notifier) (int, error) {
+	entries, err := q.load()
+	if err != nil || len(entries) == 0 {
+		return 0, err
+	}
+
+	byChannel := make(map[string][]DigestEntry)
+	for _, e := range entries {
+		for _, name := range e.Channels {
+			byChannel[name] = append(byChannel[name], e)
+		}
+	}
+
+	var (
+		errs   []error
+		sent   int
+		failed = make(map[string]bool)
+	)
+	for name, items := range byChannel {
+		notifier, ok := channels[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("摘要引用了未定义的渠道: %s", name))
+			failed[name] = true
+			continue
+		}
+		if err := notifier.Notify(ctx, &Notification{Digest: items}); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			failed[name] = true
+			continue
+		}
+		sent++
+	}
+
+	// 只保留发送失败的渠道，下次重试
+	var remaining []DigestEntry
+	for _, e := range entries {
+		var left []string
+		for _, name := range e.Channels {
+			if failed[name] {
+				left = append(left, name)
+			}
+		}
+		if len(left) > 0 {
+			e.Channels = left
+			remaining = append(remaining, e)
+		}
+	}
+	if err := q.save(remaining); err != nil {
+		errs = append(errs, err)
+	}
+	return sent, errors.Join(errs...)
+}
+
+// load 读取队列
+func (q *DigestQueue) load() ([]DigestEntry, error) {
+	data, err := os.ReadFile(q.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("读取摘要文件失败: %w", err)
+	}
+	var entries []DigestEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("解析摘要文件失败: %w", err)
+	}
+	return entries, nil
+}
+
+// save 保存队列
+func (q *DigestQueue) save(entries []DigestEntry) error {
+	if len(entries) == 0 {
+		if err := os.Remove(q.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("清理摘要文件失败: %w", err)
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(q.path), 0755); err != nil {
+		return fmt.Errorf("创建摘要目录失败: %w", err)
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化摘要失败: %w", err)
+	}
+	if err := os.WriteFile(q.path, data, 0644); err != nil {
+		return fmt.Errorf("保存摘要文件失败: %w", err)
+	}
+	return nil
+}
+
+// digestSummary 生成摘要正文
+func (n *Notification) digestSummary(limit int) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("### %s\n\n", n.Title()))
+	total := make(map[string]int)
+	for _, c := range n.SeverityCounts() {
+		total[string(c.Severity)] = c.Count
+	}
+	b.WriteString(fmt.Sprintf("**合计**：%s\n\n", formatCounts(total)))
+
+	for i, e := range n.Digest {
+		issues := formatCounts(e.Counts)
+
+		line := fmt.Sprintf("- %s `%s` %s %s：%s", e.DateTime.Format("01-02 15:04"), e.Branch, e.Author, e.Commit, issues)
+		if e.ReportURL != "" {
+			line += fmt.Sprintf(" [报告](%s)", e.ReportURL)
+		}
+		line += "\n"
+
+		more := fmt.Sprintf("- ……另有 %d 次评审\n", len(n.Digest)-i)
+		if b.Len()+len(line)+len(more) > limit && i < len(n.Digest)-1 {
+			b.WriteString(more)
+			break
+		}
+		b.WriteString(line)
+	}
+	return text.Truncate(b.String(), limit)
+}
+
+// formatCounts 按级别格式化问题数
+func formatCounts(counts map[string]int) string {
+	var parts []string
+	for _, level := range []review.Severity{review.SeverityHigh, review.SeverityMedium, review.SeverityLow} {
+		if counts[string(level)] > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", level, counts[string(level)]))
+		}
+	}
+	if len(parts) == 0 {
+		return "无问题"
+	}
+	return strings.Join(parts, " · ")
+}
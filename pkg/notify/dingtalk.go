@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/icatw/cr-tool/pkg/config"
+)
+
+// dingTalkMaxBytes 钉钉 markdown 消息正文的长度上限
+const dingTalkMaxBytes = 20000
+
+// DingTalk 钉钉机器人通知
+type DingTalk struct {
+	config config.DingConfig
+	client *http.Client
+}
+
+// NewDingTalk 创建钉钉机器人通知
+func NewDingTalk(cfg config.DingConfig) *DingTalk {
+	return &DingTalk{
+		config: cfg,
+		client: newHTTPClient(),
+	}
+}
+
+// Name 返回通知渠道名称
+func (d *DingTalk) Name() string {
+	return "dingtalk"
+}
+
+// Notify 发送钉钉消息
+func (d *DingTalk) Notify(ctx context.Context, n *Notification) error {
+	if d.config.Webhook == "" {
+		return fmt.Errorf("钉钉 webhook 未配置")
+	}
+
+	webhookURL, err := d.signedURL(time.Now().UnixMilli())
+	if err != nil {
+		return err
+	}
+
+	body, err := postJSON(ctx, d.client, webhookURL, d.message(n))
+	if err != nil {
+		return err
+	}
+	return checkErrCode(body)
+}
+
+// message 构造消息体，actionCard 需要报告链接，没有链接时使用 markdown
+func (d *DingTalk) message(n *Notification) map[string]interface{} {
+	text := n.Summary(dingTalkMaxBytes)
+	if d.config.MsgType == "actionCard" && n.ReportURL != "" {
+		return map[string]interface{}{
+			"msgtype": "actionCard",
+			"actionCard": map[string]string{
+				"title":          n.Title(),
+				"text":           text,
+				"btnOrientation": "0",
+				"singleTitle":    "查看完整报告",
+				"singleURL":      n.ReportURL,
+			},
+		}
+	}
+	return map[string]interface{}{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"title": n.Title(),
+			"text":  text,
+		},
+	}
+}
+
+// signedURL 在 webhook 地址上附加加签参数，未配置 secret 时原样返回
+func (d *DingTalk) signedURL(timestamp int64) (string, error) {
+	if d.config.Secret == "" {
+		return d.config.Webhook, nil
+	}
+
+	u, err := url.Parse(d.config.Webhook)
+	if err != nil {
+		return "", fmt.Errorf("无效的钉钉 webhook: %w", err)
+	}
+	query := u.Query()
+	query.Set("timestamp", strconv.FormatInt(timestamp, 10))
+	query.Set("sign", dingTalkSign(d.config.Secret, timestamp))
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// dingTalkSign 生成钉钉加签：Base64(HmacSHA256(timestamp + "\n" + secret))
+func dingTalkSign(secret string, timestamp int64) string {
+	stringToSign := fmt.Sprintf("%d\n%s", timestamp, secret)
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,341 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/icatw/cr-tool/pkg/codeowners"
+	"github.com/icatw/cr-tool/pkg/config"
+	"github.com/icatw/cr-tool/pkg/exporter"
+	"github.com/icatw/cr-tool/pkg/review"
+)
+
+// attachmentExts 附件格式对应的报告文件扩展名
+var attachmentExts = map[string]string{
+	string(exporter.FormatPDF):      ".pdf",
+	string(exporter.FormatMarkdown): ".md",
+	string(exporter.FormatHTML):     ".html",
+}
+
+// Email 邮件通知，正文为内联样式的 HTML 报告
+type Email struct {
+	config config.EmailConfig
+	// root 查找 CODEOWNERS 的仓库根目录
+	root string
+}
+
+// NewEmail 创建邮件通知
+func NewEmail(cfg config.EmailConfig) *Email {
+	return &Email{
+		config: cfg,
+		root:   review.RepoRoot(),
+	}
+}
+
+// Name 返回通知渠道名称
+func (e *Email) Name() string {
+	return "email"
+}
+
+// Notify 发送邮件
+func (e *Email) Notify(ctx context.Context, n *Notification) error {
+	if e.config.Host == "" {
+		return fmt.Errorf("SMTP 服务器未配置")
+	}
+	from := e.config.From
+	if from == "" {
+		from = e.config.Username
+	}
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return fmt.Errorf("无效的发件人 %q: %w", from, err)
+	}
+
+	to, err := e.recipients(n)
+	if err != nil {
+		return err
+	}
+	if len(to) == 0 {
+		return fmt.Errorf("没有收件人")
+	}
+	cc := addressList(e.config.Cc)
+
+	msg, err := e.message(n, sender, to, cc)
+	if err != nil {
+		return err
+	}
+
+	rcpts := make([]string, 0, len(to)+len(cc))
+	for _, a := range append(to, cc...) {
+		rcpts = append(rcpts, a.Address)
+	}
+	return e.send(ctx, sender.Address, rcpts, msg)
+}
+
+// recipients 收件人：配置的 to，加上按 recipients 来源解析出的地址
+func (e *Email) recipients(n *Notification) ([]*mail.Address, error) {
+	list := append([]string{}, e.config.To...)
+	h := n.History
+
+	for _, source := range e.config.Recipients {
+		switch source {
+		case "authors":
+			if h != nil && h.GitInfo != nil && h.GitInfo.AuthorEmail != "" {
+				list = append(list, h.GitInfo.AuthorEmail)
+			}
+		case "codeowners":
+			if h == nil || h.GitInfo == nil {
+				continue
+			}
+			owners, err := codeowners.Load(e.root)
+			if err != nil {
+				return nil, err
+			}
+			// @user 和 @org/team 无法得到邮箱，只使用邮箱形式的负责人
+			for _, owner := range owners.OwnersOf(h.GitInfo.ChangedFiles) {
+				if codeowners.IsEmail(owner) {
+					list = append(list, owner)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("不支持的收件人来源: %s", source)
+		}
+	}
+	return addressList(list), nil
+}
+
+// addressList 解析并去重邮箱地址，无效地址会被跳过
+func addressList(list []string) []*mail.Address {
+	seen := make(map[string]bool)
+	var addrs []*mail.Address
+	for _, item := range list {
+		addr, err := mail.ParseAddress(item)
+		if err != nil {
+			continue
+		}
+		key := strings.ToLower(addr.Address)
+		if !seen[key] {
+			seen[key] = true
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
+// subject 邮件主题，附带问题统计
+func (e *Email) subject(n *Notification) string {
+	counts := make(map[string]int)
+	for _, c := range n.SeverityCounts() {
+		counts[string(c.Severity)] = c.Count
+	}
+	return fmt.Sprintf("%s - %s", n.Title(), formatCounts(counts))
+}
+
+// message 生成 MIME 邮件：纯文本与 HTML 正文，以及报告附件
+func (e *Email) message(n *Notification, from *mail.Address, to, cc []*mail.Address) ([]byte, error) {
+	var buf bytes.Buffer
+	mixed := multipart.NewWriter(&buf)
+
+	header := []string{
+		"From: " + from.String(),
+		"To: " + joinAddresses(to),
+	}
+	if len(cc) > 0 {
+		header = append(header, "Cc: "+joinAddresses(cc))
+	}
+	header = append(header,
+		"Subject: "+mime.BEncoding.Encode("utf-8", e.subject(n)),
+		"Date: "+time.Now().Format(time.RFC1123Z),
+		fmt.Sprintf("Message-ID: <%d.cr-tool@%s>", time.Now().UnixNano(), hostOf(from.Address)),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/mixed; boundary="+mixed.Boundary(),
+		"", "",
+	)
+	var msg bytes.Buffer
+	msg.WriteString(strings.Join(header, "\r\n"))
+
+	// 正文
+	var altBuf bytes.Buffer
+	alt := multipart.NewWriter(&altBuf)
+	if err := writeBase64Part(alt, "text/plain; charset=utf-8", nil, []byte(n.Summary(1<<20))); err != nil {
+		return nil, err
+	}
+	if n.History != nil {
+		html := exporter.NewHTMLExporter().RenderEmail(n.History)
+		if err := writeBase64Part(alt, "text/html; charset=utf-8", nil, []byte(html)); err != nil {
+			return nil, err
+		}
+	}
+	if err := alt.Close(); err != nil {
+		return nil, err
+	}
+	part, err := mixed.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"multipart/alternative; boundary=" + alt.Boundary()},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(altBuf.Bytes()); err != nil {
+		return nil, err
+	}
+
+	// 附件
+	if n.History != nil {
+		for _, format := range e.config.Attachments {
+			path, err := attachment(n, format)
+			if err != nil {
+				return nil, err
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("读取附件失败: %w", err)
+			}
+			contentType := mime.TypeByExtension(filepath.Ext(path))
+			if contentType == "" {
+				contentType = "application/octet-stream"
+			}
+			disposition := mime.FormatMediaType("attachment", map[string]string{"filename": filepath.Base(path)})
+			if err := writeBase64Part(mixed, contentType, textproto.MIMEHeader{"Content-Disposition": {disposition}}, data); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+	msg.Write(buf.Bytes())
+	return msg.Bytes(), nil
+}
+
+// attachment 优先使用本次已导出的报告，没有时按格式重新导出
+func attachment(n *Notification, format string) (string, error) {
+	ext, ok := attachmentExts[format]
+	if !ok {
+		return "", fmt.Errorf("不支持的附件格式: %s", format)
+	}
+	for _, path := range n.Reports {
+		if filepath.Ext(path) == ext {
+			return path, nil
+		}
+	}
+	exp, err := exporter.New(format)
+	if err != nil {
+		return "", err
+	}
+	path, err := exp.Export(n.History)
+	if err != nil {
+		return "", fmt.Errorf("生成附件失败: %w", err)
+	}
+	return path, nil
+}
+
+// writeBase64Part 写入 base64 编码的 MIME 段，每行 76 个字符
+func writeBase64Part(w *multipart.Writer, contentType string, header textproto.MIMEHeader, data []byte) error {
+	if header == nil {
+		header = textproto.MIMEHeader{}
+	}
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Transfer-Encoding", "base64")
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		if _, err := part.Write([]byte(encoded[:76] + "\r\n")); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err = part.Write([]byte(encoded + "\r\n"))
+	return err
+}
+
+// send 通过 SMTP 发送邮件，服务器支持时使用 STARTTLS
+func (e *Email) send(ctx context.Context, from string, rcpts []string, msg []byte) error {
+	addr := net.JoinHostPort(e.config.Host, strconv.Itoa(e.config.Port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("连接 SMTP 服务器失败: %w", err)
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultTimeout)
+	}
+	conn.SetDeadline(deadline)
+
+	c, err := smtp.NewClient(conn, e.config.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("连接 SMTP 服务器失败: %w", err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: e.config.Host}); err != nil {
+			return fmt.Errorf("STARTTLS 失败: %w", err)
+		}
+	} else if e.config.StartTLS {
+		return fmt.Errorf("SMTP 服务器不支持 STARTTLS")
+	}
+
+	if e.config.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", e.config.Username, e.config.Password, e.config.Host)); err != nil {
+			return fmt.Errorf("SMTP 认证失败: %w", err)
+		}
+	}
+
+	if err := c.Mail(from); err != nil {
+		return fmt.Errorf("设置发件人失败: %w", err)
+	}
+	for _, rcpt := range rcpts {
+		if err := c.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("设置收件人 %s 失败: %w", rcpt, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	return c.Quit()
+}
+
+// joinAddresses 格式化地址列表
+func joinAddresses(addrs []*mail.Address) string {
+	parts := make([]string, len(addrs))
+	for i, a := range addrs {
+		parts[i] = a.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+// hostOf 返回邮箱地址的域名部分
+func hostOf(addr string) string {
+	if idx := strings.LastIndex(addr, "@"); idx >= 0 {
+		return addr[idx+1:]
+	}
+	return "localhost"
+}
@@ -0,0 +1,127 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/icatw/cr-tool/pkg/config"
+	"github.com/icatw/cr-tool/pkg/review"
+)
+
+// feishuMaxBytes 飞书卡片中 markdown 内容的长度上限，卡片整体不能超过 30KB
+const feishuMaxBytes = 28000
+
+// Feishu 飞书/Lark 机器人通知，发送交互式卡片
+type Feishu struct {
+	config config.FeishuConfig
+	client *http.Client
+}
+
+// NewFeishu 创建飞书机器人通知
+func NewFeishu(cfg config.FeishuConfig) *Feishu {
+	return &Feishu{
+		config: cfg,
+		client: newHTTPClient(),
+	}
+}
+
+// Name 返回通知渠道名称
+func (f *Feishu) Name() string {
+	return "feishu"
+}
+
+// Notify 发送飞书卡片消息
+func (f *Feishu) Notify(ctx context.Context, n *Notification) error {
+	if f.config.Webhook == "" {
+		return fmt.Errorf("飞书 webhook 未配置")
+	}
+
+	payload := f.message(n)
+	if f.config.Secret != "" {
+		timestamp := time.Now().Unix()
+		payload["timestamp"] = strconv.FormatInt(timestamp, 10)
+		payload["sign"] = feishuSign(f.config.Secret, timestamp)
+	}
+
+	body, err := postJSON(ctx, f.client, f.config.Webhook, payload)
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("解析飞书响应失败: %w", err)
+	}
+	if result.Code != 0 {
+		return fmt.Errorf("发送飞书消息失败: %d %s", result.Code, result.Msg)
+	}
+	return nil
+}
+
+// message 构造交互式卡片消息
+func (f *Feishu) message(n *Notification) map[string]interface{} {
+	elements := []interface{}{
+		map[string]string{
+			"tag":     "markdown",
+			"content": n.Summary(feishuMaxBytes),
+		},
+	}
+	if n.ReportURL != "" {
+		elements = append(elements, map[string]interface{}{
+			"tag": "action",
+			"actions": []interface{}{
+				map[string]interface{}{
+					"tag":  "button",
+					"type": "primary",
+					"url":  n.ReportURL,
+					"text": map[string]string{"tag": "plain_text", "content": "查看完整报告"},
+				},
+			},
+		})
+	}
+
+	return map[string]interface{}{
+		"msg_type": "interactive",
+		"card": map[string]interface{}{
+			"config": map[string]bool{"wide_screen_mode": true},
+			"header": map[string]interface{}{
+				"title":    map[string]string{"tag": "plain_text", "content": n.Title()},
+				"template": feishuColor(n),
+			},
+			"elements": elements,
+		},
+	}
+}
+
+// feishuColor 根据最严重的问题级别选择卡片标题颜色
+func feishuColor(n *Notification) string {
+	counts := n.SeverityCounts()
+	if len(counts) == 0 {
+		return "green"
+	}
+	switch counts[0].Severity {
+	case review.SeverityHigh:
+		return "red"
+	case review.SeverityMedium:
+		return "orange"
+	default:
+		return "blue"
+	}
+}
+
+// feishuSign 生成飞书加签：以 timestamp + "\n" + secret 为密钥对空字符串做 HmacSHA256 后 Base64
+func feishuSign(secret string, timestamp int64) string {
+	key := fmt.Sprintf("%d\n%s", timestamp, secret)
+	h := hmac.New(sha256.New, []byte(key))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
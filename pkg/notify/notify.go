@@ -0,0 +1,110 @@
+// Package notify 将评审结果推送到即时通讯工具
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/icatw/cr-tool/pkg/config"
+	"github.com/icatw/cr-tool/pkg/review"
+)
+
+// Notification 通知内容
+type Notification struct {
+	History *review.ReviewHistory
+	// ReportURL 评审报告链接，可为空
+	ReportURL string
+	// Reports 本次导出的报告文件，邮件通知会从中选取附件
+	Reports []string
+	// Digest 不为空时表示汇总多次评审的摘要消息，此时 History 为空
+	Digest []DigestEntry
+}
+
+// Notifier 通知发送器接口
+type Notifier interface {
+	// Name 返回通知渠道名称
+	Name() string
+	// Notify 发送通知
+	Notify(ctx context.Context, n *Notification) error
+}
+
+// FromConfig 根据配置创建已启用的通知发送器
+func FromConfig(cfg *config.Config) []Notifier {
+	var notifiers []Notifier
+	if cfg.Ding.Enabled {
+		notifiers = append(notifiers, NewDingTalk(cfg.Ding))
+	}
+	if cfg.Feishu.Enabled {
+		notifiers = append(notifiers, NewFeishu(cfg.Feishu))
+	}
+	if cfg.WeCom.Enabled {
+		notifiers = append(notifiers, NewWeCom(cfg.WeCom))
+	}
+	if cfg.Slack.Enabled {
+		notifiers = append(notifiers, NewSlack(cfg.Slack))
+	}
+	if cfg.Email.Enabled {
+		notifiers = append(notifiers, NewEmail(cfg.Email))
+	}
+	return notifiers
+}
+
+// NotifyAll 依次调用所有通知发送器，单个渠道失败不影响其他渠道
+func NotifyAll(ctx context.Context, notifiers []Notifier, n *Notification) error {
+	var errs []error
+	for _, notifier := range notifiers {
+		if err := notifier.Notify(ctx, n); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", notifier.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Title 返回通知标题
+func (n *Notification) Title() string {
+	if len(n.Digest) > 0 {
+		return fmt.Sprintf("代码评审摘要（%d 次评审）", len(n.Digest))
+	}
+	if n.History.GitInfo != nil && n.History.GitInfo.Branch != "" {
+		return fmt.Sprintf("代码评审报告 [%s]", n.History.GitInfo.Branch)
+	}
+	return "代码评审报告"
+}
+
+// SeverityCounts 返回按级别统计的问题数量，严重程度从高到低排列
+func (n *Notification) SeverityCounts() []SeverityCount {
+	counts := make(map[string]int)
+	if len(n.Digest) > 0 {
+		for _, e := range n.Digest {
+			for level, count := range e.Counts {
+				counts[level] += count
+			}
+		}
+	} else if n.History.ReviewStats != nil {
+		for level, count := range n.History.ReviewStats.IssuesByLevel {
+			counts[level] = count
+		}
+	}
+	if len(counts) == 0 && n.History != nil {
+		for _, f := range n.History.Findings {
+			counts[string(f.Severity)]++
+		}
+	}
+
+	result := make([]SeverityCount, 0, len(counts))
+	for level, count := range counts {
+		result = append(result, SeverityCount{Severity: review.Severity(level), Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Severity.Rank() > result[j].Severity.Rank()
+	})
+	return result
+}
+
+// SeverityCount 某一级别的问题数量
+type SeverityCount struct {
+	Severity review.Severity
+	Count    int
+}
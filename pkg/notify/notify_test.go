@@ -0,0 +1,424 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/icatw/cr-tool/pkg/config"
+	"github.com/icatw/cr-tool/pkg/review"
+	"github.com/stretchr/testify/assert"
+)
+
+func testNotification() *Notification {
+	return &Notification{
+		History: &review.ReviewHistory{
+			ID:      "1a2b3c4d",
+			GitInfo: &review.GitInfo{Branch: "main", Author: "Alice", CommitHash: "0123456789abcdef", CommitMessage: "feat: login"},
+			ReviewStats: &review.ReviewStats{
+				FilesChanged:  2,
+				LinesAdded:    10,
+				LinesDeleted:  3,
+				IssuesByLevel: map[string]int{"严重": 1, "低": 2},
+			},
+			Findings: []review.Finding{
+				{File: "auth/login.go", Line: 12, Severity: review.SeverityHigh, Title: "密码明文存储"},
+			},
+		},
+		ReportURL: "https://reports.example.com/20240101_review.html",
+	}
+}
+
+// webhookRecorder 记录 webhook 请求
+type webhookRecorder struct {
+	requests []*http.Request
+	bodies   []map[string]interface{}
+	response string
+}
+
+func (rec *webhookRecorder) server(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			t.Errorf("解析请求失败: %v", err)
+		}
+		rec.requests = append(rec.requests, req)
+		rec.bodies = append(rec.bodies, body)
+		w.Write([]byte(rec.response))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDingTalk(t *testing.T) {
+	rec := &webhookRecorder{response: `{"errcode":0,"errmsg":"ok"}`}
+	server := rec.server(t)
+
+	d := NewDingTalk(config.DingConfig{
+		Enabled: true,
+		Webhook: server.URL + "/robot/send?access_token=token",
+		Secret:  "SEC123",
+		MsgType: "actionCard",
+	})
+	assert.NoError(t, d.Notify(context.Background(), testNotification()))
+
+	query := rec.requests[0].URL.Query()
+	assert.Equal(t, "token", query.Get("access_token"))
+	timestamp, err := strconv.ParseInt(query.Get("timestamp"), 10, 64)
+	assert.NoError(t, err)
+	assert.Equal(t, dingTalkSign("SEC123", timestamp), query.Get("sign"))
+
+	body := rec.bodies[0]
+	assert.Equal(t, "actionCard", body["msgtype"])
+	card := body["actionCard"].(map[string]interface{})
+	assert.Equal(t, "代码评审报告 [main]", card["title"])
+	assert.Equal(t, "https://reports.example.com/20240101_review.html", card["singleURL"])
+	assert.Contains(t, card["text"], "严重 1 · 低 2")
+	assert.Contains(t, card["text"], "`auth/login.go:12` 密码明文存储")
+}
+
+func TestDingTalkMarkdownFallback(t *testing.T) {
+	rec := &webhookRecorder{response: `{"errcode":0,"errmsg":"ok"}`}
+	server := rec.server(t)
+
+	n := testNotification()
+	n.ReportURL = ""
+	d := NewDingTalk(config.DingConfig{Webhook: server.URL, MsgType: "actionCard"})
+	assert.NoError(t, d.Notify(context.Background(), n))
+
+	assert.Empty(t, rec.requests[0].URL.Query().Get("sign"))
+	assert.Equal(t, "markdown", rec.bodies[0]["msgtype"])
+}
+
+func TestDingTalkError(t *testing.T) {
+	rec := &webhookRecorder{response: `{"errcode":310000,"errmsg":"sign not match"}`}
+	server := rec.server(t)
+
+	d := NewDingTalk(config.DingConfig{Webhook: server.URL, Secret: "wrong"})
+	err := NotifyAll(context.Background(), []Notifier{d}, testNotification())
+	assert.ErrorContains(t, err, "dingtalk")
+	assert.ErrorContains(t, err, "sign not match")
+}
+
+func TestFeishu(t *testing.T) {
+	rec := &webhookRecorder{response: `{"code":0,"msg":"success"}`}
+	server := rec.server(t)
+
+	f := NewFeishu(config.FeishuConfig{Webhook: server.URL, Secret: "feishu-secret"})
+	assert.NoError(t, f.Notify(context.Background(), testNotification()))
+
+	body := rec.bodies[0]
+	assert.Equal(t, "interactive", body["msg_type"])
+	timestamp, err := strconv.ParseInt(body["timestamp"].(string), 10, 64)
+	assert.NoError(t, err)
+	assert.Equal(t, feishuSign("feishu-secret", timestamp), body["sign"])
+
+	card := body["card"].(map[string]interface{})
+	header := card["header"].(map[string]interface{})
+	assert.Equal(t, "red", header["template"])
+	elements := card["elements"].([]interface{})
+	assert.Len(t, elements, 2)
+	assert.Contains(t, elements[0].(map[string]interface{})["content"], "密码明文存储")
+
+	rec.response = `{"code":19021,"msg":"sign match fail"}`
+	assert.ErrorContains(t, f.Notify(context.Background(), testNotification()), "sign match fail")
+}
+
+func TestWeCom(t *testing.T) {
+	rec := &webhookRecorder{response: `{"errcode":0,"errmsg":"ok"}`}
+	server := rec.server(t)
+
+	n := testNotification()
+	n.History.ReviewResult = strings.Repeat("这是一段很长的评审详情。\n", 1000)
+	w := NewWeCom(config.WeComConfig{Webhook: server.URL + "/cgi-bin/webhook/send?key=abc"})
+	assert.NoError(t, w.Notify(context.Background(), n))
+
+	assert.Equal(t, "abc", rec.requests[0].URL.Query().Get("key"))
+	assert.Equal(t, "markdown", rec.bodies[0]["msgtype"])
+	content := rec.bodies[0]["markdown"].(map[string]interface{})["content"].(string)
+	assert.LessOrEqual(t, len(content), weComMaxBytes)
+	assert.Contains(t, content, "密码明文存储")
+	assert.Contains(t, content, "[查看完整报告](https://reports.example.com/20240101_review.html)")
+	assert.Contains(t, content, "内容过长已截断")
+}
+
+func TestSlack(t *testing.T) {
+	rec := &webhookRecorder{response: "ok"}
+	server := rec.server(t)
+
+	s := NewSlack(config.SlackConfig{Webhook: server.URL})
+	assert.NoError(t, s.Notify(context.Background(), testNotification()))
+
+	blocks := rec.bodies[0]["blocks"].([]interface{})
+	assert.Len(t, blocks, 3)
+	section := blocks[1].(map[string]interface{})["text"].(map[string]interface{})
+	assert.Equal(t, "mrkdwn", section["type"])
+	assert.Contains(t, section["text"], "*[严重]* `auth/login.go:12` 密码明文存储")
+	assert.NotContains(t, section["text"], "查看完整报告")
+	button := blocks[2].(map[string]interface{})["elements"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "https://reports.example.com/20240101_review.html", button["url"])
+
+	rec.response = "invalid_payload"
+	assert.ErrorContains(t, s.Notify(context.Background(), testNotification()), "invalid_payload")
+}
+
+func TestSummaryOmitsFindings(t *testing.T) {
+	n := testNotification()
+	for i := 0; i < 200; i++ {
+		n.History.Findings = append(n.History.Findings, review.Finding{
+			File: "pkg/service/user.go", Line: i + 1, Severity: review.SeverityLow, Title: "变量命名不够清晰",
+		})
+	}
+
+	summary := n.Summary(2000)
+	assert.LessOrEqual(t, len(summary), 2000)
+	assert.Contains(t, summary, "个问题\n")
+	assert.Contains(t, summary, "……另有")
+	assert.True(t, strings.HasSuffix(summary, "(https://reports.example.com/20240101_review.html)\n"))
+}
+
+func TestMatchRule(t *testing.T) {
+	h := testNotification().History
+	h.GitInfo.Branch = "release/1.2"
+	h.GitInfo.ChangedFiles = []string{"auth/login.go", "README.md"}
+	h.Findings[0].Category = "security"
+
+	tests := []struct {
+		name string
+		rule config.NotifyRule
+		want bool
+	}{
+		{"空规则", config.NotifyRule{}, true},
+		{"分支匹配", config.NotifyRule{Branches: []string{"release/*"}}, true},
+		{"分支不匹配", config.NotifyRule{Branches: []string{"main"}}, false},
+		{"作者不区分大小写", config.NotifyRule{Authors: []string{"alice"}}, true},
+		{"级别达到阈值", config.NotifyRule{MinSeverity: "high"}, true},
+		{"类别不匹配", config.NotifyRule{MinSeverity: "中等", Categories: []string{"performance"}}, false},
+		{"问题路径匹配", config.NotifyRule{MinSeverity: "低", Paths: []string{"auth/**"}}, true},
+		{"问题路径不匹配", config.NotifyRule{MinSeverity: "低", Paths: []string{"*.md"}}, false},
+		{"改动路径匹配", config.NotifyRule{Paths: []string{"*.md"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, MatchRule(tt.rule, h))
+		})
+	}
+}
+
+func TestRouter(t *testing.T) {
+	security := &webhookRecorder{response: `{"errcode":0}`}
+	team := &webhookRecorder{response: `{"errcode":0}`}
+	securityServer, teamServer := security.server(t), team.server(t)
+
+	cfg := &config.Config{Notify: config.NotifyConfig{
+		Channels: map[string]config.ChannelConfig{
+			"security": {Type: "wecom", Webhook: securityServer.URL},
+			"team":     {Type: "wecom", Webhook: teamServer.URL},
+		},
+		Rules: []config.NotifyRule{
+			{Name: "安全", Channels: []string{"security"}, MinSeverity: "严重", Branches: []string{"release/*"}},
+			{Name: "日报", Channels: []string{"team"}, Digest: true},
+		},
+		DigestFile: t.TempDir() + "/digest.json",
+	}}
+	router, err := NewRouter(cfg)
+	assert.NoError(t, err)
+
+	release := testNotification()
+	release.History.GitInfo.Branch = "release/1.2"
+	assert.NoError(t, router.Route(context.Background(), release))
+	assert.Len(t, security.bodies, 1)
+	assert.Empty(t, team.bodies)
+
+	// 普通分支进入摘要，不立即发送
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, router.Route(context.Background(), testNotification()))
+	}
+	assert.Len(t, security.bodies, 1)
+	assert.Empty(t, team.bodies)
+
+	sent, err := router.SendDigest(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	content := team.bodies[0]["markdown"].(map[string]interface{})["content"].(string)
+	assert.Contains(t, content, "代码评审摘要（3 次评审）")
+	assert.Contains(t, content, "严重 3")
+
+	// 摘要发送后清空
+	sent, err = router.SendDigest(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, sent)
+
+	cfg.Notify.Rules[0].Channels = []string{"unknown"}
+	_, err = NewRouter(cfg)
+	assert.ErrorContains(t, err, "unknown")
+
+	cfg.Notify.Rules[0].Channels = []string{"security"}
+	cfg.Notify.Rules[0].MinSeverity = "urgent"
+	_, err = NewRouter(cfg)
+	assert.ErrorContains(t, err, "urgent")
+
+	cfg.Notify.Rules[0].MinSeverity = "严重"
+	cfg.Notify.Rules[0].Categories = []string{"安全"}
+	_, err = NewRouter(cfg)
+	assert.ErrorContains(t, err, "安全")
+}
+
+// smtpRecorder 简单的 SMTP 服务端，记录收到的邮件
+type smtpRecorder struct {
+	auth  string
+	from  string
+	rcpts []string
+	data  string
+}
+
+func (rec *smtpRecorder) server(t *testing.T) (string, int) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch cmd {
+			case "EHLO":
+				reply("250-localhost")
+				reply("250 AUTH PLAIN")
+			case "AUTH":
+				rec.auth = line
+				reply("235 OK")
+			case "MAIL":
+				rec.from = line
+				reply("250 OK")
+			case "RCPT":
+				rec.rcpts = append(rec.rcpts, line)
+				reply("250 OK")
+			case "DATA":
+				reply("354 go ahead")
+				var b strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil || l == ".\r\n" {
+						break
+					}
+					b.WriteString(l)
+				}
+				rec.data = b.String()
+				reply("250 OK")
+			case "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("502 unsupported")
+			}
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	return "localhost", addr.Port
+}
+
+func TestEmail(t *testing.T) {
+	rec := &smtpRecorder{}
+	host, port := rec.server(t)
+
+	root := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "CODEOWNERS"), []byte("auth/ sec@example.com @team\n"), 0644))
+	report := filepath.Join(root, "20240101_review.md")
+	assert.NoError(t, os.WriteFile(report, []byte("# 评审报告"), 0644))
+
+	n := testNotification()
+	n.History.GitInfo.AuthorEmail = "alice@example.com"
+	n.History.GitInfo.ChangedFiles = []string{"auth/login.go"}
+	n.Reports = []string{report}
+
+	e := NewEmail(config.EmailConfig{
+		Host:        host,
+		Port:        port,
+		Username:    "bot@example.com",
+		Password:    "secret",
+		From:        "CR Bot <bot@example.com>",
+		To:          []string{"lead@example.com", "ALICE@example.com"},
+		Recipients:  []string{"authors", "codeowners"},
+		Attachments: []string{"markdown"},
+	})
+	e.root = root
+	assert.NoError(t, e.Notify(context.Background(), n))
+
+	assert.Contains(t, rec.auth, "AUTH PLAIN")
+	assert.Equal(t, "MAIL FROM:<bot@example.com>", strings.Split(rec.from, " BODY")[0])
+	assert.Equal(t, []string{"RCPT TO:<lead@example.com>", "RCPT TO:<ALICE@example.com>", "RCPT TO:<sec@example.com>"}, rec.rcpts)
+
+	msg, err := mail.ReadMessage(strings.NewReader(rec.data))
+	assert.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	assert.NoError(t, err)
+	assert.Equal(t, "代码评审报告 [main] - 严重 1 · 低 2", subject)
+
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+
+	body, err := mr.NextPart()
+	assert.NoError(t, err)
+	_, altParams, _ := mime.ParseMediaType(body.Header.Get("Content-Type"))
+	alt := multipart.NewReader(body, altParams["boundary"])
+	var types []string
+	for {
+		p, err := alt.NextPart()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		types = append(types, p.Header.Get("Content-Type"))
+		if strings.HasPrefix(p.Header.Get("Content-Type"), "text/html") {
+			html, _ := io.ReadAll(base64.NewDecoder(base64.StdEncoding, p))
+			assert.Contains(t, string(html), "密码明文存储")
+			assert.NotContains(t, string(html), "<style>")
+		}
+	}
+	assert.Equal(t, []string{"text/plain; charset=utf-8", "text/html; charset=utf-8"}, types)
+
+	attachment, err := mr.NextPart()
+	assert.NoError(t, err)
+	assert.Equal(t, "20240101_review.md", attachment.FileName())
+	content, _ := io.ReadAll(base64.NewDecoder(base64.StdEncoding, attachment))
+	assert.Equal(t, "# 评审报告", string(content))
+}
+
+func TestEmailRequiresStartTLS(t *testing.T) {
+	rec := &smtpRecorder{}
+	host, port := rec.server(t)
+
+	e := NewEmail(config.EmailConfig{Host: host, Port: port, StartTLS: true, From: "bot@example.com", To: []string{"a@example.com"}})
+	assert.ErrorContains(t, e.Notify(context.Background(), testNotification()), "STARTTLS")
+}
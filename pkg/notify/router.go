@@ -0,0 +1,215 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/icatw/cr-tool/pkg/config"
+	"github.com/icatw/cr-tool/pkg/glob"
+	"github.com/icatw/cr-tool/pkg/review"
+)
+
+// Router 按规则选择通知渠道
+type Router struct {
+	channels map[string]Notifier
+	rules    []config.NotifyRule
+	defaults []Notifier
+	digest   *DigestQueue
+}
+
+// NewRouter 根据配置创建通知路由
+// 除 notify.channels 中的命名渠道外，已启用的顶层渠道也可以在规则中按名称
+// dingtalk、feishu、wecom、slack 引用
+func NewRouter(cfg *config.Config) (*Router, error) {
+	r := &Router{
+		channels: make(map[string]Notifier),
+		rules:    cfg.Notify.Rules,
+		defaults: FromConfig(cfg),
+		digest:   NewDigestQueue(cfg.Notify.DigestFile),
+	}
+	for _, n := range r.defaults {
+		r.channels[n.Name()] = n
+	}
+
+	for name, ch := range cfg.Notify.Channels {
+		n, err := NewChannel(ch)
+		if err != nil {
+			return nil, fmt.Errorf("通知渠道 %s: %w", name, err)
+		}
+		r.channels[name] = n
+	}
+
+	for i, rule := range r.rules {
+		if _, ok := review.ParseSeverity(rule.MinSeverity); rule.MinSeverity != "" && !ok {
+			return nil, fmt.Errorf("通知规则 %d 的问题级别无效: %s", i+1, rule.MinSeverity)
+		}
+		for _, category := range rule.Categories {
+			if !containsFold(review.Categories, category) {
+				return nil, fmt.Errorf("通知规则 %d 的问题类别无效: %s，可选 %s", i+1, category, strings.Join(review.Categories, "、"))
+			}
+		}
+		for _, name := range rule.Channels {
+			if _, ok := r.channels[name]; !ok {
+				return nil, fmt.Errorf("通知规则 %d 引用了未定义的渠道: %s", i+1, name)
+			}
+		}
+	}
+	return r, nil
+}
+
+// NewChannel 根据渠道配置创建通知发送器
+func NewChannel(ch config.ChannelConfig) (Notifier, error) {
+	switch strings.ToLower(ch.Type) {
+	case "ding", "dingtalk":
+		return NewDingTalk(config.DingConfig{Enabled: true, Webhook: ch.Webhook, Secret: ch.Secret, MsgType: ch.MsgType}), nil
+	case "feishu", "lark":
+		return NewFeishu(config.FeishuConfig{Enabled: true, Webhook: ch.Webhook, Secret: ch.Secret}), nil
+	case "wecom":
+		return NewWeCom(config.WeComConfig{Enabled: true, Webhook: ch.Webhook}), nil
+	case "slack":
+		return NewSlack(config.SlackConfig{Enabled: true, Webhook: ch.Webhook}), nil
+	default:
+		return nil, fmt.Errorf("不支持的渠道类型: %s", ch.Type)
+	}
+}
+
+// Empty 是否没有任何可用的通知渠道
+func (r *Router) Empty() bool {
+	return len(r.rules) == 0 && len(r.defaults) == 0
+}
+
+// Route 按规则发送通知，未配置规则时发送到所有已启用的渠道
+func (r *Router) Route(ctx context.Context, n *Notification) error {
+	if len(r.rules) == 0 {
+		return NotifyAll(ctx, r.defaults, n)
+	}
+
+	var (
+		errs     []error
+		sent     = make(map[string]bool)
+		digested = make(map[string]bool)
+	)
+	for _, rule := range r.rules {
+		if !MatchRule(rule, n.History) {
+			continue
+		}
+
+		for _, name := range rule.Channels {
+			if rule.Digest {
+				digested[name] = true
+				continue
+			}
+			// 同一渠道只发送一次
+			if sent[name] {
+				continue
+			}
+			sent[name] = true
+			if err := r.channels[name].Notify(ctx, n); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			}
+		}
+
+		if !rule.Continue {
+			break
+		}
+	}
+
+	if len(digested) > 0 {
+		channels := make([]string, 0, len(digested))
+		for name := range digested {
+			channels = append(channels, name)
+		}
+		if err := r.digest.Add(NewDigestEntry(n, channels)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SendDigest 发送并清空待发送的摘要
+func (r *Router) SendDigest(ctx context.Context) (int, error) {
+	return r.digest.Flush(ctx, r.channels)
+}
+
+// MatchRule 判断评审结果是否命中规则
+func MatchRule(rule config.NotifyRule, h *review.ReviewHistory) bool {
+	var branch, author string
+	var changed []string
+	if h.GitInfo != nil {
+		branch = h.GitInfo.Branch
+		author = h.GitInfo.Author
+		changed = h.GitInfo.ChangedFiles
+	}
+
+	if len(rule.Branches) > 0 && !glob.MatchAny(rule.Branches, branch) {
+		return false
+	}
+	if len(rule.Authors) > 0 && !matchAuthor(rule.Authors, author) {
+		return false
+	}
+
+	if rule.MinSeverity == "" && len(rule.Categories) == 0 {
+		if len(rule.Paths) == 0 {
+			return true
+		}
+		for _, file := range changed {
+			if matchPath(rule.Paths, file) {
+				return true
+			}
+		}
+		return false
+	}
+
+	minSeverity, _ := review.ParseSeverity(rule.MinSeverity)
+	for _, f := range h.Findings {
+		if f.Severity.Rank() < minSeverity.Rank() {
+			continue
+		}
+		if len(rule.Categories) > 0 && !containsFold(rule.Categories, f.Category) {
+			continue
+		}
+		if len(rule.Paths) > 0 && !matchPath(rule.Paths, f.File) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// matchPath 路径匹配，不含 / 的模式只匹配文件名
+func matchPath(patterns []string, file string) bool {
+	for _, pattern := range patterns {
+		name := file
+		if !strings.Contains(pattern, "/") {
+			name = path.Base(file)
+		}
+		if glob.Match(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAuthor 作者匹配，支持通配符，不区分大小写
+func matchAuthor(patterns []string, author string) bool {
+	author = strings.ToLower(author)
+	for _, pattern := range patterns {
+		if glob.Match(strings.ToLower(pattern), author) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFold 不区分大小写的包含判断
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
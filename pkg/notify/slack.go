@@ -0,0 +1,102 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/icatw/cr-tool/pkg/config"
+	"github.com/icatw/cr-tool/pkg/text"
+)
+
+const (
+	// slackMaxBytes Slack section 文本的长度上限为 3000 字符，按字节计算更保守
+	slackMaxBytes = 3000
+	// slackHeaderMax Slack header 文本的长度上限
+	slackHeaderMax = 150
+)
+
+// Slack Slack Incoming Webhook 通知，使用 Block Kit 消息
+type Slack struct {
+	config config.SlackConfig
+	client *http.Client
+}
+
+// NewSlack 创建 Slack 通知
+func NewSlack(cfg config.SlackConfig) *Slack {
+	return &Slack{
+		config: cfg,
+		client: newHTTPClient(),
+	}
+}
+
+// Name 返回通知渠道名称
+func (s *Slack) Name() string {
+	return "slack"
+}
+
+// Notify 发送 Slack 消息
+func (s *Slack) Notify(ctx context.Context, n *Notification) error {
+	if s.config.Webhook == "" {
+		return fmt.Errorf("Slack webhook 未配置")
+	}
+
+	body, err := postJSON(ctx, s.client, s.config.Webhook, s.message(n))
+	if err != nil {
+		return err
+	}
+	if text := strings.TrimSpace(string(body)); text != "ok" {
+		return fmt.Errorf("发送 Slack 消息失败: %s", text)
+	}
+	return nil
+}
+
+// message 构造 Block Kit 消息，报告链接以按钮形式展示
+func (s *Slack) message(n *Notification) map[string]interface{} {
+	summary := *n
+	summary.ReportURL = ""
+
+	blocks := []interface{}{
+		map[string]interface{}{
+			"type": "header",
+			"text": map[string]string{"type": "plain_text", "text": text.Truncate(n.Title(), slackHeaderMax)},
+		},
+		map[string]interface{}{
+			"type": "section",
+			"text": map[string]string{"type": "mrkdwn", "text": toSlackMrkdwn(summary.Summary(slackMaxBytes))},
+		},
+	}
+	if n.ReportURL != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "actions",
+			"elements": []interface{}{
+				map[string]interface{}{
+					"type": "button",
+					"text": map[string]string{"type": "plain_text", "text": "查看完整报告"},
+					"url":  n.ReportURL,
+				},
+			},
+		})
+	}
+
+	return map[string]interface{}{
+		"text":   n.Title(),
+		"blocks": blocks,
+	}
+}
+
+var (
+	markdownHeading = regexp.MustCompile(`(?m)^#{1,6}\s+(.+)$`)
+	markdownBold    = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	markdownLink    = regexp.MustCompile(`\[([^\]]+)\]\(([^)]+)\)`)
+)
+
+// toSlackMrkdwn 将 Markdown 转换为 Slack mrkdwn 语法
+func toSlackMrkdwn(md string) string {
+	md = markdownHeading.ReplaceAllString(md, "*$1*")
+	md = markdownBold.ReplaceAllString(md, "*$1*")
+	md = markdownLink.ReplaceAllString(md, "<$2|$1>")
+	return md
+}
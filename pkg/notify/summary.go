@@ -0,0 +1,81 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/icatw/cr-tool/pkg/text"
+)
+
+// Summary 生成不超过 limit 字节的 Markdown 通知正文
+// 依次放入概要、问题列表和评审详情，空间不足时先截断评审详情，再省略部分问题
+func (n *Notification) Summary(limit int) string {
+	if len(n.Digest) > 0 {
+		return n.digestSummary(limit)
+	}
+	h := n.History
+
+	var head strings.Builder
+	head.WriteString(fmt.Sprintf("### %s\n\n", n.Title()))
+	if h.GitInfo != nil {
+		if h.GitInfo.Author != "" {
+			head.WriteString(fmt.Sprintf("- 作者: %s\n", h.GitInfo.Author))
+		}
+		if h.GitInfo.CommitHash != "" {
+			commit := h.GitInfo.CommitHash
+			if len(commit) > 8 {
+				commit = commit[:8]
+			}
+			head.WriteString(fmt.Sprintf("- 提交: %s %s\n", commit, h.GitInfo.CommitMessage))
+		}
+	}
+	if h.ReviewStats != nil {
+		head.WriteString(fmt.Sprintf("- 变更: %d 个文件，+%d / -%d\n",
+			h.ReviewStats.FilesChanged, h.ReviewStats.LinesAdded, h.ReviewStats.LinesDeleted))
+	}
+	if counts := n.SeverityCounts(); len(counts) > 0 {
+		parts := make([]string, 0, len(counts))
+		for _, c := range counts {
+			parts = append(parts, fmt.Sprintf("%s %d", c.Severity, c.Count))
+		}
+		head.WriteString(fmt.Sprintf("- 问题: %s\n", strings.Join(parts, " · ")))
+	} else {
+		head.WriteString("- 问题: 无\n")
+	}
+
+	var tail string
+	if n.ReportURL != "" {
+		tail = fmt.Sprintf("\n[查看完整报告](%s)\n", n.ReportURL)
+	}
+
+	budget := limit - len(head.String()) - len(tail)
+	if budget <= 0 {
+		return text.Truncate(head.String()+tail, limit)
+	}
+
+	// 问题列表，放不下时注明省略的数量
+	var body strings.Builder
+	if len(h.Findings) > 0 {
+		body.WriteString("\n")
+		reserve := len(fmt.Sprintf("- ……另有 %d 个问题\n", len(h.Findings)))
+		for i, f := range h.Findings {
+			line := fmt.Sprintf("- **[%s]** `%s` %s\n", f.Severity, f.Location(), f.Title)
+			last := i == len(h.Findings)-1
+			if (last && body.Len()+len(line) > budget) || (!last && body.Len()+len(line)+reserve > budget) {
+				body.WriteString(fmt.Sprintf("- ……另有 %d 个问题\n", len(h.Findings)-i))
+				break
+			}
+			body.WriteString(line)
+		}
+	}
+
+	// 评审详情，剩余空间太小时不再附带
+	const minDetail = 200
+	if remaining := budget - body.Len(); remaining >= minDetail && strings.TrimSpace(h.ReviewResult) != "" {
+		body.WriteString("\n---\n\n")
+		body.WriteString(text.Truncate(strings.TrimSpace(h.ReviewResult), remaining-len("\n---\n\n")-1))
+		body.WriteString("\n")
+	}
+
+	return text.Truncate(head.String()+body.String()+tail, limit)
+}
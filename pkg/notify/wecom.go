@@ -0,0 +1,49 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/icatw/cr-tool/pkg/config"
+)
+
+// weComMaxBytes 企业微信 markdown 消息内容的长度上限
+const weComMaxBytes = 4096
+
+// WeCom 企业微信群机器人通知
+type WeCom struct {
+	config config.WeComConfig
+	client *http.Client
+}
+
+// NewWeCom 创建企业微信机器人通知
+func NewWeCom(cfg config.WeComConfig) *WeCom {
+	return &WeCom{
+		config: cfg,
+		client: newHTTPClient(),
+	}
+}
+
+// Name 返回通知渠道名称
+func (w *WeCom) Name() string {
+	return "wecom"
+}
+
+// Notify 发送企业微信 markdown 消息
+func (w *WeCom) Notify(ctx context.Context, n *Notification) error {
+	if w.config.Webhook == "" {
+		return fmt.Errorf("企业微信 webhook 未配置")
+	}
+
+	body, err := postJSON(ctx, w.client, w.config.Webhook, map[string]interface{}{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"content": n.Summary(weComMaxBytes),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return checkErrCode(body)
+}
@@ -0,0 +1,299 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/icatw/cr-tool/pkg/review"
+)
+
+// severityColors 问题级别对应的颜色，与评审报告保持一致
+var severityColors = map[review.Severity]string{
+	review.SeverityHigh:   "#cf222e",
+	review.SeverityMedium: "#9a6700",
+	review.SeverityLow:    "#0969da",
+}
+
+// ExportTrendsHTML 生成趋势看板并保存到输出目录，返回文件路径
+func ExportTrendsHTML(t *Trends, outputDir string) (string, error) {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", fmt.Errorf("创建输出目录失败: %w", err)
+	}
+
+	filename := fmt.Sprintf("%s_trends.html", time.Now().Format("20060102_150405"))
+	outputPath := filepath.Join(outputDir, filename)
+	if err := os.WriteFile(outputPath, []byte(RenderTrendsHTML(t)), 0644); err != nil {
+		return "", fmt.Errorf("保存趋势报告失败: %w", err)
+	}
+	return outputPath, nil
+}
+
+// RenderTrendsHTML 将评审趋势渲染为带内联 SVG 图表的 HTML 页面
+func RenderTrendsHTML(t *Trends) string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>代码评审趋势</title>
+    <style>%s</style>
+</head>
+<body>
+<div class="container">
+<h1>代码评审趋势</h1>`, trendsCSS))
+
+	if t.Reviews == 0 {
+		b.WriteString(`<p>暂无评审记录</p></div></body></html>`)
+		return b.String()
+	}
+
+	// 概览
+	b.WriteString(fmt.Sprintf(`<p class="range">%s ~ %s</p>
+<div class="stats-grid">
+	<div class="stat-item"><div class="stat-value">%d</div><div class="stat-label">评审次数</div></div>
+	<div class="stat-item"><div class="stat-value">%d</div><div class="stat-label">已消失的问题</div></div>
+	<div class="stat-item"><div class="stat-value">%d</div><div class="stat-label">仍存在的问题</div></div>
+	<div class="stat-item"><div class="stat-value">%s</div><div class="stat-label">问题平均消失时间</div></div>
+</div>`,
+		t.From.Format("2006-01-02"), t.To.Format("2006-01-02"),
+		t.Reviews, t.Resolved, t.Open, formatDuration(t.MeanTimeToResolve)))
+
+	b.WriteString(`<h2>各级别问题趋势</h2>`)
+	b.WriteString(severityChart(t.Daily))
+
+	if len(t.TopCategories) > 0 {
+		b.WriteString(`<h2>高频问题类别</h2>`)
+		b.WriteString(barChart(t.TopCategories))
+	}
+
+	b.WriteString(`<h2>作者：变更行数与问题数</h2>`)
+	b.WriteString(scatterChart(t.Authors))
+	b.WriteString(statsTable("作者", t.Authors))
+
+	b.WriteString(`<h2>目录：变更行数与问题数</h2>`)
+	b.WriteString(scatterChart(t.Directories))
+	b.WriteString(statsTable("目录", t.Directories))
+
+	b.WriteString(fmt.Sprintf(`
+<div class="footer">生成时间：%s</div>
+</div></body></html>`, time.Now().Format("2006-01-02 15:04:05")))
+
+	return b.String()
+}
+
+// severityChart 按天堆叠的各级别问题数柱状图
+func severityChart(daily []DailyFindings) string {
+	const width, height, left, bottom, top = 800, 260, 40, 40, 10
+	maxTotal := 1
+	for _, d := range daily {
+		total := 0
+		for _, s := range severities {
+			total += d.Counts[s]
+		}
+		if total > maxTotal {
+			maxTotal = total
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf(`<svg class="chart" viewBox="0 0 %d %d" xmlns="http://www.w3.org/2000/svg">`, width, height))
+	plotHeight := float64(height - bottom - top)
+	b.WriteString(axes(left, top, width, height-bottom, maxTotal))
+
+	slot := float64(width-left-10) / float64(len(daily))
+	barWidth := math.Max(2, slot*0.7)
+	labelEvery := int(math.Ceil(float64(len(daily)) / 12))
+	for i, d := range daily {
+		x := float64(left) + slot*float64(i) + (slot-barWidth)/2
+		y := float64(height - bottom)
+		for _, s := range severities {
+			n := d.Counts[s]
+			if n == 0 {
+				continue
+			}
+			h := plotHeight * float64(n) / float64(maxTotal)
+			y -= h
+			b.WriteString(fmt.Sprintf(`<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"><title>%s %s: %d</title></rect>`,
+				x, y, barWidth, h, severityColors[s], d.Date, s, n))
+		}
+		if i%labelEvery == 0 {
+			b.WriteString(fmt.Sprintf(`<text x="%.1f" y="%d" class="label" text-anchor="middle">%s</text>`,
+				x+barWidth/2, height-bottom+16, d.Date[5:]))
+		}
+	}
+
+	// 图例
+	for i, s := range severities {
+		x := left + 10 + i*80
+		b.WriteString(fmt.Sprintf(`<rect x="%d" y="%d" width="10" height="10" fill="%s"/><text x="%d" y="%d" class="label">%s</text>`,
+			x, height-12, severityColors[s], x+14, height-3, s))
+	}
+	b.WriteString(`</svg>`)
+	return b.String()
+}
+
+// barChart 水平柱状图
+func barChart(counts []Count) string {
+	const width, row, labelWidth = 800, 24, 200
+	height := row*len(counts) + 10
+	maxCount := 1
+	for _, c := range counts {
+		if c.Count > maxCount {
+			maxCount = c.Count
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf(`<svg class="chart" viewBox="0 0 %d %d" xmlns="http://www.w3.org/2000/svg">`, width, height))
+	for i, c := range counts {
+		y := 5 + i*row
+		w := float64(width-labelWidth-60) * float64(c.Count) / float64(maxCount)
+		b.WriteString(fmt.Sprintf(`<text x="%d" y="%d" class="label" text-anchor="end">%s</text>`,
+			labelWidth-8, y+15, template.HTMLEscapeString(truncate(c.Name, 28))))
+		b.WriteString(fmt.Sprintf(`<rect x="%d" y="%d" width="%.1f" height="%d" fill="#0969da"/>`, labelWidth, y+3, w, row-8))
+		b.WriteString(fmt.Sprintf(`<text x="%.1f" y="%d" class="label">%d</text>`, float64(labelWidth)+w+6, y+15, c.Count))
+	}
+	b.WriteString(`</svg>`)
+	return b.String()
+}
+
+// scatterChart 变更行数与问题数散点图
+func scatterChart(stats []ChurnStat) string {
+	const width, height, left, bottom, top, right = 800, 300, 50, 40, 10, 20
+	maxChurn, maxFindings := 1, 1
+	for _, s := range stats {
+		if s.Churn > maxChurn {
+			maxChurn = s.Churn
+		}
+		if s.Findings > maxFindings {
+			maxFindings = s.Findings
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf(`<svg class="chart" viewBox="0 0 %d %d" xmlns="http://www.w3.org/2000/svg">`, width, height))
+	b.WriteString(axes(left, top, width-right, height-bottom, maxFindings))
+	b.WriteString(fmt.Sprintf(`<text x="%d" y="%d" class="label" text-anchor="end">变更行数 (最大 %d)</text>`,
+		width-right, height-bottom+30, maxChurn))
+
+	plotWidth := float64(width - left - right)
+	plotHeight := float64(height - bottom - top)
+	for i, s := range stats {
+		x := float64(left) + plotWidth*float64(s.Churn)/float64(maxChurn)
+		y := float64(height-bottom) - plotHeight*float64(s.Findings)/float64(maxFindings)
+		b.WriteString(fmt.Sprintf(`<circle cx="%.1f" cy="%.1f" r="5" fill="#cf222e" fill-opacity="0.6"><title>%s: %d 行 / %d 个问题</title></circle>`,
+			x, y, template.HTMLEscapeString(s.Name), s.Churn, s.Findings))
+		// 只标注问题最多的几个点，避免文字重叠
+		if i < 8 {
+			b.WriteString(fmt.Sprintf(`<text x="%.1f" y="%.1f" class="label">%s</text>`,
+				x+7, y-7, template.HTMLEscapeString(truncate(s.Name, 20))))
+		}
+	}
+	b.WriteString(`</svg>`)
+	return b.String()
+}
+
+// axes 绘制坐标轴和纵轴刻度
+func axes(left, top, right, bottom, maxValue int) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf(`<line x1="%d" y1="%d" x2="%d" y2="%d" class="axis"/>`, left, top, left, bottom))
+	b.WriteString(fmt.Sprintf(`<line x1="%d" y1="%d" x2="%d" y2="%d" class="axis"/>`, left, bottom, right, bottom))
+	for _, v := range []int{0, maxValue / 2, maxValue} {
+		y := float64(bottom) - float64(bottom-top)*float64(v)/float64(maxValue)
+		b.WriteString(fmt.Sprintf(`<text x="%d" y="%.1f" class="label" text-anchor="end">%d</text>`, left-6, y+4, v))
+	}
+	return b.String()
+}
+
+// statsTable 统计表格
+func statsTable(title string, stats []ChurnStat) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf(`<table><tr><th>%s</th><th>评审次数</th><th>变更行数</th><th>问题数</th><th>每百行问题数</th></tr>`, title))
+	for _, s := range stats {
+		density := 0.0
+		if s.Churn > 0 {
+			density = float64(s.Findings) * 100 / float64(s.Churn)
+		}
+		b.WriteString(fmt.Sprintf(`<tr><td>%s</td><td>%d</td><td>%d</td><td>%d</td><td>%.2f</td></tr>`,
+			template.HTMLEscapeString(s.Name), s.Reviews, s.Churn, s.Findings, density))
+	}
+	b.WriteString(`</table>`)
+	return b.String()
+}
+
+// formatDuration 以天或小时显示时长
+func formatDuration(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return "-"
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%.1f 天", d.Hours()/24)
+	default:
+		return fmt.Sprintf("%.1f 小时", d.Hours())
+	}
+}
+
+// truncate 按字符截断文本
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+// trendsCSS 趋势看板样式
+const trendsCSS = `
+	body {
+		font-family: -apple-system,BlinkMacSystemFont,Segoe UI,Helvetica,Arial,sans-serif;
+		line-height: 1.5;
+		color: #24292f;
+		margin: 0;
+		padding: 20px;
+	}
+	.container {
+		max-width: 1200px;
+		margin: 0 auto;
+		background: white;
+		padding: 2rem;
+		border-radius: 6px;
+		box-shadow: 0 1px 3px rgba(0,0,0,0.12);
+	}
+	h1 { padding-bottom: .3em; border-bottom: 1px solid #d0d7de; }
+	h2 { margin-top: 2em; }
+	.range { color: #666; }
+	.stats-grid {
+		display: grid;
+		grid-template-columns: repeat(auto-fit, minmax(200px, 1fr));
+		gap: 1rem;
+		margin: 1rem 0;
+	}
+	.stat-item {
+		background: #f6f8fa;
+		border: 1px solid #d0d7de;
+		border-radius: 6px;
+		padding: 1rem;
+		text-align: center;
+	}
+	.stat-value { font-size: 2rem; font-weight: bold; }
+	.stat-label { color: #666; margin-top: 0.5rem; }
+	.chart { width: 100%; height: auto; }
+	.chart .axis { stroke: #d0d7de; }
+	.chart .label { font-size: 11px; fill: #57606a; }
+	table { width: 100%; border-collapse: collapse; margin-top: 1rem; }
+	th, td { border: 1px solid #d0d7de; padding: 0.4rem 0.6rem; text-align: left; }
+	th { background: #f6f8fa; }
+	.footer {
+		margin-top: 2rem;
+		padding-top: 1rem;
+		border-top: 1px solid #d0d7de;
+		color: #666;
+		font-size: 0.9rem;
+	}
+`
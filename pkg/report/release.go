@@ -0,0 +1,128 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/icatw/cr-tool/pkg/history"
+	"github.com/icatw/cr-tool/pkg/review"
+)
+
+// CommitLogFormat 解析提交记录时 git log 使用的 --format，字段以 \x00 分隔，提交以 \x1e 结尾
+const CommitLogFormat = "%H%x00%h%x00%an%x00%aI%x00%s%x00%b%x1e"
+
+// Commit 发布范围内的一个提交
+type Commit struct {
+	Hash    string
+	Short   string
+	Author  string
+	Date    time.Time
+	Subject string
+	Body    string
+}
+
+// ParseCommits 解析以 CommitLogFormat 输出的 git log
+func ParseCommits(log string) []Commit {
+	var commits []Commit
+	for _, entry := range strings.Split(log, "\x1e") {
+		fields := strings.SplitN(strings.TrimLeft(entry, "\n"), "\x00", 6)
+		if len(fields) < 6 {
+			continue
+		}
+		date, _ := time.Parse(time.RFC3339, fields[3])
+		commits = append(commits, Commit{
+			Hash:    fields[0],
+			Short:   fields[1],
+			Author:  fields[2],
+			Date:    date,
+			Subject: fields[4],
+			Body:    strings.TrimSpace(fields[5]),
+		})
+	}
+	return commits
+}
+
+// FormatCommits 将提交记录格式化为发送给模型的文本
+func FormatCommits(commits []Commit) string {
+	var b strings.Builder
+	for _, c := range commits {
+		fmt.Fprintf(&b, "- %s %s（%s）\n", c.Short, c.Subject, c.Author)
+		if c.Body != "" {
+			for _, line := range strings.Split(c.Body, "\n") {
+				fmt.Fprintf(&b, "  %s\n", line)
+			}
+		}
+	}
+	return b.String()
+}
+
+// ReleaseReviews 筛选发布范围内的评审记录：基于范围内的提交进行的评审，
+// 或在 since 与 until 之间进行的评审，结果按时间排序
+func ReleaseReviews(records []*history.Record, commits []Commit, since, until time.Time) []*history.Record {
+	hashes := make(map[string]bool, len(commits))
+	for _, c := range commits {
+		hashes[c.Hash] = true
+	}
+
+	var result []*history.Record
+	for _, r := range records {
+		inRange := !r.DateTime.Before(since) && !r.DateTime.After(until)
+		if r.GitInfo != nil && hashes[r.GitInfo.CommitHash] || inRange {
+			result = append(result, r)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].DateTime.Before(result[j].DateTime)
+	})
+	return result
+}
+
+// FormatReviews 汇总评审记录，列出各级别问题数、处理情况以及严重和中等问题
+func FormatReviews(records []*history.Record) string {
+	if len(records) == 0 {
+		return ""
+	}
+
+	counts := make(map[review.Severity]int)
+	handled := make(map[history.Status]int)
+	var b, issues strings.Builder
+	for _, r := range records {
+		for _, f := range r.Findings {
+			counts[f.Severity]++
+			d, ok := r.Decision(f)
+			if ok {
+				handled[d.Status]++
+			}
+			if f.Severity.Rank() < review.SeverityMedium.Rank() {
+				continue
+			}
+			status := history.Status("").Label()
+			if ok {
+				status = d.Status.Label()
+				if d.Applied {
+					status += "，已应用修复"
+				}
+			}
+			fmt.Fprintf(&issues, "- [%s] %s %s（%s）\n", f.Severity, f.Location(), f.Title, status)
+		}
+	}
+
+	fmt.Fprintf(&b, "共 %d 次评审", len(records))
+	for _, s := range severities {
+		fmt.Fprintf(&b, "，%s %d 个", s, counts[s])
+	}
+	b.WriteString("\n处理情况：")
+	for i, s := range []history.Status{history.StatusAccepted, history.StatusFalsePositive, history.StatusWontFix} {
+		if i > 0 {
+			b.WriteString("，")
+		}
+		fmt.Fprintf(&b, "%s %d 个", s.Label(), handled[s])
+	}
+	b.WriteString("\n")
+	if issues.Len() > 0 {
+		b.WriteString("严重和中等问题：\n" + issues.String())
+	}
+	return b.String()
+}
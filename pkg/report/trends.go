@@ -0,0 +1,229 @@
+// Package report 基于评审历史生成汇总报告
+package report
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/icatw/cr-tool/pkg/history"
+	"github.com/icatw/cr-tool/pkg/review"
+)
+
+// severities 参与统计的问题级别，按严重程度排列
+var severities = []review.Severity{review.SeverityHigh, review.SeverityMedium, review.SeverityLow}
+
+// DailyFindings 某一天的问题数量
+type DailyFindings struct {
+	Date   string
+	Counts map[review.Severity]int
+}
+
+// Count 名称与数量
+type Count struct {
+	Name  string
+	Count int
+}
+
+// ChurnStat 变更行数与问题数
+type ChurnStat struct {
+	Name     string
+	Reviews  int
+	Churn    int
+	Findings int
+}
+
+// Trends 评审趋势
+type Trends struct {
+	From    time.Time
+	To      time.Time
+	Reviews int
+
+	Daily         []DailyFindings
+	TopCategories []Count
+	Authors       []ChurnStat
+	Directories   []ChurnStat
+
+	// Resolved 已消失的问题数，Open 仍未消失的问题数
+	Resolved int
+	Open     int
+	// MeanTimeToResolve 问题从首次出现到消失的平均时长
+	MeanTimeToResolve time.Duration
+}
+
+// openFinding 尚未消失的问题
+type openFinding struct {
+	file      string
+	firstSeen time.Time
+}
+
+// Aggregate 汇总评审记录，记录顺序不限
+func Aggregate(records []*history.Record) *Trends {
+	sorted := make([]*history.Record, len(records))
+	copy(sorted, records)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].DateTime.Before(sorted[j].DateTime)
+	})
+
+	t := &Trends{Reviews: len(sorted)}
+	if len(sorted) == 0 {
+		return t
+	}
+	t.From = sorted[0].DateTime
+	t.To = sorted[len(sorted)-1].DateTime
+
+	daily := make(map[string]map[review.Severity]int)
+	categories := make(map[string]int)
+	authors := make(map[string]*ChurnStat)
+	dirs := make(map[string]*ChurnStat)
+	open := make(map[string]openFinding)
+	var resolvedTotal time.Duration
+
+	for _, r := range sorted {
+		day := r.DateTime.Format("2006-01-02")
+		if daily[day] == nil {
+			daily[day] = make(map[review.Severity]int)
+		}
+		for _, f := range r.Findings {
+			daily[day][f.Severity]++
+		}
+
+		// 类别优先使用结构化问题，旧记录回退到 CommonIssues
+		if len(r.Findings) > 0 {
+			for _, f := range r.Findings {
+				if f.Category != "" {
+					categories[f.Category]++
+				}
+			}
+		} else if r.ReviewStats != nil {
+			for _, issue := range r.ReviewStats.CommonIssues {
+				categories[issue]++
+			}
+		}
+
+		// 按作者统计
+		author := r.Author()
+		if author == "" {
+			author = "未知"
+		}
+		a := statFor(authors, author)
+		a.Reviews++
+		a.Findings += len(r.Findings)
+		if r.ReviewStats != nil {
+			a.Churn += r.ReviewStats.LinesAdded + r.ReviewStats.LinesDeleted
+		}
+
+		// 按目录统计
+		touched := make(map[string]bool)
+		if r.ReviewStats != nil {
+			for file, churn := range r.ReviewStats.FileChanges {
+				d := statFor(dirs, dirOf(file))
+				d.Churn += churn
+				if !touched[d.Name] {
+					touched[d.Name] = true
+					d.Reviews++
+				}
+			}
+		}
+		for _, f := range r.Findings {
+			statFor(dirs, dirOf(f.File)).Findings++
+		}
+
+		// 本次评审涉及的文件中，之前存在而本次未出现的问题视为已消失
+		changed := changedFiles(r)
+		present := make(map[string]bool, len(r.Findings))
+		for _, f := range r.Findings {
+			present[f.Fingerprint()] = true
+		}
+		for fp, o := range open {
+			if !present[fp] && changed[o.file] {
+				resolvedTotal += r.DateTime.Sub(o.firstSeen)
+				t.Resolved++
+				delete(open, fp)
+			}
+		}
+		for _, f := range r.Findings {
+			fp := f.Fingerprint()
+			if _, ok := open[fp]; !ok {
+				open[fp] = openFinding{file: f.File, firstSeen: r.DateTime}
+			}
+		}
+	}
+
+	t.Open = len(open)
+	if t.Resolved > 0 {
+		t.MeanTimeToResolve = resolvedTotal / time.Duration(t.Resolved)
+	}
+
+	for day, counts := range daily {
+		t.Daily = append(t.Daily, DailyFindings{Date: day, Counts: counts})
+	}
+	sort.Slice(t.Daily, func(i, j int) bool { return t.Daily[i].Date < t.Daily[j].Date })
+
+	for name, count := range categories {
+		t.TopCategories = append(t.TopCategories, Count{Name: name, Count: count})
+	}
+	sort.Slice(t.TopCategories, func(i, j int) bool {
+		if t.TopCategories[i].Count != t.TopCategories[j].Count {
+			return t.TopCategories[i].Count > t.TopCategories[j].Count
+		}
+		return t.TopCategories[i].Name < t.TopCategories[j].Name
+	})
+	if len(t.TopCategories) > 10 {
+		t.TopCategories = t.TopCategories[:10]
+	}
+
+	t.Authors = sortedStats(authors)
+	t.Directories = sortedStats(dirs)
+	return t
+}
+
+// statFor 获取或创建统计项
+func statFor(stats map[string]*ChurnStat, name string) *ChurnStat {
+	s, ok := stats[name]
+	if !ok {
+		s = &ChurnStat{Name: name}
+		stats[name] = s
+	}
+	return s
+}
+
+// sortedStats 按问题数和变更行数倒序排列
+func sortedStats(stats map[string]*ChurnStat) []ChurnStat {
+	result := make([]ChurnStat, 0, len(stats))
+	for _, s := range stats {
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Findings != result[j].Findings {
+			return result[i].Findings > result[j].Findings
+		}
+		if result[i].Churn != result[j].Churn {
+			return result[i].Churn > result[j].Churn
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// changedFiles 返回评审涉及的文件
+func changedFiles(r *history.Record) map[string]bool {
+	files := make(map[string]bool)
+	if r.GitInfo != nil {
+		for _, f := range r.GitInfo.ChangedFiles {
+			files[f] = true
+		}
+	}
+	if r.ReviewStats != nil {
+		for f := range r.ReviewStats.FileChanges {
+			files[f] = true
+		}
+	}
+	return files
+}
+
+// dirOf 返回文件所在目录，根目录下的文件归为 "."
+func dirOf(file string) string {
+	return path.Dir(strings.TrimPrefix(file, "/"))
+}
@@ -0,0 +1,105 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/icatw/cr-tool/pkg/history"
+	"github.com/icatw/cr-tool/pkg/review"
+	"github.com/stretchr/testify/assert"
+)
+
+func record(author string, at time.Time, changes map[string]int, findings ...review.Finding) *history.Record {
+	return history.NewRecord(&review.ReviewHistory{
+		ID:       at.Format("150405"),
+		GitInfo:  &review.GitInfo{Author: author},
+		Findings: findings,
+		ReviewStats: &review.ReviewStats{
+			LinesAdded:  sum(changes),
+			FileChanges: changes,
+		},
+		DateTime: at,
+	}, "")
+}
+
+func sum(m map[string]int) int {
+	total := 0
+	for _, v := range m {
+		total += v
+	}
+	return total
+}
+
+func TestAggregate(t *testing.T) {
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.Local)
+	sqli := review.Finding{File: "db/query.go", Severity: review.SeverityHigh, Category: "security", Title: "SQL 注入"}
+	naming := review.Finding{File: "api/user.go", Severity: review.SeverityLow, Category: "style", Title: "命名"}
+	loop := review.Finding{File: "db/query.go", Severity: review.SeverityMedium, Category: "performance", Title: "循环查询"}
+
+	records := []*history.Record{
+		// 乱序传入，Aggregate 会按时间排序
+		record("Bob", start.Add(48*time.Hour), map[string]int{"db/query.go": 5}, loop),
+		record("Alice", start, map[string]int{"db/query.go": 30, "api/user.go": 10}, sqli, naming),
+		record("Alice", start.Add(24*time.Hour), map[string]int{"api/user.go": 4}, sqli),
+	}
+
+	trends := Aggregate(records)
+	assert.Equal(t, 3, trends.Reviews)
+	assert.Len(t, trends.Daily, 3)
+	assert.Equal(t, 1, trends.Daily[0].Counts[review.SeverityHigh])
+	assert.Equal(t, 1, trends.Daily[0].Counts[review.SeverityLow])
+
+	assert.Equal(t, Count{Name: "security", Count: 2}, trends.TopCategories[0])
+
+	assert.Equal(t, ChurnStat{Name: "Alice", Reviews: 2, Churn: 44, Findings: 3}, trends.Authors[0])
+	assert.Equal(t, "db", trends.Directories[0].Name)
+	assert.Equal(t, 35, trends.Directories[0].Churn)
+	assert.Equal(t, 3, trends.Directories[0].Findings)
+
+	// 命名问题在第二次评审 api/user.go 时消失，SQL 注入在第三次评审 db/query.go 时消失
+	assert.Equal(t, 2, trends.Resolved)
+	assert.Equal(t, 1, trends.Open)
+	assert.Equal(t, 36*time.Hour, trends.MeanTimeToResolve)
+
+	html := RenderTrendsHTML(trends)
+	assert.Contains(t, html, "<svg")
+	assert.Contains(t, html, "1.5 天")
+	assert.True(t, strings.HasSuffix(html, "</html>"))
+}
+
+func TestAggregateEmpty(t *testing.T) {
+	trends := Aggregate(nil)
+	assert.Equal(t, 0, trends.Reviews)
+	assert.Contains(t, RenderTrendsHTML(trends), "暂无评审记录")
+}
+
+func TestReleaseNotes(t *testing.T) {
+	log := "aaaa1111\x00aaaa\x00张三\x002024-03-02T10:00:00+08:00\x00feat: 支持导出 PDF\x00新增 pdf 格式\n\x1e\n" +
+		"bbbb2222\x00bbbb\x00李四\x002024-03-01T10:00:00+08:00\x00fix: 修复空指针\x00\x1e\n"
+	commits := ParseCommits(log)
+	assert.Len(t, commits, 2)
+	assert.Equal(t, "bbbb", commits[1].Short)
+	assert.Equal(t, "新增 pdf 格式", commits[0].Body)
+	assert.Equal(t, 2024, commits[0].Date.Year())
+	assert.Equal(t, "- aaaa feat: 支持导出 PDF（张三）\n  新增 pdf 格式\n- bbbb fix: 修复空指针（李四）\n", FormatCommits(commits))
+
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+	sqli := review.Finding{File: "db/query.go", Line: 3, Severity: review.SeverityHigh, Title: "SQL 注入"}
+	naming := review.Finding{File: "api/user.go", Severity: review.SeverityLow, Title: "命名"}
+	inWindow := record("张三", start.Add(time.Hour), nil, sqli, naming)
+	inWindow.Decide(sqli, history.StatusAccepted, true)
+	byCommit := record("李四", start.Add(-48*time.Hour), nil)
+	byCommit.GitInfo.CommitHash = "bbbb2222"
+	outside := record("王五", start.Add(-48*time.Hour), nil, sqli)
+
+	records := ReleaseReviews([]*history.Record{inWindow, outside, byCommit}, commits, start, start.Add(24*time.Hour))
+	assert.Equal(t, []*history.Record{byCommit, inWindow}, records)
+
+	summary := FormatReviews(records)
+	assert.Contains(t, summary, "共 2 次评审，严重 1 个，中等 0 个，低 1 个")
+	assert.Contains(t, summary, "接受 1 个")
+	assert.Contains(t, summary, "- [严重] db/query.go:3 SQL 注入（接受，已应用修复）")
+	assert.NotContains(t, summary, "命名")
+	assert.Equal(t, "", FormatReviews(nil))
+}
@@ -0,0 +1,50 @@
+package review
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MaxContextFileSize 追问时附加的单个文件的大小上限
+const MaxContextFileSize = 64 << 10
+
+// Ask 针对一次评审继续提问，以原始的系统提示词（不含问题列表的格式要求）、diff 和评审结果作为上下文，
+// turns 为之前的问答记录，返回模型的回答
+func (r *Reviewer) Ask(h *ReviewHistory, diffContent string, turns []Message, question string) (string, TokenUsage, error) {
+	if err := r.validateConfig(); err != nil {
+		return "", TokenUsage{}, err
+	}
+	if strings.TrimSpace(question) == "" {
+		return "", TokenUsage{}, fmt.Errorf("问题不能为空")
+	}
+
+	// 分组评审时合并各分组的系统提示词
+	var prompts []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(h.Template, ",") {
+		prompt := r.template(strings.TrimSpace(name)).SystemPrompt
+		if !seen[prompt] {
+			seen[prompt] = true
+			prompts = append(prompts, prompt)
+		}
+	}
+
+	// 追问只需要文字回答，不要求输出结构化的问题列表
+	messages := []Message{{Role: "system", Content: strings.Join(prompts, "\n\n")}}
+	// 整体审计的记录没有 diff
+	if diffContent != "" {
+		messages = append(messages, Message{Role: "user", Content: diffContent})
+	}
+	messages = append(messages, Message{Role: "assistant", Content: h.ReviewResult})
+	messages = append(messages, turns...)
+	messages = append(messages, Message{Role: "user", Content: question})
+	return r.complete(messages)
+}
+
+// FileContext 将文件内容格式化为追问时附加的上下文
+func FileContext(path string, content []byte) (string, error) {
+	if len(content) > MaxContextFileSize {
+		return "", fmt.Errorf("文件 %s 超过大小限制: %d > %d bytes", path, len(content), MaxContextFileSize)
+	}
+	return fmt.Sprintf("文件 %s 的完整内容：\n```\n%s\n```", path, strings.TrimRight(string(content), "\n")), nil
+}
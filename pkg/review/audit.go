@@ -0,0 +1,348 @@
+package review
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxAuditFileSize 审计时单个文件的大小上限，超过的文件会被跳过
+const MaxAuditFileSize = 1 << 20
+
+// auditSkipDirs 审计时不进入的目录
+var auditSkipDirs = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+}
+
+// AuditFile 待审计的文件
+type AuditFile struct {
+	// Path 相对仓库根目录的路径
+	Path    string
+	Content string
+}
+
+// auditChunk 一个文件或大文件中的一段，Start 和 End 为起止行号
+type auditChunk struct {
+	Path  string
+	Start int
+	End   int
+	Text  string
+}
+
+// Collect 遍历路径下的文件，跳过忽略规则命中的文件、二进制文件、依赖锁文件、生成代码和过大的文件，
+// 返回待审计的文件和被跳过的文件
+func (r *Reviewer) Collect(paths []string) ([]AuditFile, []string, error) {
+	root, err := filepath.Abs(RepoRoot())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		files   []AuditFile
+		skipped []string
+	)
+	seen := make(map[string]bool)
+	for _, p := range paths {
+		err := filepath.WalkDir(p, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if auditSkipDirs[d.Name()] && path != p {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+
+			name := relativePath(root, path)
+			if seen[name] {
+				return nil
+			}
+			seen[name] = true
+
+			if lockFiles[filepath.Base(name)] || r.ignore != nil && r.ignore.Match(name) {
+				skipped = append(skipped, name)
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			if info.Size() > MaxAuditFileSize {
+				skipped = append(skipped, name)
+				return nil
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if isBinary(data) || hasGeneratedHeader(strings.Split(string(data), "\n")) {
+				skipped = append(skipped, name)
+				return nil
+			}
+			files = append(files, AuditFile{Path: name, Content: string(data)})
+			return nil
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("读取 %s 失败: %w", p, err)
+		}
+	}
+	return files, skipped, nil
+}
+
+// relativePath 返回相对仓库根目录的路径，不在仓库中时返回清理后的原路径
+func relativePath(root, path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		if rel, err := filepath.Rel(root, abs); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(path))
+}
+
+// isBinary 与 git 相同，前 8000 个字节中包含 NUL 时视为二进制文件
+func isBinary(data []byte) bool {
+	if len(data) > 8000 {
+		data = data[:8000]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+// estimateTokens 按字节数 n 粗略估算 token 数，代码中平均每个 token 约 3 到 4 个字节
+func estimateTokens(n int) int {
+	return n/3 + 1
+}
+
+// batchFiles 为文件内容加上行号，按 token 预算分批；超出预算的文件按行拆分为多段
+func batchFiles(files []AuditFile, budget int) [][]auditChunk {
+	var chunks []auditChunk
+	for _, f := range files {
+		lines := strings.Split(strings.TrimSuffix(f.Content, "\n"), "\n")
+		width := len(fmt.Sprint(len(lines)))
+		var text strings.Builder
+		start := 1
+		for i, line := range lines {
+			numbered := fmt.Sprintf("%*d| %s\n", width, i+1, line)
+			if text.Len() > 0 && estimateTokens(text.Len()+len(numbered)) > budget {
+				chunks = append(chunks, auditChunk{Path: f.Path, Start: start, End: i, Text: text.String()})
+				text.Reset()
+				start = i + 1
+			}
+			text.WriteString(numbered)
+		}
+		chunks = append(chunks, auditChunk{Path: f.Path, Start: start, End: len(lines), Text: text.String()})
+	}
+
+	var (
+		batches [][]auditChunk
+		current []auditChunk
+		tokens  int
+	)
+	for _, c := range chunks {
+		n := estimateTokens(len(c.Text))
+		if len(current) > 0 && tokens+n > budget {
+			batches = append(batches, current)
+			current, tokens = nil, 0
+		}
+		current = append(current, c)
+		tokens += n
+	}
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+	return batches
+}
+
+// formatBatch 将一批文件内容格式化为发送给模型的内容
+func formatBatch(batch []auditChunk, total map[string]int) string {
+	var b strings.Builder
+	for _, c := range batch {
+		if c.Start == 1 && c.End == total[c.Path] {
+			fmt.Fprintf(&b, "文件 %s：\n```\n%s```\n\n", c.Path, c.Text)
+		} else {
+			fmt.Fprintf(&b, "文件 %s（第 %d-%d 行，共 %d 行）：\n```\n%s```\n\n", c.Path, c.Start, c.End, total[c.Path], c.Text)
+		}
+	}
+	return b.String()
+}
+
+// batchNames 返回一批中的文件名，同一文件的多段只列一次
+func batchNames(batch []auditChunk) []string {
+	var names []string
+	for i, c := range batch {
+		if i == 0 || batch[i-1].Path != c.Path {
+			names = append(names, c.Path)
+		}
+	}
+	return names
+}
+
+// Audit 审计完整的文件而不是 diff：按 token 预算将文件分批，使用审计模板逐批评审，
+// 合并为一份包含各文件问题和整体风险概述的评审结果。
+// skipped 为收集文件时跳过的文件，progress 不为空时在每批开始前调用
+func (r *Reviewer) Audit(files []AuditFile, skipped []string, progress func(batch, total int, names []string)) (*ReviewHistory, error) {
+	if err := r.validateConfig(); err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%w: 没有需要审计的文件", ErrEmptyDiff)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+
+	budget := r.config.Audit.TokenBudget
+	if budget <= 0 {
+		budget = 24000
+	}
+	templateName := r.config.Audit.Template
+	if templateName == "" {
+		templateName = "audit"
+	}
+
+	lineCount := make(map[string]int, len(files))
+	var all strings.Builder
+	for _, f := range files {
+		lineCount[f.Path] = strings.Count(strings.TrimSuffix(f.Content, "\n"), "\n") + 1
+		all.WriteString(f.Path + "\x00" + f.Content + "\x00")
+	}
+
+	var (
+		usage    TokenUsage
+		findings []Finding
+		details  strings.Builder
+	)
+	batches := batchFiles(files, budget)
+	for i, batch := range batches {
+		names := batchNames(batch)
+		if progress != nil {
+			progress(i+1, len(batches), names)
+		}
+		result, batchUsage, err := r.reviewContent(templateName, formatBatch(batch, lineCount))
+		if err != nil {
+			return nil, fmt.Errorf("审计第 %d 批失败: %w", i+1, err)
+		}
+		usage.Add(batchUsage)
+
+		result, batchFindings := parseFindings(result)
+		findings = append(findings, batchFindings...)
+		if len(batches) > 1 {
+			fmt.Fprintf(&details, "## 审计详情（第 %d/%d 批）\n\n> 文件：%s\n\n", i+1, len(batches), strings.Join(names, ", "))
+		} else {
+			details.WriteString("## 审计详情\n\n")
+		}
+		details.WriteString(result + "\n\n")
+	}
+
+	// 去除行内注释和基线忽略的问题，行号对应工作区中的文件
+	findings, suppressedCount := r.filterFindings(findings, nil)
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		return findings[i].Line < findings[j].Line
+	})
+
+	gitInfo, err := r.getGitInfo()
+	if err != nil {
+		log.Printf("获取 Git 信息失败: %v", err)
+	}
+	totalLines := 0
+	stats := &ReviewStats{
+		FilesChanged:   len(files),
+		FileChanges:    lineCount,
+		IssuesByLevel:  countBySeverity(findings),
+		IgnoredFiles:   skipped,
+		ReviewDateTime: time.Now(),
+	}
+	for _, f := range files {
+		totalLines += lineCount[f.Path]
+		if gitInfo != nil {
+			gitInfo.ChangedFiles = append(gitInfo.ChangedFiles, f.Path)
+		}
+	}
+
+	summary := riskSummary(findings, len(files), totalLines, len(batches))
+	hash := calculateHash(all.String())
+	return &ReviewHistory{
+		ID:           hash[:8],
+		GitInfo:      gitInfo,
+		ReviewStats:  stats,
+		ReviewResult: strings.TrimSpace(summary + "\n\n" + details.String()),
+		Findings:     findings,
+		Suppressed:   suppressedCount,
+		DiffHash:     hash,
+		Model:        r.config.ModelName,
+		Template:     templateName,
+		Usage:        &usage,
+		DateTime:     time.Now(),
+	}, nil
+}
+
+// riskLevel 根据最严重的问题给出风险等级
+func riskLevel(counts map[Severity]int) string {
+	switch {
+	case counts[SeverityHigh] > 0:
+		return "高"
+	case counts[SeverityMedium] > 0:
+		return "中"
+	default:
+		return "低"
+	}
+}
+
+// riskSummary 生成整体风险概述和按文件统计的问题表格，风险最高的文件排在前面
+func riskSummary(findings []Finding, files, lines, batches int) string {
+	total := make(map[Severity]int)
+	byFile := make(map[string]map[Severity]int)
+	for _, f := range findings {
+		total[f.Severity]++
+		if byFile[f.File] == nil {
+			byFile[f.File] = make(map[Severity]int)
+		}
+		byFile[f.File][f.Severity]++
+	}
+
+	var b strings.Builder
+	b.WriteString("## 整体风险\n\n")
+	fmt.Fprintf(&b, "- 风险等级：%s\n", riskLevel(total))
+	fmt.Fprintf(&b, "- 审计范围：%d 个文件，%d 行，分 %d 批审计\n", files, lines, batches)
+	fmt.Fprintf(&b, "- 问题数量：严重 %d 个，中等 %d 个，低 %d 个\n",
+		total[SeverityHigh], total[SeverityMedium], total[SeverityLow])
+	if len(byFile) == 0 {
+		return b.String()
+	}
+
+	names := make([]string, 0, len(byFile))
+	for name := range byFile {
+		names = append(names, name)
+	}
+	score := func(name string) int {
+		c := byFile[name]
+		return c[SeverityHigh]*100 + c[SeverityMedium]*10 + c[SeverityLow]
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if score(names[i]) != score(names[j]) {
+			return score(names[i]) > score(names[j])
+		}
+		return names[i] < names[j]
+	})
+
+	b.WriteString("\n| 文件 | 风险 | 严重 | 中等 | 低 |\n|------|------|------|------|------|\n")
+	for _, name := range names {
+		c := byFile[name]
+		fmt.Fprintf(&b, "| `%s` | %s | %d | %d | %d |\n", name, riskLevel(c), c[SeverityHigh], c[SeverityMedium], c[SeverityLow])
+	}
+	if rest := files - len(byFile); rest > 0 {
+		fmt.Fprintf(&b, "\n其余 %d 个文件未发现问题。\n", rest)
+	}
+	return b.String()
+}
@@ -0,0 +1,94 @@
+package review
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// BaselineEntry 基线中的问题
+type BaselineEntry struct {
+	Fingerprint string `json:"fingerprint"`
+	File        string `json:"file"`
+	Category    string `json:"category"`
+	Title       string `json:"title"`
+}
+
+// Baseline 已知问题基线，基线中的问题不会出现在后续报告中
+type Baseline struct {
+	Version  int             `json:"version"`
+	Findings []BaselineEntry `json:"findings"`
+
+	index map[string]bool
+}
+
+// LoadBaseline 读取基线文件，文件不存在时返回空基线
+func LoadBaseline(path string) (*Baseline, error) {
+	b := &Baseline{Version: 1}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return b, nil
+		}
+		return nil, fmt.Errorf("读取基线文件失败: %w", err)
+	}
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil, fmt.Errorf("解析基线文件失败: %w", err)
+	}
+	return b, nil
+}
+
+// Contains 检查问题是否在基线中
+func (b *Baseline) Contains(f Finding) bool {
+	if b == nil {
+		return false
+	}
+	if b.index == nil {
+		b.index = make(map[string]bool, len(b.Findings))
+		for _, entry := range b.Findings {
+			b.index[entry.Fingerprint] = true
+		}
+	}
+	return b.index[f.Fingerprint()]
+}
+
+// Add 将问题加入基线，返回新增的数量
+func (b *Baseline) Add(findings []Finding) int {
+	added := 0
+	for _, f := range findings {
+		if b.Contains(f) {
+			continue
+		}
+		fingerprint := f.Fingerprint()
+		b.Findings = append(b.Findings, BaselineEntry{
+			Fingerprint: fingerprint,
+			File:        f.File,
+			Category:    f.Category,
+			Title:       f.Title,
+		})
+		b.index[fingerprint] = true
+		added++
+	}
+	return added
+}
+
+// Save 保存基线文件，条目按文件和指纹排序，便于提交和比对
+func (b *Baseline) Save(path string) error {
+	sort.Slice(b.Findings, func(i, j int) bool {
+		if b.Findings[i].File != b.Findings[j].File {
+			return b.Findings[i].File < b.Findings[j].File
+		}
+		return b.Findings[i].Fingerprint < b.Findings[j].Fingerprint
+	})
+
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化基线失败: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("保存基线文件失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package review
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/icatw/cr-tool/pkg/config"
+	"github.com/icatw/cr-tool/pkg/diff"
+	"github.com/icatw/cr-tool/pkg/glob"
+)
+
+// MaxSummaryDiffSize 生成提交信息、PR 描述时发送给模型的 diff 大小上限，超出部分只列出文件的增删行数
+const MaxSummaryDiffSize = 64 << 10
+
+// CommitTypes Conventional Commits 中允许使用的类型
+var CommitTypes = []string{"feat", "fix", "docs", "style", "refactor", "perf", "test", "build", "ci", "chore", "revert"}
+
+// CommitMessage 符合 Conventional Commits 规范的提交信息
+type CommitMessage struct {
+	Type    string `json:"type"`
+	Scope   string `json:"scope,omitempty"`
+	Subject string `json:"subject"`
+	Body    string `json:"body,omitempty"`
+	// Breaking 不兼容改动的说明，为空表示没有不兼容的改动
+	Breaking string `json:"breaking,omitempty"`
+}
+
+// String 按 Conventional Commits 格式输出提交信息
+func (m *CommitMessage) String() string {
+	var b strings.Builder
+	b.WriteString(m.Type)
+	if m.Scope != "" {
+		b.WriteString("(" + m.Scope + ")")
+	}
+	if m.Breaking != "" {
+		b.WriteString("!")
+	}
+	b.WriteString(": " + m.Subject + "\n")
+	if m.Body != "" {
+		b.WriteString("\n" + m.Body + "\n")
+	}
+	if m.Breaking != "" {
+		b.WriteString("\nBREAKING CHANGE: " + m.Breaking + "\n")
+	}
+	return b.String()
+}
+
+// commitInstruction 要求模型输出结构化提交信息的提示词
+const commitInstruction = "\n\n请只输出一个语言标记为 cr-commit 的代码块，内容为 JSON 对象，例如：\n" +
+	"```cr-commit\n" +
+	`{"type": "feat", "scope": "review", "subject": "支持按路径选择评审模板", "body": "改动的原因和主要内容", "breaking": ""}` +
+	"\n```\n" +
+	"其中 type 只能是 feat、fix、docs、style、refactor、perf、test、build、ci、chore、revert；scope 为小写的模块名，涉及多个模块时留空；" +
+	"subject 不超过 50 个字符，不以句号结尾；body 每行不超过 72 个字符，改动很简单时留空；存在不兼容的改动时在 breaking 中说明，否则留空。"
+
+// commitLanguages 提交信息语言对应的提示词
+var commitLanguages = map[string]string{
+	"zh": "subject 和 body 使用中文。",
+	"en": "Write the subject and body in English, using the imperative mood (e.g. \"add\", not \"added\").",
+}
+
+// commitBlock 匹配模型回复中的结构化提交信息
+var commitBlock = regexp.MustCompile("(?s)```cr-commit[ \t]*\n(.*?)```")
+
+// Commit 根据暂存区的 diff 生成提交信息，language 为 zh 或 en，为空时使用配置
+func (r *Reviewer) Commit(diffContent, language string) (*CommitMessage, TokenUsage, error) {
+	if err := r.validateConfig(); err != nil {
+		return nil, TokenUsage{}, err
+	}
+	if strings.TrimSpace(diffContent) == "" {
+		return nil, TokenUsage{}, ErrEmptyDiff
+	}
+	if language == "" {
+		language = r.config.Commit.Language
+	}
+	if language == "" {
+		language = "zh"
+	}
+	instruction, ok := commitLanguages[language]
+	if !ok {
+		return nil, TokenUsage{}, fmt.Errorf("不支持的语言: %s", language)
+	}
+
+	files, _ := r.filterFiles(diff.Parse(diffContent))
+	if len(files) == 0 {
+		return nil, TokenUsage{}, fmt.Errorf("%w: 所有文件均已被忽略", ErrEmptyDiff)
+	}
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	scope := r.inferScope(names)
+
+	templateName := r.config.Commit.Template
+	if templateName == "" {
+		templateName = "commit"
+	}
+	prompt := r.template(templateName).SystemPrompt + commitInstruction + "\n" + instruction
+	if scope != "" {
+		prompt += fmt.Sprintf("\n根据变更的路径推断的 scope 为 %s，没有更合适的 scope 时直接使用。", scope)
+	}
+	result, usage, err := r.complete([]Message{
+		{Role: "system", Content: prompt},
+		{Role: "user", Content: summaryDiff(files, MaxSummaryDiffSize)},
+	})
+	if err != nil {
+		return nil, usage, err
+	}
+
+	match := commitBlock.FindStringSubmatch(result)
+	if match == nil {
+		return nil, usage, errors.New("模型没有给出提交信息")
+	}
+	var msg CommitMessage
+	if err := json.Unmarshal([]byte(match[1]), &msg); err != nil {
+		return nil, usage, fmt.Errorf("解析提交信息失败: %w", err)
+	}
+	msg.Type = strings.ToLower(strings.TrimSpace(msg.Type))
+	if !slices.Contains(CommitTypes, msg.Type) {
+		return nil, usage, fmt.Errorf("无效的提交类型: %s", msg.Type)
+	}
+	msg.Subject = strings.TrimRight(strings.TrimSpace(msg.Subject), "。.")
+	if msg.Subject == "" {
+		return nil, usage, errors.New("模型没有给出提交标题")
+	}
+	msg.Scope = strings.ToLower(strings.TrimSpace(msg.Scope))
+	if msg.Scope == "" {
+		msg.Scope = scope
+	}
+	msg.Body = strings.TrimSpace(msg.Body)
+	msg.Breaking = strings.TrimSpace(msg.Breaking)
+	return &msg, usage, nil
+}
+
+// summaryDiff 拼接发送给模型的 diff，超出大小的文件只列出增删行数
+func summaryDiff(files []*diff.File, limit int) string {
+	var b, omitted strings.Builder
+	for _, f := range files {
+		if b.Len()+len(f.Raw) <= limit {
+			b.WriteString(f.Raw)
+			if !strings.HasSuffix(f.Raw, "\n") {
+				b.WriteString("\n")
+			}
+			continue
+		}
+		added, deleted := 0, 0
+		for _, h := range f.Hunks {
+			for _, l := range h.Lines {
+				switch l.Kind {
+				case diff.Added:
+					added++
+				case diff.Deleted:
+					deleted++
+				}
+			}
+		}
+		fmt.Fprintf(&omitted, "%s（+%d -%d）\n", f.Name(), added, deleted)
+	}
+	if omitted.Len() > 0 {
+		b.WriteString("\n以下文件的改动过大，只列出增删行数：\n" + omitted.String())
+	}
+	return b.String()
+}
+
+// genericDirs 推断 scope 时跳过的通用目录名
+var genericDirs = map[string]bool{
+	"pkg": true, "cmd": true, "internal": true, "src": true, "lib": true,
+	"app": true, "apps": true, "packages": true, "modules": true,
+}
+
+// inferScope 根据变更文件的路径推断 scope：优先使用 commit.scopes 中的规则，
+// 否则取路径中第一个非通用的目录名；所有文件的 scope 一致时才返回
+func (r *Reviewer) inferScope(names []string) string {
+	scope := ""
+	for i, name := range names {
+		s := r.scopeOf(name)
+		if s == "" || (i > 0 && s != scope) {
+			return ""
+		}
+		scope = s
+	}
+	return scope
+}
+
+// scopeOf 推断单个文件的 scope
+func (r *Reviewer) scopeOf(name string) string {
+	for _, rule := range r.config.Commit.Scopes {
+		if rule.Scope != "" && matchScope(rule, name) {
+			return rule.Scope
+		}
+	}
+	dirs := strings.Split(path.Dir(name), "/")
+	for _, dir := range dirs {
+		if dir != "." && !genericDirs[dir] {
+			return strings.ToLower(dir)
+		}
+	}
+	return ""
+}
+
+// matchScope 判断文件是否命中 scope 规则，不含 / 的路径模式只匹配文件名
+func matchScope(rule config.CommitScope, name string) bool {
+	for _, pattern := range rule.Paths {
+		target := name
+		if !strings.Contains(pattern, "/") {
+			target = path.Base(name)
+		}
+		if glob.Match(pattern, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package review
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/icatw/cr-tool/pkg/diff"
+)
+
+// Describe 根据 PR 的 diff 和提交记录生成 Markdown 格式的 PR 描述：
+// 概述、动机、按模块分组的改动列表、风险评估和测试说明
+func (r *Reviewer) Describe(diffContent, commits string) (string, TokenUsage, error) {
+	if err := r.validateConfig(); err != nil {
+		return "", TokenUsage{}, err
+	}
+	if strings.TrimSpace(diffContent) == "" {
+		return "", TokenUsage{}, ErrEmptyDiff
+	}
+	files, _ := r.filterFiles(diff.Parse(diffContent))
+	if len(files) == 0 {
+		return "", TokenUsage{}, fmt.Errorf("%w: 所有文件均已被忽略", ErrEmptyDiff)
+	}
+
+	var b strings.Builder
+	if strings.TrimSpace(commits) != "" {
+		fmt.Fprintf(&b, "提交记录：\n%s\n\n", strings.TrimSpace(commits))
+	}
+	b.WriteString("代码变更：\n" + summaryDiff(files, MaxSummaryDiffSize))
+	return r.generate("describe", b.String())
+}
+
+// ReleaseNotes 根据提交记录和评审摘要生成 Markdown 格式的发布说明
+func (r *Reviewer) ReleaseNotes(commits, reviews string) (string, TokenUsage, error) {
+	if err := r.validateConfig(); err != nil {
+		return "", TokenUsage{}, err
+	}
+	if strings.TrimSpace(commits) == "" {
+		return "", TokenUsage{}, errors.New("范围内没有提交")
+	}
+
+	content := "提交记录：\n" + strings.TrimSpace(commits)
+	if strings.TrimSpace(reviews) != "" {
+		content += "\n\n代码评审摘要：\n" + strings.TrimSpace(reviews)
+	}
+	return r.generate("release-notes", content)
+}
+
+// generate 使用指定模板生成文档，去掉模型包裹在外层的 markdown 代码块
+func (r *Reviewer) generate(templateName, content string) (string, TokenUsage, error) {
+	result, usage, err := r.complete([]Message{
+		{Role: "system", Content: r.template(templateName).SystemPrompt},
+		{Role: "user", Content: content},
+	})
+	if err != nil {
+		return "", usage, err
+	}
+	result = strings.TrimSpace(result)
+	if strings.HasPrefix(result, "```markdown\n") && strings.HasSuffix(result, "```") {
+		result = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(result, "```markdown\n"), "```"))
+	}
+	return result, usage, nil
+}
@@ -0,0 +1,151 @@
+package review
+
+import (
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/icatw/cr-tool/pkg/diff"
+	"github.com/icatw/cr-tool/pkg/ignore"
+)
+
+// lockFiles 依赖锁文件，内容由工具生成，无需评审
+var lockFiles = map[string]bool{
+	"go.sum":              true,
+	"package-lock.json":   true,
+	"yarn.lock":           true,
+	"pnpm-lock.yaml":      true,
+	"npm-shrinkwrap.json": true,
+	"Cargo.lock":          true,
+	"Gemfile.lock":        true,
+	"poetry.lock":         true,
+	"Pipfile.lock":        true,
+	"composer.lock":       true,
+	"mix.lock":            true,
+	"pubspec.lock":        true,
+	"Podfile.lock":        true,
+}
+
+// generatedPattern 生成代码的标记，参见 https://go.dev/s/generatedcode，
+// 除 Go 的 // 外也接受其他语言常用的 #、-- 和 /* 注释
+var generatedPattern = regexp.MustCompile(`^(//|#|--|/\*) Code generated .* DO NOT EDIT\.( \*/)?$`)
+
+// maxHeaderSize 从工作区读取文件头部时的大小上限
+const maxHeaderSize = 8 << 10
+
+// newIgnoreMatcher 合并配置中的忽略规则与仓库根目录下的忽略文件
+func newIgnoreMatcher(patterns []string, ignoreFile string) *ignore.Matcher {
+	m := ignore.New(patterns)
+	if ignoreFile == "" {
+		return m
+	}
+
+	if !filepath.IsAbs(ignoreFile) {
+		ignoreFile = filepath.Join(RepoRoot(), ignoreFile)
+	}
+	filePatterns, err := ignore.ReadFile(ignoreFile)
+	if err != nil {
+		// 仅记录错误，不影响主流程
+		log.Printf("%v", err)
+		return m
+	}
+	m.Add(filePatterns...)
+	return m
+}
+
+// RepoRoot 获取仓库根目录，不在 Git 仓库中时使用当前目录
+func RepoRoot() string {
+	output, err := exec.Command("git", "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return "."
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// filterFiles 去除被忽略的文件，返回保留的文件和被忽略的文件名
+func (r *Reviewer) filterFiles(files []*diff.File) ([]*diff.File, []string) {
+	kept := make([]*diff.File, 0, len(files))
+	var ignored []string
+	for _, f := range files {
+		if r.shouldIgnoreFile(f) {
+			ignored = append(ignored, f.Name())
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept, ignored
+}
+
+// shouldIgnoreFile 检查是否应该忽略文件
+// 除忽略规则外，二进制文件、依赖锁文件和生成代码也会被跳过
+func (r *Reviewer) shouldIgnoreFile(f *diff.File) bool {
+	name := f.Name()
+	if name == "" {
+		return false
+	}
+	if f.Binary || lockFiles[filepath.Base(name)] {
+		return true
+	}
+	if r.ignore != nil && r.ignore.Match(name) {
+		return true
+	}
+	return r.isGenerated(f)
+}
+
+// isGenerated 检查文件是否为生成代码。第一个变更块从文件开头开始时检查变更块中的内容
+// （删除的文件检查旧内容），否则从工作区读取文件头部，不读取工作区时只检查变更块
+func (r *Reviewer) isGenerated(f *diff.File) bool {
+	if len(f.Hunks) == 0 {
+		return false
+	}
+	h := f.Hunks[0]
+	skip, start := diff.Deleted, h.NewStart
+	if f.IsDeleted() {
+		skip, start = diff.Added, h.OldStart
+	}
+	if start <= 1 {
+		var lines []string
+		for _, l := range h.Lines {
+			if l.Kind != skip {
+				lines = append(lines, l.Content)
+			}
+		}
+		if hasGeneratedHeader(lines) {
+			return true
+		}
+	}
+	if f.IsDeleted() || r.root == "" {
+		return false
+	}
+	return hasGeneratedHeader(readHeader(filepath.Join(r.root, filepath.FromSlash(f.Name()))))
+}
+
+// readHeader 读取文件开头的若干行，读取失败时返回空
+func readHeader(path string) []string {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+	buf := make([]byte, maxHeaderSize)
+	n, _ := io.ReadFull(file, buf)
+	return strings.Split(string(buf[:n]), "\n")
+}
+
+// hasGeneratedHeader 检查 package 子句之前的行中是否有生成代码的标记
+func hasGeneratedHeader(lines []string) bool {
+	for _, line := range lines {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.HasPrefix(line, "package ") {
+			return false
+		}
+		if generatedPattern.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
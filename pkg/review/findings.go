@@ -0,0 +1,197 @@
+package review
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/icatw/cr-tool/pkg/diff"
+)
+
+// Severity 问题级别
+type Severity string
+
+const (
+	SeverityHigh   Severity = "严重"
+	SeverityMedium Severity = "中等"
+	SeverityLow    Severity = "低"
+)
+
+// severityAliases 问题级别的别名
+var severityAliases = map[string]Severity{
+	"严重":       SeverityHigh,
+	"高":        SeverityHigh,
+	"critical": SeverityHigh,
+	"high":     SeverityHigh,
+	"error":    SeverityHigh,
+	"中等":       SeverityMedium,
+	"中":        SeverityMedium,
+	"medium":   SeverityMedium,
+	"major":    SeverityMedium,
+	"warning":  SeverityMedium,
+	"低":        SeverityLow,
+	"low":      SeverityLow,
+	"minor":    SeverityLow,
+	"info":     SeverityLow,
+}
+
+// ParseSeverity 解析问题级别，支持中英文别名
+func ParseSeverity(s string) (Severity, bool) {
+	severity, ok := severityAliases[strings.ToLower(strings.TrimSpace(s))]
+	return severity, ok
+}
+
+// Rank 返回级别的数值，越大越严重，未知级别为 0
+func (s Severity) Rank() int {
+	switch s {
+	case SeverityHigh:
+		return 3
+	case SeverityMedium:
+		return 2
+	case SeverityLow:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Fingerprint 问题指纹，由文件、类别和标题计算，不包含行号，代码移动后依然稳定
+func (f Finding) Fingerprint() string {
+	title := strings.Join(strings.Fields(strings.ToLower(f.Title)), " ")
+	return calculateHash(f.File + "\x00" + strings.ToLower(f.Category) + "\x00" + title)[:16]
+}
+
+// Location 返回问题位置，如 main.go:12
+func (f Finding) Location() string {
+	if f.Line > 0 {
+		return fmt.Sprintf("%s:%d", f.File, f.Line)
+	}
+	return f.File
+}
+
+// Categories 提示词中约定的问题类别
+var Categories = []string{"security", "performance", "correctness", "maintainability", "style"}
+
+// findingsInstruction 要求模型输出结构化问题列表的提示词
+const findingsInstruction = "\n\n在评审结果的最后，请附加一个语言标记为 cr-findings 的代码块，以 JSON 数组列出所有发现的问题，例如：\n" +
+	"```cr-findings\n" +
+	`[{"file": "main.go", "line": 12, "severity": "严重", "category": "security", "title": "SQL 注入风险", "detail": "问题说明", "suggestion": "修改建议"}]` +
+	"\n```\n" +
+	"其中 severity 只能是 严重、中等、低；category 使用小写英文单词，如 security、performance、correctness、maintainability、style；line 为变更后文件中的行号。" +
+	"如果可以直接给出修复后的代码，请附加 end_line 和 fix 字段，fix 为替换 line 到 end_line 整行内容的完整代码（保留缩进），不确定时省略。没有发现问题时输出空数组 []。"
+
+// findingsBlock 匹配评审结果中的结构化问题列表
+var findingsBlock = regexp.MustCompile("(?s)```cr-findings[ \t]*\n(.*?)```[ \t]*\n?")
+
+// parseFindings 从评审结果中提取结构化问题列表，并返回去掉代码块后的评审结果
+func parseFindings(result string) (string, []Finding) {
+	var findings []Finding
+	for _, match := range findingsBlock.FindAllStringSubmatch(result, -1) {
+		var items []Finding
+		if err := json.Unmarshal([]byte(match[1]), &items); err != nil {
+			log.Printf("解析问题列表失败: %v", err)
+			continue
+		}
+		for _, item := range items {
+			if severity, ok := ParseSeverity(string(item.Severity)); ok {
+				item.Severity = severity
+			}
+			item.Category = strings.ToLower(strings.TrimSpace(item.Category))
+			findings = append(findings, item)
+		}
+	}
+
+	result = findingsBlock.ReplaceAllString(result, "")
+	return strings.TrimSpace(result), findings
+}
+
+// suppressDirective 匹配行内忽略注释，如 cr:ignore 或 cr:ignore-next-line security
+var suppressDirective = regexp.MustCompile(`cr:ignore(-next-line)?(?:[ \t]+([\w,-]+))?`)
+
+// suppressed 检查问题是否被行内注释忽略
+// cr:ignore 作用于所在行，cr:ignore-next-line 作用于下一行，可选的类别用逗号分隔
+func suppressed(f Finding, lineAt func(int) (string, bool)) bool {
+	if f.Line <= 0 {
+		return false
+	}
+
+	check := func(line int, nextLine bool) bool {
+		content, ok := lineAt(line)
+		if !ok {
+			return false
+		}
+		for _, m := range suppressDirective.FindAllStringSubmatch(content, -1) {
+			if (m[1] != "") != nextLine {
+				continue
+			}
+			if m[2] == "" {
+				return true
+			}
+			for _, category := range strings.Split(m[2], ",") {
+				if strings.EqualFold(category, f.Category) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	return check(f.Line, false) || check(f.Line-1, true)
+}
+
+// filterFindings 去除被行内注释或基线忽略的问题，返回保留的问题和被忽略的数量
+func (r *Reviewer) filterFindings(findings []Finding, files []*diff.File) ([]Finding, int) {
+	byName := make(map[string]*diff.File, len(files))
+	for _, f := range files {
+		byName[f.Name()] = f
+	}
+
+	kept := make([]Finding, 0, len(findings))
+	count := 0
+	for _, f := range findings {
+		if r.baseline.Contains(f) || suppressed(f, sourceLines(byName[f.File], r.root, f.File)) {
+			count++
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept, count
+}
+
+// sourceLines 返回按行号读取源码的函数，优先使用 diff 中的内容，否则读取 root 下的工作区文件，
+// root 为空时只使用 diff 中的内容
+func sourceLines(file *diff.File, root, name string) func(int) (string, bool) {
+	var lines []string
+	loaded := false
+
+	return func(n int) (string, bool) {
+		if file != nil {
+			if l, ok := file.LineAt(n); ok {
+				return l.Content, true
+			}
+		}
+		if !loaded && root != "" {
+			loaded = true
+			if data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(name))); err == nil {
+				lines = strings.Split(string(data), "\n")
+			}
+		}
+		if n <= 0 || n > len(lines) {
+			return "", false
+		}
+		return lines[n-1], true
+	}
+}
+
+// countBySeverity 按级别统计问题数量
+func countBySeverity(findings []Finding) map[string]int {
+	counts := make(map[string]int)
+	for _, f := range findings {
+		counts[string(f.Severity)]++
+	}
+	return counts
+}
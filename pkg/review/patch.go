@@ -0,0 +1,55 @@
+package review
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// patchPrompt 要求模型以 unified diff 给出修复的系统提示词
+const patchPrompt = `你是一名资深工程师，需要根据代码评审发现的问题修改代码。
+请只输出一个语言标记为 diff 的代码块，内容为可以用 git apply 应用的 unified diff：
+- 只修改给出的文件，文件头使用 --- a/<路径> 和 +++ b/<路径>，路径与给出的文件路径一致
+- 每个变更块保留 3 行上下文，上下文和删除行必须与给出的文件内容完全一致，保留原有缩进
+- 只做修复该问题所需的最小改动，不要修改无关代码
+无法给出可靠的修复时，输出空的 diff 代码块。`
+
+// patchBlock 匹配模型回复中的 diff 代码块
+var patchBlock = regexp.MustCompile("(?s)```(?:diff|patch)[ \t]*\n(.*?)```")
+
+// Patch 请求模型为问题生成修复补丁，content 为问题所在文件的当前内容，返回 unified diff
+func (r *Reviewer) Patch(f Finding, content string) (string, TokenUsage, error) {
+	if err := r.validateConfig(); err != nil {
+		return "", TokenUsage{}, err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "问题：%s\n位置：%s\n级别：%s\n", f.Title, f.Location(), f.Severity)
+	if f.EndLine > f.Line {
+		fmt.Fprintf(&b, "涉及第 %d 到 %d 行\n", f.Line, f.EndLine)
+	}
+	if f.Detail != "" {
+		fmt.Fprintf(&b, "说明：%s\n", f.Detail)
+	}
+	if f.Suggestion != "" {
+		fmt.Fprintf(&b, "建议：%s\n", f.Suggestion)
+	}
+	if f.Fix != "" {
+		fmt.Fprintf(&b, "评审时给出的修复代码：\n```\n%s\n```\n", strings.TrimRight(f.Fix, "\n"))
+	}
+	fmt.Fprintf(&b, "\n文件 %s 的当前内容：\n```\n%s\n```", f.File, strings.TrimRight(content, "\n"))
+
+	result, usage, err := r.complete([]Message{
+		{Role: "system", Content: patchPrompt},
+		{Role: "user", Content: b.String()},
+	})
+	if err != nil {
+		return "", usage, err
+	}
+	match := patchBlock.FindStringSubmatch(result)
+	if match == nil || strings.TrimSpace(match[1]) == "" {
+		return "", usage, errors.New("模型没有给出补丁")
+	}
+	return match[1], usage, nil
+}
@@ -6,27 +6,66 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/icatw/cr-tool/pkg/config"
+	"github.com/icatw/cr-tool/pkg/diff"
+	"github.com/icatw/cr-tool/pkg/ignore"
 )
 
 // Reviewer 代码评审器
 type Reviewer struct {
-	config *config.Config
-	cache  *Cache
+	config   *config.Config
+	cache    *Cache
+	ignore   *ignore.Matcher
+	baseline *Baseline
+	// root 仓库根目录，用于读取工作区中的文件，为空时不读取
+	root string
 }
 
 // New 创建新的评审器
 func New() *Reviewer {
+	cfg := config.Get()
+	r := &Reviewer{
+		config: cfg,
+		cache:  NewCache(),
+		root:   RepoRoot(),
+		ignore: newIgnoreMatcher(cfg.Review.IgnorePatterns, cfg.Review.IgnoreFile),
+	}
+
+	baseline, err := LoadBaseline(r.BaselinePath())
+	if err != nil {
+		// 仅记录错误，不影响主流程
+		log.Printf("%v", err)
+	}
+	r.baseline = baseline
+	return r
+}
+
+// NewRemote 创建评审其他仓库改动的评审器，用于 PR/MR 评审和服务模式：
+// 不读取本地的忽略文件、基线和工作区，行内忽略注释只在 diff 中查找
+func NewRemote() *Reviewer {
+	cfg := config.Get()
 	return &Reviewer{
-		config: config.Get(),
+		config: cfg,
 		cache:  NewCache(),
+		ignore: ignore.New(cfg.Review.IgnorePatterns),
 	}
 }
 
+// BaselinePath 返回基线文件路径，相对路径基于仓库根目录
+func (r *Reviewer) BaselinePath() string {
+	path := r.config.Review.BaselineFile
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(RepoRoot(), path)
+}
+
 // 添加错误定义
 var (
 	ErrEmptyDiff     = errors.New("空的 diff 内容")
@@ -59,62 +98,118 @@ func (r *Reviewer) Review(diffContent string) (*ReviewHistory, error) {
 	if strings.TrimSpace(diffContent) == "" {
 		return nil, ErrEmptyDiff
 	}
+
+	// 去除被忽略的文件，避免其内容发送给模型
+	files, ignored := r.filterFiles(diff.Parse(diffContent))
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%w: 所有文件均已被忽略", ErrEmptyDiff)
+	}
+	if len(ignored) > 0 {
+		diffContent = diff.Join(files)
+	}
 	if len(diffContent) > r.config.Review.MaxDiffSize {
 		return nil, fmt.Errorf("%w: %d > %d bytes",
 			ErrDiffTooLarge, len(diffContent), r.config.Review.MaxDiffSize)
 	}
 
+	// 按路由规则分组，每组使用匹配的模板评审
+	var (
+		usage     TokenUsage
+		templates []string
+		merged    strings.Builder
+	)
+	groups := r.routeFiles(files)
+	for i, g := range groups {
+		content := diffContent
+		if len(groups) > 1 {
+			content = diff.Join(g.Files)
+		}
+		result, groupUsage, err := r.reviewContent(g.Template, content)
+		if err != nil {
+			if len(groups) > 1 {
+				return nil, fmt.Errorf("评审分组 %s 失败: %w", g.Template, err)
+			}
+			return nil, err
+		}
+		usage.Add(groupUsage)
+		templates = append(templates, g.Template)
+
+		if len(groups) > 1 {
+			if i > 0 {
+				merged.WriteString("\n\n")
+			}
+			merged.WriteString(fmt.Sprintf("# 评审分组：%s\n\n> 文件：%s\n\n", g.Template, strings.Join(g.Names(), ", ")))
+		}
+		merged.WriteString(result)
+	}
+
+	history, err := r.createHistory(diffContent, merged.String(), ignored)
+	if err != nil {
+		return nil, err
+	}
+	history.Model = r.config.ModelName
+	history.Template = strings.Join(templates, ",")
+	history.Usage = &usage
+	return history, nil
+}
+
+// reviewContent 使用指定模板评审内容，优先读取缓存
+func (r *Reviewer) reviewContent(templateName, content string) (string, TokenUsage, error) {
+	key := templateName + "\n" + content
+
 	// 检查缓存
-	if result := r.cache.Get(diffContent); result != "" {
-		return r.createHistory(diffContent, result)
+	if result := r.cache.Get(key); result != "" {
+		return result, TokenUsage{}, nil
 	}
 
 	// 执行评审
-	result, err := r.performReview(diffContent)
+	result, usage, err := r.performReview(templateName, content)
 	if err != nil {
-		return nil, err
+		return "", usage, err
 	}
 
 	// 保存缓存
-	if err := r.cache.Set(diffContent, result); err != nil {
+	if err := r.cache.Set(key, result); err != nil {
 		// 仅记录错误，不影响主流程
-		fmt.Printf("保存缓存失败: %v\n", err)
+		log.Printf("保存缓存失败: %v", err)
 	}
 
-	return r.createHistory(diffContent, result)
+	return result, usage, nil
 }
 
 // performReview 执行实际的评审请求
-func (r *Reviewer) performReview(diffContent string) (string, error) {
+func (r *Reviewer) performReview(templateName, diffContent string) (string, TokenUsage, error) {
 	// 获取模板
-	template, ok := r.config.Review.Templates[r.config.Review.Template]
-	if !ok {
-		template = r.config.Review.Templates["default"]
-	}
+	template := r.template(templateName)
 
-	payload := RequestBody{
-		Model: r.config.ModelName,
-		Messages: []Message{
-			{
-				Role:    "system",
-				Content: template.SystemPrompt,
-			},
-			{
-				Role:    "user",
-				Content: diffContent,
-			},
+	return r.complete([]Message{
+		{
+			Role:    "system",
+			Content: template.SystemPrompt + findingsInstruction,
+		},
+		{
+			Role:    "user",
+			Content: diffContent,
 		},
+	})
+}
+
+// complete 发送对话请求，返回模型的回复和 token 用量
+func (r *Reviewer) complete(messages []Message) (string, TokenUsage, error) {
+	payload := RequestBody{
+		Model:    r.config.ModelName,
+		Messages: messages,
 	}
 
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
-		return "", fmt.Errorf("序列化请求失败: %w", err)
+		return "", TokenUsage{}, fmt.Errorf("序列化请求失败: %w", err)
 	}
 
 	// 发送请求
 	req, err := http.NewRequest("POST", r.config.BaseURL, bytes.NewBuffer(payloadBytes))
 	if err != nil {
-		return "", fmt.Errorf("创建请求失败: %w", err)
+		return "", TokenUsage{}, fmt.Errorf("创建请求失败: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -126,46 +221,68 @@ func (r *Reviewer) performReview(diffContent string) (string, error) {
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("发送请求失败: %w", err)
+		return "", TokenUsage{}, fmt.Errorf("发送请求失败: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("请求失败，状态码: %d", resp.StatusCode)
+		return "", TokenUsage{}, fmt.Errorf("请求失败，状态码: %d", resp.StatusCode)
 	}
 
 	var result ResponseBody
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("解析响应失败: %w", err)
+		return "", TokenUsage{}, fmt.Errorf("解析响应失败: %w", err)
 	}
 
 	if len(result.Choices) == 0 {
-		return "", fmt.Errorf("未获取到评审结果")
+		return "", TokenUsage{}, fmt.Errorf("未获取到评审结果")
 	}
 
-	return result.Choices[0].Message.Content, nil
+	return result.Choices[0].Message.Content, result.Usage, nil
 }
 
 // createHistory 创建评审历史记录
-func (r *Reviewer) createHistory(diffContent, result string) (*ReviewHistory, error) {
+func (r *Reviewer) createHistory(diffContent, result string, ignored []string) (*ReviewHistory, error) {
 	// 获取 Git 信息
 	gitInfo, err := r.getGitInfo()
 	if err != nil {
 		// 记录错误但继续执行
-		fmt.Printf("获取 Git 信息失败: %v\n", err)
+		log.Printf("获取 Git 信息失败: %v", err)
+	}
+
+	files := diff.Parse(diffContent)
+	if gitInfo != nil {
+		for _, f := range files {
+			if name := f.Name(); name != "" {
+				gitInfo.ChangedFiles = append(gitInfo.ChangedFiles, name)
+			}
+		}
 	}
 
+	// 提取结构化问题，并去除行内注释和基线忽略的问题
+	result, findings := parseFindings(result)
+	findings, suppressedCount := r.filterFindings(findings, files)
+
 	// 分析统计信息
 	stats, err := r.analyzeStats(diffContent, result)
 	if err != nil {
-		fmt.Printf("分析统计信息失败: %v\n", err)
+		log.Printf("分析统计信息失败: %v", err)
+	} else {
+		stats.IgnoredFiles = ignored
+		if len(findings) > 0 || suppressedCount > 0 {
+			stats.IssuesByLevel = countBySeverity(findings)
+		}
 	}
 
+	hash := calculateHash(diffContent)
 	return &ReviewHistory{
-		ID:           calculateHash(diffContent)[:8],
+		ID:           hash[:8],
 		GitInfo:      gitInfo,
 		ReviewStats:  stats,
 		ReviewResult: result,
+		Findings:     findings,
+		Suppressed:   suppressedCount,
+		DiffHash:     hash,
 		DateTime:     time.Now(),
 	}, nil
 }
@@ -1,10 +1,77 @@
 package review
 
 import (
-	"github.com/stretchr/testify/assert"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
+
+	"github.com/icatw/cr-tool/pkg/config"
+	"github.com/icatw/cr-tool/pkg/diff"
+	"github.com/stretchr/testify/assert"
 )
 
+func TestMain(m *testing.M) {
+	config.Set(testConfig())
+	os.Exit(m.Run())
+}
+
+// testConfig 返回测试使用的配置
+func testConfig() *config.Config {
+	return &config.Config{
+		APIKey:    "test_key",
+		ModelName: "test_model",
+		BaseURL:   "http://127.0.0.1:0",
+		Review: config.ReviewConfig{
+			Template:    "default",
+			MaxDiffSize: 2000,
+		},
+	}
+}
+
+// newTestServer 创建模拟的模型接口，返回系统提示词的第一行作为评审结果
+func newTestServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body RequestBody
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			t.Errorf("解析请求失败: %v", err)
+		}
+		prompt := strings.SplitN(body.Messages[0].Content, "。", 2)[0]
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{
+				{"message": map[string]string{"role": "assistant", "content": prompt + "\n" + body.Messages[1].Content}},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newModelServer 创建模拟的模型接口并设置为当前配置，测试结束后恢复，
+// reply 根据请求返回模型的回复。返回的配置可以在创建 Reviewer 之前继续修改
+func newModelServer(t *testing.T, reply func(body RequestBody) string) *config.Config {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body RequestBody
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			t.Errorf("解析请求失败: %v", err)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{
+				{"message": map[string]string{"role": "assistant", "content": reply(body)}},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := testConfig()
+	cfg.BaseURL = server.URL
+	config.Set(cfg)
+	t.Cleanup(func() { config.Set(testConfig()) })
+	return cfg
+}
+
 func TestReview(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -32,3 +99,396 @@ func TestReview(t *testing.T) {
 		})
 	}
 }
+
+func TestReviewRoutes(t *testing.T) {
+	server := newTestServer(t)
+
+	cfg := testConfig()
+	cfg.BaseURL = server.URL
+	cfg.Review.Routes = []config.TemplateRoute{
+		{Paths: []string{"auth/**"}, Template: "security"},
+		{Paths: []string{"migrations/*.sql"}, Template: "sql"},
+	}
+	config.Set(cfg)
+	t.Cleanup(func() { config.Set(testConfig()) })
+
+	diffContent := `diff --git a/auth/token/jwt.go b/auth/token/jwt.go
+@@ -1 +1 @@
+-old
++new
+diff --git a/main.go b/main.go
+@@ -1 +1 @@
+-a
++b
+diff --git a/migrations/001_init.sql b/migrations/001_init.sql
+@@ -0,0 +1 @@
++CREATE TABLE t (id INT);
+`
+	history, err := New().Review(diffContent)
+	assert.NoError(t, err)
+
+	result := history.ReviewResult
+	assert.Contains(t, result, "# 评审分组：default")
+	assert.Contains(t, result, "# 评审分组：security\n\n> 文件：auth/token/jwt.go")
+	assert.Contains(t, result, "# 评审分组：sql\n\n> 文件：migrations/001_init.sql")
+	assert.Contains(t, result, "你是一个资深的应用安全专家")
+	assert.Less(t, strings.Index(result, "评审分组：default"), strings.Index(result, "评审分组：security"))
+	assert.Equal(t, 3, history.ReviewStats.FilesChanged)
+}
+
+func TestMatchRoute(t *testing.T) {
+	tests := []struct {
+		route    config.TemplateRoute
+		filename string
+		want     bool
+	}{
+		{config.TemplateRoute{Paths: []string{"*.sql"}}, "db/migrations/1.sql", true},
+		{config.TemplateRoute{Paths: []string{"migrations/*.sql"}}, "db/migrations/1.sql", false},
+		{config.TemplateRoute{Languages: []string{"terraform"}}, "infra/main.tf", true},
+		{config.TemplateRoute{Paths: []string{"web/**"}, Languages: []string{"vue"}}, "web/src/App.vue", true},
+		{config.TemplateRoute{Paths: []string{"web/**"}, Languages: []string{"vue"}}, "web/src/main.ts", false},
+		{config.TemplateRoute{}, "main.go", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, matchRoute(tt.route, tt.filename), tt.filename)
+	}
+}
+
+func TestReviewIgnoredFiles(t *testing.T) {
+	server := newTestServer(t)
+
+	cfg := testConfig()
+	cfg.BaseURL = server.URL
+	cfg.Review.IgnorePatterns = []string{"vendor/"}
+	config.Set(cfg)
+	t.Cleanup(func() { config.Set(testConfig()) })
+
+	diffContent := `diff --git a/vendor/lib/a.go b/vendor/lib/a.go
+@@ -1 +1 @@
+-secret_old
++secret_new
+diff --git a/api/user.pb.go b/api/user.pb.go
+@@ -0,0 +1 @@
++// Code generated by protoc-gen-go. DO NOT EDIT.
+diff --git a/go.sum b/go.sum
+@@ -1 +1 @@
+-x v1 h1:a
++x v2 h1:b
+diff --git a/logo.png b/logo.png
+Binary files a/logo.png and b/logo.png differ
+diff --git a/main.go b/main.go
+@@ -1 +1 @@
+-a
++b
+`
+	history, err := New().Review(diffContent)
+	assert.NoError(t, err)
+	assert.NotContains(t, history.ReviewResult, "secret_new")
+	assert.Contains(t, history.ReviewResult, "main.go")
+	assert.Equal(t, 1, history.ReviewStats.FilesChanged)
+	assert.ElementsMatch(t, []string{"vendor/lib/a.go", "api/user.pb.go", "go.sum", "logo.png"}, history.ReviewStats.IgnoredFiles)
+
+	_, err = New().Review("diff --git a/go.sum b/go.sum\n@@ -1 +1 @@\n-a\n+b\n")
+	assert.ErrorIs(t, err, ErrEmptyDiff)
+}
+
+func TestIsGenerated(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(dir+"/user.pb.go", []byte("// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage api\n"), 0644))
+	assert.NoError(t, os.WriteFile(dir+"/main.go", []byte("package main\n\n// Code generated by x. DO NOT EDIT.\n"), 0644))
+	wd, _ := os.Getwd()
+	assert.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	tests := []struct {
+		diff string
+		want bool
+	}{
+		{"diff --git a/a.go b/a.go\n@@ -0,0 +1,2 @@\n+// Code generated by stringer. DO NOT EDIT.\n+package a\n", true},
+		{"diff --git a/a.go b/a.go\n@@ -0,0 +1,3 @@\n+// Copyright 2024\n+\n+// Code generated by mockgen. DO NOT EDIT.\n", true},
+		{"diff --git a/a.go b/a.go\n@@ -0,0 +1,2 @@\n+package a\n+// Code generated by stringer. DO NOT EDIT.\n", false},
+		{"diff --git a/a.go b/a.go\n@@ -0,0 +1 @@\n+const header = \"// Code generated by x. DO NOT EDIT.\"\n", false},
+		{"diff --git a/a_pb2.py b/a_pb2.py\n@@ -0,0 +1 @@\n+# Code generated by protoc. DO NOT EDIT.\n", true},
+		{"diff --git a/a.sql b/a.sql\n@@ -0,0 +1 @@\n+-- Code generated by sqlc. DO NOT EDIT.\n", true},
+		{"diff --git a/a.ts b/a.ts\n@@ -0,0 +1 @@\n+/* Code generated by tool. DO NOT EDIT. */\n", true},
+		// 文件中间的变更块从工作区读取文件头部
+		{"diff --git a/user.pb.go b/user.pb.go\n@@ -10 +10 @@\n-x\n+y\n", true},
+		{"diff --git a/main.go b/main.go\n@@ -10 +10 @@\n-x\n+y\n", false},
+		{"diff --git a/missing.go b/missing.go\n@@ -10,0 +11 @@\n+// Code generated by stringer. DO NOT EDIT.\n", false},
+		// 删除的文件检查旧内容
+		{"diff --git a/old.pb.go b/old.pb.go\n--- a/old.pb.go\n+++ /dev/null\n@@ -1,2 +0,0 @@\n-// Code generated by protoc-gen-go. DO NOT EDIT.\n-package api\n", true},
+	}
+
+	r := New()
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, r.isGenerated(diff.Parse(tt.diff)[0]), tt.diff)
+	}
+}
+
+func TestReviewFindings(t *testing.T) {
+	response := "## 主要问题\n1. SQL 注入\n\n```cr-findings\n" + `[
+  {"file": "db/query.go", "line": 2, "severity": "high", "category": "security", "title": "SQL 注入风险"},
+  {"file": "db/query.go", "line": 4, "severity": "中等", "category": "performance", "title": "循环内查询"},
+  {"file": "db/query.go", "line": 6, "severity": "低", "category": "style", "title": "命名不规范"},
+  {"file": "db/query.go", "line": 7, "severity": "低", "category": "correctness", "title": "未处理错误"},
+  {"file": "db/query.go", "line": 7, "severity": "中等", "category": "security", "title": "敏感变量"},
+  {"file": "db/query.go", "line": 7, "severity": "低", "category": "style", "title": "魔法数字"}
+]` + "\n```\n"
+	cfg := newModelServer(t, func(body RequestBody) string { return response })
+
+	baselinePath := t.TempDir() + "/baseline.json"
+	baseline := &Baseline{Version: 1}
+	baseline.Add([]Finding{{File: "db/query.go", Category: "correctness", Title: "未处理错误"}})
+	assert.NoError(t, baseline.Save(baselinePath))
+
+	cfg.Review.BaselineFile = baselinePath
+
+	diffContent := `diff --git a/db/query.go b/db/query.go
+@@ -0,0 +1,7 @@
++func query(id string) {
++	db.Exec("SELECT * FROM t WHERE id = " + id)
++	for _, x := range xs {
++		db.Query(x) // cr:ignore performance
++	}
++	// cr:ignore-next-line security
++	badName := 1
+`
+	history, err := New().Review(diffContent)
+	assert.NoError(t, err)
+	assert.NotContains(t, history.ReviewResult, "cr-findings")
+	assert.Len(t, history.Findings, 3)
+	assert.Equal(t, SeverityHigh, history.Findings[0].Severity)
+	assert.Equal(t, "db/query.go:2", history.Findings[0].Location())
+	assert.Equal(t, "命名不规范", history.Findings[1].Title)
+	// cr:ignore-next-line security 只忽略下一行的 security 问题
+	assert.Equal(t, "魔法数字", history.Findings[2].Title)
+	assert.Equal(t, 3, history.Suppressed)
+	assert.Equal(t, map[string]int{"严重": 1, "低": 2}, history.ReviewStats.IssuesByLevel)
+}
+
+func TestNewRemote(t *testing.T) {
+	response := "```cr-findings\n" + `[
+  {"file": "db/query.go", "line": 1, "severity": "低", "category": "style", "title": "命名不规范"},
+  {"file": "db/query.go", "line": 10, "severity": "低", "category": "correctness", "title": "未处理错误"}
+]` + "\n```\n"
+	cfg := newModelServer(t, func(body RequestBody) string { return response })
+
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(dir+"/db", 0755))
+	assert.NoError(t, os.WriteFile(dir+"/db/query.go", []byte("badName := 1 // cr:ignore\n"), 0644))
+	baseline := &Baseline{Version: 1}
+	baseline.Add([]Finding{{File: "db/query.go", Category: "correctness", Title: "未处理错误"}})
+	assert.NoError(t, baseline.Save(dir+"/baseline.json"))
+	cfg.Review.BaselineFile = dir + "/baseline.json"
+	wd, _ := os.Getwd()
+	assert.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	diffContent := "diff --git a/db/query.go b/db/query.go\n@@ -10 +10 @@\n-a\n+b\n"
+	history, err := New().Review(diffContent)
+	assert.NoError(t, err)
+	assert.Empty(t, history.Findings)
+	assert.Equal(t, 2, history.Suppressed)
+
+	// 评审其他仓库的改动时不使用本地的基线和工作区文件
+	history, err = NewRemote().Review(diffContent)
+	assert.NoError(t, err)
+	assert.Len(t, history.Findings, 2)
+	assert.Equal(t, 0, history.Suppressed)
+}
+
+func TestFingerprint(t *testing.T) {
+	a := Finding{File: "a.go", Line: 10, Category: "Security", Title: "SQL  注入"}
+	b := Finding{File: "a.go", Line: 42, Category: "security", Title: "sql 注入"}
+	assert.Equal(t, a.Fingerprint(), b.Fingerprint())
+
+	c := Finding{File: "b.go", Line: 10, Category: "security", Title: "SQL 注入"}
+	assert.NotEqual(t, a.Fingerprint(), c.Fingerprint())
+}
+
+func TestAsk(t *testing.T) {
+	var messages []Message
+	newModelServer(t, func(body RequestBody) string {
+		messages = body.Messages
+		return "因为拼接了用户输入"
+	})
+
+	h := &ReviewHistory{Template: "default,default", ReviewResult: "存在 SQL 注入"}
+	turns := []Message{{Role: "user", Content: "在哪一行？"}, {Role: "assistant", Content: "第 2 行"}}
+	answer, _, err := New().Ask(h, "+db.Exec(sql)", turns, "为什么？")
+	assert.NoError(t, err)
+	assert.Equal(t, "因为拼接了用户输入", answer)
+
+	var roles []string
+	for _, m := range messages {
+		roles = append(roles, m.Role)
+	}
+	assert.Equal(t, []string{"system", "user", "assistant", "user", "assistant", "user"}, roles)
+	assert.Equal(t, New().template("default").SystemPrompt, messages[0].Content)
+	assert.NotContains(t, messages[0].Content, "cr-findings")
+	assert.Equal(t, "+db.Exec(sql)", messages[1].Content)
+	assert.Equal(t, "存在 SQL 注入", messages[2].Content)
+	assert.Equal(t, "为什么？", messages[5].Content)
+
+	_, _, err = New().Ask(h, "", nil, " ")
+	assert.Error(t, err)
+
+	content, err := FileContext("db/query.go", []byte("package db\n"))
+	assert.NoError(t, err)
+	assert.Contains(t, content, "db/query.go")
+	assert.Contains(t, content, "package db")
+	_, err = FileContext("big.go", make([]byte, MaxContextFileSize+1))
+	assert.Error(t, err)
+}
+
+func TestPatch(t *testing.T) {
+	response := "修复如下：\n```diff\n--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-var x = 1\n+var x = 2\n```\n"
+	var messages []Message
+	newModelServer(t, func(body RequestBody) string {
+		messages = body.Messages
+		return response
+	})
+
+	f := Finding{File: "main.go", Line: 1, Severity: SeverityLow, Title: "魔法数字", Suggestion: "使用常量"}
+	patch, _, err := New().Patch(f, "var x = 1\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-var x = 1\n+var x = 2\n", patch)
+	assert.Contains(t, messages[1].Content, "main.go:1")
+	assert.Contains(t, messages[1].Content, "使用常量")
+	assert.Contains(t, messages[1].Content, "var x = 1")
+
+	response = "无法给出可靠的修复\n```diff\n```\n"
+	_, _, err = New().Patch(f, "var x = 1\n")
+	assert.Error(t, err)
+}
+
+func TestCommit(t *testing.T) {
+	response := "```cr-commit\n" + `{"type": "Feat", "scope": "", "subject": "支持生成提交信息。", "body": "新增 cr commit-msg 命令", "breaking": "移除 --legacy 参数"}` + "\n```"
+	var messages []Message
+	newModelServer(t, func(body RequestBody) string {
+		messages = body.Messages
+		return response
+	})
+
+	diffContent := "diff --git a/pkg/review/commit.go b/pkg/review/commit.go\n@@ -0,0 +1 @@\n+package review\n" +
+		"diff --git a/pkg/review/templates/commit.json b/pkg/review/templates/commit.json\n@@ -0,0 +1 @@\n+{}\n"
+	msg, _, err := New().Commit(diffContent, "en")
+	assert.NoError(t, err)
+	assert.Contains(t, messages[0].Content, "scope 为 review")
+	assert.Contains(t, messages[0].Content, "in English")
+	assert.Equal(t, "feat(review)!: 支持生成提交信息\n\n新增 cr commit-msg 命令\n\nBREAKING CHANGE: 移除 --legacy 参数\n", msg.String())
+
+	_, _, err = New().Commit(diffContent, "fr")
+	assert.Error(t, err)
+
+	response = "```cr-commit\n" + `{"type": "update", "subject": "x"}` + "\n```"
+	_, _, err = New().Commit(diffContent, "")
+	assert.Error(t, err)
+}
+
+func TestInferScope(t *testing.T) {
+	cfg := testConfig()
+	cfg.Commit.Scopes = []config.CommitScope{{Paths: []string{"*.md", "docs/**"}, Scope: "docs"}}
+	config.Set(cfg)
+	t.Cleanup(func() { config.Set(testConfig()) })
+
+	r := New()
+	assert.Equal(t, "review", r.inferScope([]string{"pkg/review/a.go", "pkg/review/templates/b.json"}))
+	assert.Equal(t, "cr", r.inferScope([]string{"cmd/cr/cmd/fix.go"}))
+	assert.Equal(t, "docs", r.inferScope([]string{"README.md", "docs/guide/setup.txt"}))
+	assert.Equal(t, "", r.inferScope([]string{"pkg/review/a.go", "pkg/diff/b.go"}))
+	assert.Equal(t, "", r.inferScope([]string{"go.mod"}))
+}
+
+func TestDescribe(t *testing.T) {
+	var messages []Message
+	newModelServer(t, func(body RequestBody) string {
+		messages = body.Messages
+		return "```markdown\n## 概述\n新增导出\n```"
+	})
+
+	content, _, err := New().Describe("diff --git a/a.go b/a.go\n@@ -0,0 +1 @@\n+package a\n", "- abcd feat: 导出")
+	assert.NoError(t, err)
+	assert.Equal(t, "## 概述\n新增导出", content)
+	assert.Contains(t, messages[0].Content, "风险评估")
+	assert.Contains(t, messages[1].Content, "- abcd feat: 导出")
+	assert.Contains(t, messages[1].Content, "+package a")
+
+	_, _, err = New().Describe("", "")
+	assert.Error(t, err)
+
+	_, _, err = New().ReleaseNotes("- abcd feat: 导出", "共 1 次评审")
+	assert.NoError(t, err)
+	assert.Contains(t, messages[0].Content, "不兼容的改动")
+	assert.Contains(t, messages[1].Content, "代码评审摘要：\n共 1 次评审")
+
+	_, _, err = New().ReleaseNotes("", "")
+	assert.Error(t, err)
+}
+
+func TestAudit(t *testing.T) {
+	var requests []string
+	cfg := newModelServer(t, func(body RequestBody) string {
+		content := body.Messages[1].Content
+		requests = append(requests, content)
+		result := "## 主要问题\n无\n\n```cr-findings\n[]\n```"
+		if strings.Contains(content, "文件 db/query.go") {
+			result = "## 主要问题\n1. SQL 注入\n\n```cr-findings\n" +
+				`[{"file": "db/query.go", "line": 2, "severity": "严重", "category": "security", "title": "SQL 注入"}]` + "\n```"
+		}
+		return result
+	})
+
+	dir := t.TempDir()
+	write := func(name, content string) {
+		path := dir + "/" + name
+		assert.NoError(t, os.MkdirAll(path[:strings.LastIndex(path, "/")], 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	write("db/query.go", "package db\nfunc q(id string) { db.Exec(\"SELECT \" + id) }\n")
+	write("util/big.go", strings.Repeat("// 很长的注释，用来让文件超出预算\n", 40))
+	write("util/gen.go", "// Code generated by tool. DO NOT EDIT.\npackage util\n")
+	// package 子句之后的标记不是生成代码
+	write("util/tmpl.go", "package util\n\n// Code generated by tool. DO NOT EDIT.\nconst header = 1\n")
+	write("go.sum", "x v1.0.0 h1:abc\n")
+	write("notes.txt", "忽略\n")
+	write("logo.png", "\x89PNG\x00\x00")
+	write(".git/config", "[core]\n")
+
+	wd, _ := os.Getwd()
+	assert.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	cfg.Review.IgnorePatterns = []string{"*.txt"}
+	cfg.Audit.TokenBudget = 300
+
+	r := New()
+	files, skipped, err := r.Collect([]string{"."})
+	assert.NoError(t, err)
+	assert.Len(t, files, 3)
+	assert.ElementsMatch(t, []string{"util/gen.go", "go.sum", "notes.txt", "logo.png"}, skipped)
+
+	var progress []int
+	history, err := r.Audit(files, skipped, func(batch, total int, names []string) {
+		progress = append(progress, batch)
+	})
+	assert.NoError(t, err)
+	// big.go 超出预算被拆成多段
+	assert.Greater(t, len(progress), 2)
+	assert.Contains(t, requests[0], "\n2| func q(id string)")
+	assert.Contains(t, strings.Join(requests, ""), "文件 util/big.go（第 1-")
+
+	assert.Len(t, history.Findings, 1)
+	assert.Equal(t, "db/query.go:2", history.Findings[0].Location())
+	assert.Equal(t, "audit", history.Template)
+	assert.Equal(t, 3, history.ReviewStats.FilesChanged)
+	assert.Contains(t, history.ReviewResult, "- 风险等级：高")
+	assert.Contains(t, history.ReviewResult, "| `db/query.go` | 高 | 1 | 0 | 0 |")
+	assert.Contains(t, history.ReviewResult, "其余 2 个文件未发现问题")
+	assert.Contains(t, history.ReviewResult, "## 审计详情（第 1/")
+	assert.NotContains(t, history.ReviewResult, "cr-findings")
+
+	_, err = r.Audit(nil, nil, nil)
+	assert.Error(t, err)
+}
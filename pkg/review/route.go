@@ -0,0 +1,149 @@
+package review
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/icatw/cr-tool/pkg/config"
+	"github.com/icatw/cr-tool/pkg/diff"
+	"github.com/icatw/cr-tool/pkg/glob"
+)
+
+// languages 文件扩展名与语言的对应关系
+var languages = map[string]string{
+	".go":     "go",
+	".sql":    "sql",
+	".tf":     "terraform",
+	".tfvars": "terraform",
+	".hcl":    "terraform",
+	".vue":    "vue",
+	".js":     "javascript",
+	".jsx":    "javascript",
+	".mjs":    "javascript",
+	".ts":     "typescript",
+	".tsx":    "typescript",
+	".py":     "python",
+	".java":   "java",
+	".kt":     "kotlin",
+	".rs":     "rust",
+	".rb":     "ruby",
+	".php":    "php",
+	".c":      "c",
+	".h":      "c",
+	".cc":     "cpp",
+	".cpp":    "cpp",
+	".hpp":    "cpp",
+	".cs":     "csharp",
+	".swift":  "swift",
+	".sh":     "shell",
+	".yaml":   "yaml",
+	".yml":    "yaml",
+	".json":   "json",
+	".proto":  "protobuf",
+	".md":     "markdown",
+}
+
+// languageOf 根据文件名推断语言
+func languageOf(filename string) string {
+	base := filepath.Base(filename)
+	switch base {
+	case "Dockerfile":
+		return "dockerfile"
+	case "Makefile":
+		return "makefile"
+	}
+	return languages[strings.ToLower(filepath.Ext(base))]
+}
+
+// matchRoute 判断文件是否命中路由规则
+// 路径与语言条件同时配置时需要同时满足；不含 / 的路径模式只匹配文件名
+func matchRoute(route config.TemplateRoute, filename string) bool {
+	if len(route.Paths) == 0 && len(route.Languages) == 0 {
+		return false
+	}
+
+	if len(route.Paths) > 0 {
+		matched := false
+		for _, pattern := range route.Paths {
+			name := filename
+			if !strings.Contains(pattern, "/") {
+				name = filepath.Base(filename)
+			}
+			if glob.Match(pattern, name) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if len(route.Languages) > 0 {
+		lang := languageOf(filename)
+		matched := false
+		for _, l := range route.Languages {
+			if strings.EqualFold(l, lang) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// reviewGroup 使用同一模板评审的一组文件
+type reviewGroup struct {
+	Template string
+	Files    []*diff.File
+}
+
+// Names 返回分组内的文件名
+func (g *reviewGroup) Names() []string {
+	names := make([]string, 0, len(g.Files))
+	for _, f := range g.Files {
+		if name := f.Name(); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// routeFiles 按路由规则将文件分组，未命中任何规则的文件使用默认模板
+func (r *Reviewer) routeFiles(files []*diff.File) []*reviewGroup {
+	groups := make(map[string]*reviewGroup)
+	for _, f := range files {
+		tmpl := r.config.Review.Template
+		for _, route := range r.config.Review.Routes {
+			if route.Template != "" && matchRoute(route, f.Name()) {
+				tmpl = route.Template
+				break
+			}
+		}
+
+		g, ok := groups[tmpl]
+		if !ok {
+			g = &reviewGroup{Template: tmpl}
+			groups[tmpl] = g
+		}
+		g.Files = append(g.Files, f)
+	}
+
+	result := make([]*reviewGroup, 0, len(groups))
+	for _, g := range groups {
+		result = append(result, g)
+	}
+	// 默认模板排在最前，其余按模板名排序，保证结果稳定
+	sort.Slice(result, func(i, j int) bool {
+		if (result[i].Template == r.config.Review.Template) != (result[j].Template == r.config.Review.Template) {
+			return result[i].Template == r.config.Review.Template
+		}
+		return result[i].Template < result[j].Template
+	})
+	return result
+}
@@ -2,7 +2,6 @@ package review
 
 import (
 	"os/exec"
-	"path/filepath"
 	"strings"
 	"time"
 )
@@ -10,6 +9,7 @@ import (
 // analyzeStats 分析评审统计信息
 func (r *Reviewer) analyzeStats(diffContent, reviewResult string) (*ReviewStats, error) {
 	stats := &ReviewStats{
+		FileChanges:    make(map[string]int),
 		IssuesByLevel:  make(map[string]int),
 		CommonIssues:   make([]string, 0),
 		ReviewDateTime: time.Now(),
@@ -24,15 +24,19 @@ func (r *Reviewer) analyzeStats(diffContent, reviewResult string) (*ReviewStats,
 		if strings.HasPrefix(line, "diff --git") {
 			parts := strings.Split(line, " ")
 			if len(parts) > 2 {
-				currentFile = strings.TrimPrefix(parts[2], "b/")
-				if !r.shouldIgnoreFile(currentFile) {
-					changedFiles[currentFile] = true
-				}
+				currentFile = strings.TrimPrefix(parts[2], "a/")
+				changedFiles[currentFile] = true
 			}
 		} else if strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++") {
 			stats.LinesAdded++
+			if currentFile != "" {
+				stats.FileChanges[currentFile]++
+			}
 		} else if strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "---") {
 			stats.LinesDeleted++
+			if currentFile != "" {
+				stats.FileChanges[currentFile]++
+			}
 		}
 	}
 	stats.FilesChanged = len(changedFiles)
@@ -80,27 +84,18 @@ func (r *Reviewer) getGitInfo() (*GitInfo, error) {
 	gitInfo.Branch = strings.TrimSpace(string(output))
 
 	// 获取最近的提交信息
-	output, err = exec.Command("git", "log", "-1", "--pretty=format:%H|%s|%an").Output()
+	// 提交信息可能包含 |，放在最后
+	output, err = exec.Command("git", "log", "-1", "--pretty=format:%H|%an|%ae|%s").Output()
 	if err != nil {
 		return nil, err
 	}
-	parts := strings.Split(string(output), "|")
-	if len(parts) == 3 {
+	parts := strings.SplitN(string(output), "|", 4)
+	if len(parts) == 4 {
 		gitInfo.CommitHash = parts[0]
-		gitInfo.CommitMessage = parts[1]
-		gitInfo.Author = parts[2]
+		gitInfo.Author = parts[1]
+		gitInfo.AuthorEmail = parts[2]
+		gitInfo.CommitMessage = parts[3]
 	}
 
 	return gitInfo, nil
 }
-
-// shouldIgnoreFile 检查是否应该忽略文件
-func (r *Reviewer) shouldIgnoreFile(filename string) bool {
-	for _, pattern := range r.config.Review.IgnorePatterns {
-		matched, err := filepath.Match(pattern, filename)
-		if err == nil && matched {
-			return true
-		}
-	}
-	return false
-}
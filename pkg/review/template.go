@@ -0,0 +1,53 @@
+package review
+
+import (
+	"embed"
+	"encoding/json"
+	"path"
+	"strings"
+
+	"github.com/icatw/cr-tool/pkg/config"
+)
+
+//go:embed templates/*.json
+var templateFS embed.FS
+
+// builtinTemplates 内置评审模板，配置中未定义同名模板时使用
+var builtinTemplates = loadBuiltinTemplates()
+
+// loadBuiltinTemplates 加载内置模板
+func loadBuiltinTemplates() map[string]config.ReviewTemplate {
+	templates := make(map[string]config.ReviewTemplate)
+
+	entries, err := templateFS.ReadDir("templates")
+	if err != nil {
+		return templates
+	}
+	for _, entry := range entries {
+		data, err := templateFS.ReadFile(path.Join("templates", entry.Name()))
+		if err != nil {
+			continue
+		}
+		var tmpl config.ReviewTemplate
+		if err := json.Unmarshal(data, &tmpl); err != nil {
+			continue
+		}
+		templates[strings.TrimSuffix(entry.Name(), ".json")] = tmpl
+	}
+	return templates
+}
+
+// template 按名称获取评审模板
+// 优先使用配置中的模板，其次使用内置模板，都不存在时回退到 default
+func (r *Reviewer) template(name string) config.ReviewTemplate {
+	if tmpl, ok := r.config.Review.Templates[name]; ok {
+		return tmpl
+	}
+	if tmpl, ok := builtinTemplates[name]; ok {
+		return tmpl
+	}
+	if tmpl, ok := r.config.Review.Templates["default"]; ok {
+		return tmpl
+	}
+	return builtinTemplates["default"]
+}
@@ -8,15 +8,50 @@ type ReviewHistory struct {
 	GitInfo      *GitInfo     `json:"git_info"`
 	ReviewStats  *ReviewStats `json:"stats"`
 	ReviewResult string       `json:"result"`
+	Findings     []Finding    `json:"findings,omitempty"`
+	Suppressed   int          `json:"suppressed,omitempty"`
+	DiffHash     string       `json:"diff_hash,omitempty"`
+	Model        string       `json:"model,omitempty"`
+	Template     string       `json:"template,omitempty"`
+	Usage        *TokenUsage  `json:"usage,omitempty"`
 	DateTime     time.Time    `json:"datetime"`
 }
 
+// TokenUsage 模型 token 用量，命中缓存时不计入
+type TokenUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Add 累加 token 用量
+func (u *TokenUsage) Add(other TokenUsage) {
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.TotalTokens += other.TotalTokens
+}
+
+// Finding 结构化的评审问题
+type Finding struct {
+	File       string   `json:"file"`
+	Line       int      `json:"line,omitempty"`
+	EndLine    int      `json:"end_line,omitempty"`
+	Severity   Severity `json:"severity"`
+	Category   string   `json:"category,omitempty"`
+	Title      string   `json:"title"`
+	Detail     string   `json:"detail,omitempty"`
+	Suggestion string   `json:"suggestion,omitempty"`
+	// Fix 修复后的代码，用于替换 Line 到 EndLine 的整行内容，为空表示没有可直接应用的修复
+	Fix string `json:"fix,omitempty"`
+}
+
 // GitInfo Git 信息
 type GitInfo struct {
 	Branch        string   `json:"branch"`
 	CommitHash    string   `json:"commit_hash"`
 	CommitMessage string   `json:"commit_message"`
 	Author        string   `json:"author"`
+	AuthorEmail   string   `json:"author_email,omitempty"`
 	ChangedFiles  []string `json:"changed_files"`
 }
 
@@ -25,8 +60,10 @@ type ReviewStats struct {
 	FilesChanged   int            `json:"files_changed"`
 	LinesAdded     int            `json:"lines_added"`
 	LinesDeleted   int            `json:"lines_deleted"`
+	FileChanges    map[string]int `json:"file_changes,omitempty"`
 	IssuesByLevel  map[string]int `json:"issues_by_level"`
 	CommonIssues   []string       `json:"common_issues"`
+	IgnoredFiles   []string       `json:"ignored_files,omitempty"`
 	ReviewDateTime time.Time      `json:"review_datetime"`
 }
 
@@ -50,4 +87,5 @@ type ResponseBody struct {
 			Content string `json:"content"`
 		} `json:"message"`
 	} `json:"choices"`
+	Usage TokenUsage `json:"usage"`
 }
@@ -0,0 +1,331 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/icatw/cr-tool/pkg/exporter"
+	"github.com/icatw/cr-tool/pkg/review"
+)
+
+//go:embed openapi.yaml
+var openAPIDocument []byte
+
+// patchSignature git format-patch 末尾的签名，如 "-- \n2.43.0\n"
+var patchSignature = regexp.MustCompile(`\n-- \n\d+\.\d+[^\n]*\n*$`)
+
+// exportType 导出格式对应的 Content-Type 和文件扩展名
+type exportType struct {
+	contentType string
+	ext         string
+}
+
+// exportTypes 支持通过 API 导出的格式
+var exportTypes = map[string]exportType{
+	string(exporter.FormatMarkdown): {"text/markdown; charset=utf-8", ".md"},
+	string(exporter.FormatHTML):     {"text/html; charset=utf-8", ".html"},
+	string(exporter.FormatPDF):      {"application/pdf", ".pdf"},
+	string(exporter.FormatJUnit):    {"application/xml", ".junit.xml"},
+
+	string(exporter.FormatGitHub):      {"text/plain; charset=utf-8", ".txt"},
+	string(exporter.FormatGitLab):      {"application/json", ".json"},
+	string(exporter.FormatRDJSON):      {"application/json", ".json"},
+	string(exporter.FormatCheckstyle):  {"application/xml", ".xml"},
+	string(exporter.FormatErrorformat): {"text/plain; charset=utf-8", ".txt"},
+}
+
+// APIReview 通过 REST API 提交的评审
+type APIReview struct {
+	ID        string                `json:"id"`
+	Status    string                `json:"status"`
+	Error     string                `json:"error,omitempty"`
+	Review    *review.ReviewHistory `json:"review,omitempty"`
+	CreatedAt time.Time             `json:"created_at"`
+	UpdatedAt time.Time             `json:"updated_at"`
+
+	err  error
+	done chan struct{}
+}
+
+// reviewRequest POST /v1/reviews 的 JSON 请求
+type reviewRequest struct {
+	Diff  string `json:"diff"`
+	Async bool   `json:"async"`
+}
+
+// reviewSet API 评审结果，保存在内存中，只保留最近结束的评审
+type reviewSet struct {
+	mu    sync.Mutex
+	items map[string]*APIReview
+}
+
+// newReviewSet 创建评审结果集合
+func newReviewSet() *reviewSet {
+	return &reviewSet{items: make(map[string]*APIReview)}
+}
+
+// add 添加等待中的评审
+func (rs *reviewSet) add() *APIReview {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	now := time.Now()
+	r := &APIReview{ID: newID(), Status: JobQueued, CreatedAt: now, UpdatedAt: now, done: make(chan struct{})}
+	rs.items[r.ID] = r
+	return r
+}
+
+// get 获取评审的副本
+func (rs *reviewSet) get(id string) (APIReview, bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	r, ok := rs.items[id]
+	if !ok {
+		return APIReview{}, false
+	}
+	return *r, true
+}
+
+// update 修改评审状态
+func (rs *reviewSet) update(id string, fn func(r *APIReview)) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if r, ok := rs.items[id]; ok {
+		fn(r)
+		r.UpdatedAt = time.Now()
+	}
+}
+
+// finish 记录评审结果，并清理过多的已结束评审
+func (rs *reviewSet) finish(id string, history *review.ReviewHistory, err error) {
+	rs.update(id, func(r *APIReview) {
+		r.Status, r.Review = JobDone, history
+		if err != nil {
+			r.Status, r.Error, r.err = JobFailed, err.Error(), err
+		}
+		close(r.done)
+	})
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	var finished []*APIReview
+	for _, r := range rs.items {
+		if r.Status == JobDone || r.Status == JobFailed {
+			finished = append(finished, r)
+		}
+	}
+	if len(finished) > maxFinishedJobs {
+		sort.Slice(finished, func(i, k int) bool { return finished[i].UpdatedAt.After(finished[k].UpdatedAt) })
+		for _, r := range finished[maxFinishedJobs:] {
+			delete(rs.items, r.ID)
+		}
+	}
+}
+
+// remove 删除评审
+func (rs *reviewSet) remove(id string) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	delete(rs.items, id)
+}
+
+// newID 生成评审 ID
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// handleAPI 路由 /v1 接口，除 OpenAPI 文档外都需要 API key
+func (s *Server) handleAPI(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	if path == "/v1/openapi.yaml" && r.Method == http.MethodGet {
+		w.Header().Set("Content-Type", "application/yaml; charset=utf-8")
+		w.Write(openAPIDocument)
+		return
+	}
+	if len(s.config.APIKeys) == 0 {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "未启用 REST API (server.api_keys)"})
+		return
+	}
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "API key 无效"})
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(path, "/v1/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "reviews" && r.Method == http.MethodPost:
+		s.createReview(w, r)
+	case len(parts) == 2 && parts[0] == "reviews" && r.Method == http.MethodGet:
+		s.getReview(w, parts[1])
+	case len(parts) == 3 && parts[0] == "reviews" && parts[2] == "export" && r.Method == http.MethodGet:
+		s.exportReview(w, r, parts[1])
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "接口不存在"})
+	}
+}
+
+// authorized 校验 Authorization: Bearer <key> 或 X-API-Key 请求头
+func (s *Server) authorized(r *http.Request) bool {
+	key := r.Header.Get("X-API-Key")
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		key = strings.TrimPrefix(auth, "Bearer ")
+	}
+	if key == "" {
+		return false
+	}
+	for _, k := range s.config.APIKeys {
+		if k != "" && subtle.ConstantTimeCompare([]byte(key), []byte(k)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// createReview 提交评审：请求体为 JSON 或直接为 diff/patch 内容
+// 指定 async 或 diff 超过 async_threshold 时立即返回 202，否则等待评审完成
+func (s *Server) createReview(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, int64(s.config.MaxRequestSize)))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": fmt.Sprintf("请求内容超过 %d 字节", s.config.MaxRequestSize)})
+			return
+		}
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "读取请求失败"})
+		return
+	}
+
+	req := reviewRequest{Async: r.URL.Query().Get("async") == "true"}
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/json" {
+		if err := json.Unmarshal(body, &req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "解析请求失败: " + err.Error()})
+			return
+		}
+	} else {
+		req.Diff = string(body)
+	}
+	diffContent := diffFromPatch(req.Diff)
+	if strings.TrimSpace(diffContent) == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "diff 内容为空"})
+		return
+	}
+
+	item := s.reviews.add()
+	ok := s.enqueue(func(ctx context.Context) {
+		s.reviews.update(item.ID, func(r *APIReview) { r.Status = JobRunning })
+		history, err := s.reviewDiff(diffContent)
+		s.reviews.finish(item.ID, history, err)
+	})
+	if !ok {
+		s.reviews.remove(item.ID)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": errQueueFull.Error()})
+		return
+	}
+
+	if req.Async || (s.config.AsyncThreshold > 0 && len(diffContent) > s.config.AsyncThreshold) {
+		w.Header().Set("Location", "/v1/reviews/"+item.ID)
+		result, _ := s.reviews.get(item.ID)
+		writeJSON(w, http.StatusAccepted, result)
+		return
+	}
+
+	select {
+	case <-item.done:
+	case <-r.Context().Done():
+		return
+	}
+	result, _ := s.reviews.get(item.ID)
+	writeJSON(w, reviewStatus(result), result)
+}
+
+// reviewDiff 评审 diff，panic 视为评审失败
+func (s *Server) reviewDiff(diffContent string) (history *review.ReviewHistory, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("评审异常: %v", r)
+		}
+	}()
+	return s.opts.ReviewDiff(diffContent)
+}
+
+// getReview 查询评审状态和结果
+func (s *Server) getReview(w http.ResponseWriter, id string) {
+	result, ok := s.reviews.get(id)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "评审不存在: " + id})
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// exportReview 以指定格式导出评审报告，默认 html
+func (s *Server) exportReview(w http.ResponseWriter, r *http.Request, id string) {
+	result, ok := s.reviews.get(id)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "评审不存在: " + id})
+		return
+	}
+	if result.Status != JobDone {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "评审未完成: " + result.Status})
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = string(exporter.FormatHTML)
+	}
+	export, ok := exportTypes[format]
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "不支持的导出格式: " + format})
+		return
+	}
+	data, err := exporter.Render(format, result.Review)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	w.Header().Set("Content-Type", export.contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", id+"_review"+export.ext))
+	w.Write(data)
+}
+
+// reviewStatus 同步评审结果对应的 HTTP 状态码
+func reviewStatus(r APIReview) int {
+	switch {
+	case r.err == nil:
+		return http.StatusOK
+	case errors.Is(r.err, review.ErrEmptyDiff):
+		return http.StatusUnprocessableEntity
+	case errors.Is(r.err, review.ErrDiffTooLarge):
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// diffFromPatch 去掉 git format-patch 的邮件头和签名，只保留 diff 部分
+func diffFromPatch(content string) string {
+	if i := strings.Index(content, "diff --git "); i > 0 {
+		content = content[i:]
+	}
+	return patchSignature.ReplaceAllString(content, "\n")
+}
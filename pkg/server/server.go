@@ -0,0 +1,272 @@
+// Package server 以 HTTP 服务的形式提供 PR/MR webhook 自动评审和评审 REST API
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/icatw/cr-tool/pkg/config"
+	"github.com/icatw/cr-tool/pkg/forge"
+	"github.com/icatw/cr-tool/pkg/review"
+)
+
+// maxPayloadSize webhook 内容上限，与 GitHub 的限制一致
+const maxPayloadSize = 25 << 20
+
+// errQueueFull 等待评审的任务已达上限
+var errQueueFull = errors.New("评审队列已满")
+
+// Options 服务依赖的外部操作，测试时可以替换为本地实现
+type Options struct {
+	// Forge 创建仓库对应的平台客户端
+	Forge func(name, repo string) (forge.Forge, error)
+	// Review 评审 diff
+	Review forge.ReviewFunc
+	// ReviewDiff 评审 REST API 提交的 diff
+	ReviewDiff func(diffContent string) (*review.ReviewHistory, error)
+	// Secrets 各平台的 webhook 密钥，未配置密钥的平台不接收 webhook
+	Secrets map[string]string
+}
+
+// Server 评审服务：接收 PR/MR 事件和 API 请求，由固定数量的 worker 评审
+type Server struct {
+	config config.ServerConfig
+	opts   Options
+	store  *JobStore
+	// queue 等待执行的任务，webhook 评审和 API 评审共用 worker
+	queue   chan func(context.Context)
+	reviews *reviewSet
+}
+
+// New 创建服务，上次退出时未完成的任务会重新加入队列
+func New(cfg config.ServerConfig, opts Options) (*Server, error) {
+	if opts.Forge == nil || opts.Review == nil || opts.ReviewDiff == nil {
+		return nil, fmt.Errorf("未设置平台客户端或评审函数")
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 100
+	}
+	if cfg.MaxRequestSize <= 0 {
+		cfg.MaxRequestSize = 10 << 20
+	}
+
+	store, err := OpenJobStore(cfg.StateFile)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		config:  cfg,
+		opts:    opts,
+		store:   store,
+		queue:   make(chan func(context.Context), cfg.QueueSize),
+		reviews: newReviewSet(),
+	}
+	for _, job := range store.Jobs() {
+		if job.State != JobQueued {
+			continue
+		}
+		if !s.enqueueJob(job.ID) {
+			if err := store.Fail(job.ID, errQueueFull); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return s, nil
+}
+
+// Store 任务状态
+func (s *Server) Store() *JobStore {
+	return s.store
+}
+
+// Handler HTTP 接口：POST /webhook/{github,gitlab,gitea}、/v1 REST API 和 GET /healthz
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/webhook/", s.handleWebhook)
+	mux.HandleFunc("/v1/", s.handleAPI)
+	mux.HandleFunc("/healthz", s.handleHealth)
+	return mux
+}
+
+// Run 启动 worker 处理队列中的任务，ctx 结束后等待评审中的任务退出
+// 被中断的任务保持评审中状态，下次启动时重新评审
+func (s *Server) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < s.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case task := <-s.queue:
+					task(ctx)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// ListenAndServe 监听 config.Addr 并处理任务，直到 ctx 结束
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	srv := &http.Server{Addr: s.config.Addr, Handler: s.Handler(), ReadHeaderTimeout: 10 * time.Second}
+
+	workers := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(workers)
+	}()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	err := srv.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		err = nil
+	}
+	<-workers
+	return err
+}
+
+// handleWebhook 校验并解析 webhook，需要评审的事件加入队列
+func (s *Server) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "只支持 POST"})
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/webhook/")
+	secret := s.opts.Secrets[name]
+	if secret == "" {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "未配置该平台的 webhook_secret: " + name})
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPayloadSize))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "读取请求失败"})
+		return
+	}
+	ev, err := ParseWebhook(name, secret, r.Header, body)
+	if errors.Is(err, ErrSignature) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if ev == nil {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ignored"})
+		return
+	}
+
+	id, enqueue, err := s.store.Add(ev)
+	if err != nil {
+		log.Printf("保存任务失败: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "保存任务失败"})
+		return
+	}
+	if enqueue && !s.enqueueJob(id) {
+		if err := s.store.Fail(id, errQueueFull); err != nil {
+			log.Printf("保存任务失败: %v", err)
+		}
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": errQueueFull.Error()})
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]string{"status": "queued", "job": id})
+}
+
+// handleHealth 健康检查，返回各状态的任务数
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	counts := map[string]int{JobQueued: 0, JobRunning: 0}
+	for _, job := range s.store.Jobs() {
+		counts[job.State]++
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok", "jobs": counts})
+}
+
+// enqueue 将任务加入队列，队列已满时返回 false
+func (s *Server) enqueue(task func(context.Context)) bool {
+	select {
+	case s.queue <- task:
+		return true
+	default:
+		return false
+	}
+}
+
+// enqueueJob 将 webhook 评审任务加入队列
+func (s *Server) enqueueJob(id string) bool {
+	return s.enqueue(func(ctx context.Context) { s.process(ctx, id) })
+}
+
+// process 评审任务并记录结果
+func (s *Server) process(ctx context.Context, id string) {
+	job, ok, err := s.store.Start(id)
+	if err != nil {
+		log.Printf("保存任务失败: %v", err)
+	}
+	if !ok {
+		return
+	}
+
+	reviewErr := s.review(ctx, job)
+	if ctx.Err() != nil {
+		return
+	}
+	if reviewErr != nil {
+		log.Printf("评审 %s 失败: %v", id, reviewErr)
+	}
+	rerun, err := s.store.Finish(id, reviewErr)
+	if err != nil {
+		log.Printf("保存任务失败: %v", err)
+	}
+	if rerun && !s.enqueueJob(id) {
+		if err := s.store.Fail(id, errQueueFull); err != nil {
+			log.Printf("保存任务失败: %v", err)
+		}
+	}
+}
+
+// review 评审 PR/MR 并发布结果，panic 视为评审失败
+func (s *Server) review(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("评审异常: %v", r)
+		}
+	}()
+
+	f, err := s.opts.Forge(job.Forge, job.Repo)
+	if err != nil {
+		return err
+	}
+	pr, result, err := forge.ReviewPullRequest(ctx, f, job.Number, s.opts.Review)
+	if err == nil && result != nil {
+		log.Printf("已评审 %s：新增 %d 条评论，更新 %d 条，%d 个问题已修复，状态 %s",
+			pr.URL, result.Created, result.Updated, result.Resolved, result.State)
+	}
+	return err
+}
+
+// writeJSON 输出 JSON 响应
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
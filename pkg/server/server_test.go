@@ -0,0 +1,346 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/icatw/cr-tool/pkg/config"
+	"github.com/icatw/cr-tool/pkg/forge"
+	"github.com/icatw/cr-tool/pkg/review"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSecret = "s3cret"
+
+func loadPayload(t *testing.T, name string) []byte {
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	assert.NoError(t, err)
+	return data
+}
+
+func sign(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(testSecret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestParseWebhook(t *testing.T) {
+	github := loadPayload(t, "github_pull_request.json")
+	ev, err := ParseWebhook("github", testSecret, http.Header{
+		"X-Github-Event":      {"pull_request"},
+		"X-Hub-Signature-256": {"sha256=" + sign(github)},
+	}, github)
+	assert.NoError(t, err)
+	assert.Equal(t, &Event{Forge: "github", Repo: "icatw/cr-tool", Number: 5, HeadSHA: "abcdef1234567890abcdef1234567890abcdef12"}, ev)
+
+	_, err = ParseWebhook("github", testSecret, http.Header{
+		"X-Github-Event":      {"pull_request"},
+		"X-Hub-Signature-256": {"sha256=" + sign([]byte("{}"))},
+	}, github)
+	assert.ErrorIs(t, err, ErrSignature)
+	_, err = ParseWebhook("github", "", http.Header{"X-Github-Event": {"pull_request"}}, github)
+	assert.ErrorIs(t, err, ErrSignature)
+
+	ev, err = ParseWebhook("github", testSecret, http.Header{
+		"X-Github-Event":      {"push"},
+		"X-Hub-Signature-256": {"sha256=" + sign(github)},
+	}, github)
+	assert.NoError(t, err)
+	assert.Nil(t, ev)
+
+	gitea := loadPayload(t, "gitea_pull_request.json")
+	ev, err = ParseWebhook("gitea", testSecret, http.Header{
+		"X-Gitea-Event":     {"pull_request"},
+		"X-Gitea-Signature": {sign(gitea)},
+	}, gitea)
+	assert.NoError(t, err)
+	assert.Equal(t, &Event{Forge: "gitea", Repo: "team/app", Number: 3, HeadSHA: "1234567890abcdef1234567890abcdef12345678"}, ev)
+
+	gitlab := loadPayload(t, "gitlab_merge_request.json")
+	header := http.Header{"X-Gitlab-Event": {"Merge Request Hook"}, "X-Gitlab-Token": {testSecret}}
+	ev, err = ParseWebhook("gitlab", testSecret, header, gitlab)
+	assert.NoError(t, err)
+	assert.Equal(t, &Event{Forge: "gitlab", Repo: "group/app", Number: 7, HeadSHA: "abcdef1234567890abcdef1234567890abcdef12"}, ev)
+
+	// 只修改标题的 update 事件没有 oldrev，不需要评审
+	var payload map[string]interface{}
+	assert.NoError(t, json.Unmarshal(gitlab, &payload))
+	delete(payload["object_attributes"].(map[string]interface{}), "oldrev")
+	titleOnly, _ := json.Marshal(payload)
+	ev, err = ParseWebhook("gitlab", testSecret, header, titleOnly)
+	assert.NoError(t, err)
+	assert.Nil(t, ev)
+
+	_, err = ParseWebhook("gitlab", testSecret, http.Header{"X-Gitlab-Event": {"Merge Request Hook"}, "X-Gitlab-Token": {"wrong"}}, gitlab)
+	assert.ErrorIs(t, err, ErrSignature)
+}
+
+func TestJobStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.json")
+	store, err := OpenJobStore(path)
+	assert.NoError(t, err)
+
+	ev := &Event{Forge: "github", Repo: "o/r", Number: 1, HeadSHA: "a"}
+	id, enqueue, err := store.Add(ev)
+	assert.NoError(t, err)
+	assert.True(t, enqueue)
+	assert.Equal(t, "github:o/r#1", id)
+
+	// 等待中的任务只更新提交
+	_, enqueue, _ = store.Add(&Event{Forge: "github", Repo: "o/r", Number: 1, HeadSHA: "b"})
+	assert.False(t, enqueue)
+
+	job, ok, err := store.Start(id)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "b", job.HeadSHA)
+	_, ok, _ = store.Start(id)
+	assert.False(t, ok, "评审中的任务不能重复开始")
+
+	// 重启后评审中的任务恢复为等待
+	reopened, err := OpenJobStore(path)
+	assert.NoError(t, err)
+	job, _ = reopened.Get(id)
+	assert.Equal(t, JobQueued, job.State)
+
+	// 评审中收到新的推送，结束后重新评审
+	_, enqueue, _ = store.Add(&Event{Forge: "github", Repo: "o/r", Number: 1, HeadSHA: "c"})
+	assert.False(t, enqueue)
+	rerun, err := store.Finish(id, nil)
+	assert.NoError(t, err)
+	assert.True(t, rerun)
+
+	store.Start(id)
+	rerun, _ = store.Finish(id, errors.New("超时"))
+	assert.False(t, rerun)
+	job, _ = store.Get(id)
+	assert.Equal(t, JobFailed, job.State)
+	assert.Equal(t, "超时", job.Error)
+}
+
+// fakeForge 本地的平台客户端
+type fakeForge struct {
+	mu        sync.Mutex
+	repo      string
+	published chan int
+	statuses  []string
+}
+
+func (f *fakeForge) Name() string { return "github" }
+
+func (f *fakeForge) PullRequest(ctx context.Context, number int) (*forge.PullRequest, error) {
+	return &forge.PullRequest{Number: number, URL: "https://github.test/" + f.repo, HeadSHA: "abc"}, nil
+}
+
+func (f *fakeForge) Diff(ctx context.Context, pr *forge.PullRequest) (string, error) {
+	return "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n-a\n+b\n", nil
+}
+
+func (f *fakeForge) SetStatus(ctx context.Context, pr *forge.PullRequest, state, description string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.statuses = append(f.statuses, state)
+	return nil
+}
+
+func (f *fakeForge) Publish(ctx context.Context, pr *forge.PullRequest, history *review.ReviewHistory, diffContent string) (*forge.Result, error) {
+	f.published <- pr.Number
+	return &forge.Result{State: forge.StateSuccess}, nil
+}
+
+func TestServer(t *testing.T) {
+	fake := &fakeForge{published: make(chan int, 1)}
+	path := filepath.Join(t.TempDir(), "jobs.json")
+	srv, err := New(config.ServerConfig{Workers: 2, QueueSize: 10, StateFile: path}, Options{
+		Forge: func(name, repo string) (forge.Forge, error) {
+			fake.repo = repo
+			return fake, nil
+		},
+		Review: func(pr *forge.PullRequest, diffContent string) (*review.ReviewHistory, error) {
+			return &review.ReviewHistory{ReviewResult: "没有问题"}, nil
+		},
+		ReviewDiff: reviewDiff,
+		Secrets:    map[string]string{"github": testSecret},
+	})
+	assert.NoError(t, err)
+
+	handler := srv.Handler()
+	post := func(path string, header http.Header, body []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		req.Header = header
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	body := loadPayload(t, "github_pull_request.json")
+	assert.Equal(t, http.StatusUnauthorized, post("/webhook/github", http.Header{"X-Github-Event": {"pull_request"}}, body).Code)
+	assert.Equal(t, http.StatusNotFound, post("/webhook/gitlab", http.Header{}, body).Code)
+
+	rec := post("/webhook/github", http.Header{
+		"X-Github-Event":      {"pull_request"},
+		"X-Hub-Signature-256": {"sha256=" + sign(body)},
+	}, body)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Contains(t, rec.Body.String(), "github:icatw/cr-tool#5")
+
+	// 任务在启动 worker 前已持久化，重启后可以继续
+	reopened, err := OpenJobStore(path)
+	assert.NoError(t, err)
+	job, ok := reopened.Get("github:icatw/cr-tool#5")
+	assert.True(t, ok)
+	assert.Equal(t, JobQueued, job.State)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		srv.Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case number := <-fake.published:
+		assert.Equal(t, 5, number)
+	case <-time.After(5 * time.Second):
+		t.Fatal("任务未被处理")
+	}
+	assert.Eventually(t, func() bool {
+		job, _ := srv.Store().Get("github:icatw/cr-tool#5")
+		return job.State == JobDone
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "icatw/cr-tool", fake.repo)
+
+	cancel()
+	<-done
+
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"ok","jobs":{"queued":0,"running":0,"done":1}}`, rec.Body.String())
+}
+
+const testPatch = `From 1a2b3c Mon Sep 17 00:00:00 2001
+From: Alice <alice@example.com>
+Subject: [PATCH] 记住密码
+
+---
+ auth/login.go | 2 +-
+
+diff --git a/auth/login.go b/auth/login.go
+--- a/auth/login.go
++++ b/auth/login.go
+@@ -1 +1 @@
+-check(user)
++save(user.Password)
+-- 
+2.43.0
+`
+
+// reviewDiff 本地评审函数，diff 中包含 "empty" 时视为没有需要评审的文件
+func reviewDiff(diffContent string) (*review.ReviewHistory, error) {
+	if strings.Contains(diffContent, "empty") {
+		return nil, review.ErrEmptyDiff
+	}
+	return &review.ReviewHistory{
+		ID:           "r1",
+		ReviewResult: "## 问题\n密码明文存储",
+		Findings:     []review.Finding{{File: "auth/login.go", Line: 1, Severity: review.SeverityHigh, Title: "密码明文存储"}},
+	}, nil
+}
+
+func TestAPI(t *testing.T) {
+	outputDir := t.TempDir()
+	config.Set(&config.Config{Output: config.OutputConfig{Dir: outputDir}})
+	srv, err := New(config.ServerConfig{Workers: 1, QueueSize: 10, APIKeys: []string{"key"}, MaxRequestSize: 1024, AsyncThreshold: 512}, Options{
+		Forge:      func(name, repo string) (forge.Forge, error) { return nil, errors.New("未使用") },
+		Review:     func(pr *forge.PullRequest, diffContent string) (*review.ReviewHistory, error) { return nil, nil },
+		ReviewDiff: reviewDiff,
+	})
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go srv.Run(ctx)
+
+	handler := srv.Handler()
+	do := func(method, path, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer key")
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/reviews/x", nil)
+	req.Header.Set("X-API-Key", "wrong")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = do(http.MethodGet, "/v1/openapi.yaml", "", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "/reviews/{id}/export")
+
+	// 同步评审 git format-patch
+	rec = do(http.MethodPost, "/v1/reviews", "text/x-patch", testPatch)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var result APIReview
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+	assert.Equal(t, JobDone, result.Status)
+	assert.Equal(t, "密码明文存储", result.Review.Findings[0].Title)
+
+	rec = do(http.MethodGet, "/v1/reviews/"+result.ID+"/export?format=markdown", "", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/markdown; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, `inline; filename="`+result.ID+`_review.md"`, rec.Header().Get("Content-Disposition"))
+	assert.Contains(t, rec.Body.String(), "密码明文存储")
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/v1/reviews/"+result.ID+"/export?format=docx", "", "").Code)
+	rec = do(http.MethodGet, "/v1/reviews/"+result.ID+"/export?format=junit", "", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "<testsuite")
+	// 导出内容在内存中生成，不写入输出目录
+	entries, _ := os.ReadDir(outputDir)
+	assert.Empty(t, entries)
+
+	// 异步评审
+	rec = do(http.MethodPost, "/v1/reviews?async=true", "application/json", `{"diff":"diff --git a/a b/a"}`)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+	assert.Equal(t, "/v1/reviews/"+result.ID, rec.Header().Get("Location"))
+	assert.Eventually(t, func() bool {
+		rec := do(http.MethodGet, "/v1/reviews/"+result.ID, "", "")
+		return strings.Contains(rec.Body.String(), `"status":"done"`)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// 超过 async_threshold 自动转为异步
+	rec = do(http.MethodPost, "/v1/reviews", "text/x-diff", "diff --git a/a b/a\n"+strings.Repeat("+x\n", 200))
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, do(http.MethodPost, "/v1/reviews", "text/x-diff", strings.Repeat("x", 2048)).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/v1/reviews", "text/x-diff", " ").Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, do(http.MethodPost, "/v1/reviews", "text/x-diff", "diff --git a/empty b/empty").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/v1/reviews/missing", "", "").Code)
+}
+
+func TestDiffFromPatch(t *testing.T) {
+	diffContent := diffFromPatch(testPatch)
+	assert.True(t, strings.HasPrefix(diffContent, "diff --git a/auth/login.go"))
+	assert.True(t, strings.HasSuffix(diffContent, "+save(user.Password)\n"))
+	assert.Equal(t, "diff --git a/a b/a\n", diffFromPatch("diff --git a/a b/a\n"))
+}
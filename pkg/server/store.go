@@ -0,0 +1,223 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 任务状态
+const (
+	JobQueued  = "queued"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+// maxFinishedJobs 状态文件中保留的已结束任务数
+const maxFinishedJobs = 200
+
+// Job 评审任务，同一个 PR/MR 只有一个任务，新的推送会复用原任务
+type Job struct {
+	ID      string `json:"id"`
+	Forge   string `json:"forge"`
+	Repo    string `json:"repo"`
+	Number  int    `json:"number"`
+	HeadSHA string `json:"head_sha,omitempty"`
+	State   string `json:"state"`
+	// Rerun 评审过程中收到新的推送，结束后需要重新评审
+	Rerun     bool      `json:"rerun,omitempty"`
+	Attempts  int       `json:"attempts"`
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// finished 任务是否已结束
+func (j *Job) finished() bool {
+	return j.State == JobDone || j.State == JobFailed
+}
+
+// JobStore 任务状态，每次变更后写入文件
+type JobStore struct {
+	mu   sync.Mutex
+	path string
+	jobs map[string]*Job
+	now  func() time.Time
+}
+
+// OpenJobStore 打开任务状态文件，path 为空时只保存在内存中
+// 上次退出时未完成的任务恢复为等待状态
+func OpenJobStore(path string) (*JobStore, error) {
+	s := &JobStore{path: path, jobs: make(map[string]*Job), now: time.Now}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取任务状态失败: %w", err)
+	}
+	var jobs []*Job
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return nil, fmt.Errorf("解析任务状态失败: %w", err)
+	}
+	for _, j := range jobs {
+		if j.State == JobRunning {
+			j.State = JobQueued
+		}
+		s.jobs[j.ID] = j
+	}
+	return s, nil
+}
+
+// Add 为事件创建任务，返回是否需要加入队列
+// 任务已在等待时只更新提交，评审中时标记为结束后重新评审
+func (s *JobStore) Add(ev *Event) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := fmt.Sprintf("%s:%s#%d", ev.Forge, ev.Repo, ev.Number)
+	now := s.now()
+	enqueue := false
+	job, ok := s.jobs[id]
+	switch {
+	case !ok:
+		job = &Job{ID: id, Forge: ev.Forge, Repo: ev.Repo, Number: ev.Number, CreatedAt: now}
+		s.jobs[id] = job
+		fallthrough
+	case job.finished():
+		job.State, job.Attempts, job.Error = JobQueued, 0, ""
+		enqueue = true
+	case job.State == JobRunning:
+		job.Rerun = true
+	}
+	job.HeadSHA = ev.HeadSHA
+	job.UpdatedAt = now
+	return id, enqueue, s.save()
+}
+
+// Start 开始评审，任务不在等待状态时返回 false
+func (s *JobStore) Start(id string) (Job, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok || job.State != JobQueued {
+		return Job{}, false, nil
+	}
+	job.State = JobRunning
+	job.Attempts++
+	job.UpdatedAt = s.now()
+	return *job, true, s.save()
+}
+
+// Finish 结束评审，返回是否需要重新加入队列
+func (s *JobStore) Finish(id string, reviewErr error) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return false, nil
+	}
+	job.UpdatedAt = s.now()
+	if job.Rerun {
+		job.State, job.Rerun, job.Attempts, job.Error = JobQueued, false, 0, ""
+		return true, s.save()
+	}
+	job.State, job.Error = JobDone, ""
+	if reviewErr != nil {
+		job.State, job.Error = JobFailed, reviewErr.Error()
+	}
+	return false, s.save()
+}
+
+// Fail 将任务标记为失败，用于无法加入队列的任务
+func (s *JobStore) Fail(id string, err error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if job, ok := s.jobs[id]; ok {
+		job.State, job.Rerun, job.Error, job.UpdatedAt = JobFailed, false, err.Error(), s.now()
+	}
+	return s.save()
+}
+
+// Get 获取任务
+func (s *JobStore) Get(id string) (Job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// Jobs 按创建时间排序的全部任务
+func (s *JobStore) Jobs() []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, *j)
+	}
+	sort.Slice(jobs, func(i, k int) bool {
+		if !jobs[i].CreatedAt.Equal(jobs[k].CreatedAt) {
+			return jobs[i].CreatedAt.Before(jobs[k].CreatedAt)
+		}
+		return jobs[i].ID < jobs[k].ID
+	})
+	return jobs
+}
+
+// save 写入状态文件，只保留最近结束的任务，调用方需持有锁
+func (s *JobStore) save() error {
+	var finished []*Job
+	for _, j := range s.jobs {
+		if j.finished() {
+			finished = append(finished, j)
+		}
+	}
+	if len(finished) > maxFinishedJobs {
+		sort.Slice(finished, func(i, k int) bool { return finished[i].UpdatedAt.After(finished[k].UpdatedAt) })
+		for _, j := range finished[maxFinishedJobs:] {
+			delete(s.jobs, j.ID)
+		}
+	}
+	if s.path == "" {
+		return nil
+	}
+
+	jobs := make([]*Job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j)
+	}
+	sort.Slice(jobs, func(i, k int) bool { return jobs[i].ID < jobs[k].ID })
+	data, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化任务状态失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("创建任务状态目录失败: %w", err)
+	}
+	// 先写临时文件再重命名，避免写入中途退出导致文件损坏
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("写入任务状态失败: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("写入任务状态失败: %w", err)
+	}
+	return nil
+}
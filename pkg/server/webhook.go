@@ -0,0 +1,143 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrSignature webhook 签名校验失败
+var ErrSignature = errors.New("webhook 签名无效")
+
+// Event 需要评审的 PR/MR 事件
+type Event struct {
+	Forge   string
+	Repo    string
+	Number  int
+	HeadSHA string
+}
+
+// pullRequestPayload GitHub 和 Gitea 的 pull_request 事件，两者格式相同
+type pullRequestPayload struct {
+	Action      string `json:"action"`
+	Number      int    `json:"number"`
+	PullRequest struct {
+		Draft bool `json:"draft"`
+		Head  struct {
+			SHA string `json:"sha"`
+		} `json:"head"`
+	} `json:"pull_request"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+// mergeRequestPayload GitLab 的 Merge Request Hook 事件
+type mergeRequestPayload struct {
+	ObjectKind       string `json:"object_kind"`
+	ObjectAttributes struct {
+		IID    int    `json:"iid"`
+		Action string `json:"action"`
+		// OldRev 只有推送了新提交的 update 事件才有
+		OldRev     string `json:"oldrev"`
+		Draft      bool   `json:"draft"`
+		LastCommit struct {
+			ID string `json:"id"`
+		} `json:"last_commit"`
+	} `json:"object_attributes"`
+	Project struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"project"`
+}
+
+// ParseWebhook 校验签名并解析 webhook 请求，不需要评审的事件返回 nil
+func ParseWebhook(forgeName, secret string, header http.Header, body []byte) (*Event, error) {
+	switch forgeName {
+	case "github":
+		if !validHMAC(secret, body, strings.TrimPrefix(header.Get("X-Hub-Signature-256"), "sha256=")) {
+			return nil, ErrSignature
+		}
+		if header.Get("X-GitHub-Event") != "pull_request" {
+			return nil, nil
+		}
+		return parsePullRequest(forgeName, body, "synchronize")
+	case "gitea":
+		if !validHMAC(secret, body, header.Get("X-Gitea-Signature")) {
+			return nil, ErrSignature
+		}
+		if header.Get("X-Gitea-Event") != "pull_request" {
+			return nil, nil
+		}
+		return parsePullRequest(forgeName, body, "synchronized")
+	case "gitlab":
+		// GitLab 不对内容签名，而是原样发送配置的 secret token
+		token := header.Get("X-Gitlab-Token")
+		if secret == "" || subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+			return nil, ErrSignature
+		}
+		if header.Get("X-Gitlab-Event") != "Merge Request Hook" {
+			return nil, nil
+		}
+		return parseMergeRequest(body)
+	default:
+		return nil, fmt.Errorf("不支持的平台: %s", forgeName)
+	}
+}
+
+// validHMAC 校验 HMAC-SHA256 签名
+func validHMAC(secret string, body []byte, signature string) bool {
+	if secret == "" || signature == "" {
+		return false
+	}
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// parsePullRequest 解析 pull_request 事件，只处理创建、重新打开和推送新提交
+func parsePullRequest(forgeName string, body []byte, synchronize string) (*Event, error) {
+	var p pullRequestPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, fmt.Errorf("解析 webhook 内容失败: %w", err)
+	}
+	switch p.Action {
+	case "opened", "reopened", "ready_for_review", synchronize:
+	default:
+		return nil, nil
+	}
+	if p.PullRequest.Draft || p.Number <= 0 || p.Repository.FullName == "" {
+		return nil, nil
+	}
+	return &Event{Forge: forgeName, Repo: p.Repository.FullName, Number: p.Number, HeadSHA: p.PullRequest.Head.SHA}, nil
+}
+
+// parseMergeRequest 解析 Merge Request Hook，只处理创建、重新打开和推送新提交
+func parseMergeRequest(body []byte) (*Event, error) {
+	var p mergeRequestPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, fmt.Errorf("解析 webhook 内容失败: %w", err)
+	}
+	attrs := p.ObjectAttributes
+	switch {
+	case p.ObjectKind != "merge_request", attrs.Draft:
+		return nil, nil
+	case attrs.Action == "open", attrs.Action == "reopen":
+	case attrs.Action == "update" && attrs.OldRev != "":
+	default:
+		return nil, nil
+	}
+	if attrs.IID <= 0 || p.Project.PathWithNamespace == "" {
+		return nil, nil
+	}
+	return &Event{Forge: "gitlab", Repo: p.Project.PathWithNamespace, Number: attrs.IID, HeadSHA: attrs.LastCommit.ID}, nil
+}
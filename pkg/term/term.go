@@ -0,0 +1,100 @@
+// Package term 终端相关的工具：判断终端、获取大小、分页、原始模式，以及按显示宽度排版文本
+package term
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// IsTerminal 判断文件是否为终端
+func IsTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
+
+// Size 返回终端的列数和行数，优先使用 COLUMNS 和 LINES 环境变量，无法获取时为 80x24
+func Size() (int, int) {
+	cols, _ := strconv.Atoi(os.Getenv("COLUMNS"))
+	rows, _ := strconv.Atoi(os.Getenv("LINES"))
+	if cols <= 0 || rows <= 0 {
+		// 标准输入通常是 diff 管道，从控制终端读取大小
+		if tty, err := os.Open("/dev/tty"); err == nil {
+			if out, err := stty(tty, "size"); err == nil {
+				if fields := strings.Fields(out); len(fields) == 2 {
+					r, _ := strconv.Atoi(fields[0])
+					c, _ := strconv.Atoi(fields[1])
+					if cols <= 0 {
+						cols = c
+					}
+					if rows <= 0 {
+						rows = r
+					}
+				}
+			}
+			tty.Close()
+		}
+	}
+	if cols <= 0 {
+		cols = 80
+	}
+	if rows <= 0 {
+		rows = 24
+	}
+	return cols, rows
+}
+
+// Page 输出到终端且内容超过一屏时通过 $PAGER（默认 less）分页显示，
+// 分页程序不可用时直接输出
+func Page(out *os.File, data []byte) error {
+	_, rows := Size()
+	pager := strings.Fields(os.Getenv("PAGER"))
+	if len(pager) == 0 {
+		pager = []string{"less"}
+	}
+	if !IsTerminal(out) || bytes.Count(data, []byte("\n")) < rows || pager[0] == "cat" {
+		_, err := out.Write(data)
+		return err
+	}
+
+	cmd := exec.Command(pager[0], pager[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = out
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	if os.Getenv("LESS") == "" {
+		// 保留颜色，内容不足一屏时直接退出
+		cmd.Env = append(cmd.Env, "LESS=FRX")
+	}
+	if err := cmd.Start(); err != nil {
+		_, err := out.Write(data)
+		return err
+	}
+	return cmd.Wait()
+}
+
+// MakeRaw 将终端切换到原始模式（逐键读取、不回显），返回恢复原状态的函数
+func MakeRaw(tty *os.File) (func() error, error) {
+	state, err := stty(tty, "-g")
+	if err != nil {
+		return nil, fmt.Errorf("无法设置终端: %w", err)
+	}
+	if _, err := stty(tty, "raw", "-echo"); err != nil {
+		return nil, fmt.Errorf("无法设置终端: %w", err)
+	}
+	return func() error {
+		_, err := stty(tty, strings.TrimSpace(state))
+		return err
+	}, nil
+}
+
+// stty 对终端执行 stty 命令
+func stty(tty *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = tty
+	out, err := cmd.Output()
+	return string(out), err
+}
@@ -0,0 +1,22 @@
+package term
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrap(t *testing.T) {
+	assert.Equal(t, "- aaa bbb\n  ccc", Wrap("aaa bbb ccc", 9, "- ", "  "))
+	assert.Equal(t, "中文中\n文", Wrap("中文中文", 6, "", ""))
+	assert.Equal(t, "\x1b[1mab\x1b[0m\ncd", Wrap("\x1b[1mab\x1b[0m cd", 3, "", ""))
+	assert.Equal(t, "toolongword\nx", Wrap("toolongword x", 5, "", ""), "超长的单词不截断")
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "abc", Truncate("abc", 3))
+	assert.Equal(t, "ab…", Truncate("abcd", 3))
+	assert.Equal(t, "中…", Truncate("中文字", 4))
+	assert.Equal(t, "中文  ", Pad("中文", 6))
+	assert.Equal(t, 4, Width("\x1b[1m中文\x1b[0m"))
+}
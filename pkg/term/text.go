@@ -0,0 +1,151 @@
+package term
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ansiEscape ANSI 颜色和样式转义序列
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// token 折行的最小单位
+type token struct {
+	text  string
+	width int
+	space bool
+}
+
+// Wrap 按显示宽度折行，first 和 rest 分别为首行和后续行的前缀。
+// ANSI 转义序列不计宽度，中日韩字符之间可以断行，超长的单词不会被截断
+func Wrap(s string, width int, first, rest string) string {
+	var (
+		b            strings.Builder
+		lineWidth    = Width(first)
+		atStart      = true
+		pendingSpace bool
+	)
+	b.WriteString(first)
+	for _, t := range tokenize(s) {
+		if t.space {
+			pendingSpace = !atStart
+			continue
+		}
+		need := t.width
+		if pendingSpace {
+			need++
+		}
+		if !atStart && t.width > 0 && lineWidth+need > width {
+			b.WriteString("\n" + rest)
+			lineWidth = Width(rest)
+			pendingSpace = false
+		}
+		if pendingSpace {
+			b.WriteByte(' ')
+			lineWidth++
+			pendingSpace = false
+		}
+		b.WriteString(t.text)
+		lineWidth += t.width
+		if t.width > 0 {
+			atStart = false
+		}
+	}
+	return b.String()
+}
+
+// tokenize 将文本拆分为单词、空格和单个宽字符
+func tokenize(s string) []token {
+	var (
+		tokens []token
+		cur    strings.Builder
+		width  int
+	)
+	flush := func() {
+		if cur.Len() > 0 {
+			tokens = append(tokens, token{text: cur.String(), width: width})
+			cur.Reset()
+			width = 0
+		}
+	}
+	for i := 0; i < len(s); {
+		if loc := ansiEscape.FindStringIndex(s[i:]); loc != nil && loc[0] == 0 {
+			cur.WriteString(s[i : i+loc[1]])
+			i += loc[1]
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		switch {
+		case r == ' ' || r == '\t' || r == '\n':
+			flush()
+			tokens = append(tokens, token{text: " ", width: 1, space: true})
+		case runeWidth(r) == 2:
+			flush()
+			cur.WriteRune(r)
+			width = 2
+			flush()
+		default:
+			cur.WriteRune(r)
+			width++
+		}
+	}
+	flush()
+	return tokens
+}
+
+// Width 文本在终端中的显示宽度
+func Width(s string) int {
+	width := 0
+	for _, r := range ansiEscape.ReplaceAllString(s, "") {
+		width += runeWidth(r)
+	}
+	return width
+}
+
+// runeWidth 字符的显示宽度，中日韩文字和全角符号占两列
+func runeWidth(r rune) int {
+	switch {
+	case r >= 0x1100 && r <= 0x115F,
+		r >= 0x2E80 && r <= 0xA4CF,
+		r >= 0xAC00 && r <= 0xD7A3,
+		r >= 0xF900 && r <= 0xFAFF,
+		r >= 0xFE30 && r <= 0xFE4F,
+		r >= 0xFF00 && r <= 0xFF60,
+		r >= 0xFFE0 && r <= 0xFFE6,
+		r >= 0x1F300 && r <= 0x1FAFF,
+		r >= 0x20000 && r <= 0x3FFFD:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// Truncate 将不含转义序列的文本截断到指定显示宽度，截断时以 … 结尾
+func Truncate(s string, width int) string {
+	if Width(s) <= width {
+		return s
+	}
+	if width <= 0 {
+		return ""
+	}
+	var b strings.Builder
+	w := 0
+	for _, r := range s {
+		rw := runeWidth(r)
+		if w+rw > width-1 {
+			break
+		}
+		b.WriteRune(r)
+		w += rw
+	}
+	return b.String() + "…"
+}
+
+// Pad 在文本后补充空格到指定显示宽度
+func Pad(s string, width int) string {
+	if w := Width(s); w < width {
+		return s + strings.Repeat(" ", width-w)
+	}
+	return s
+}
@@ -0,0 +1,27 @@
+// Package text 提供通知、评论等场景共用的文本处理
+package text
+
+import "unicode/utf8"
+
+// TruncateMark 截断后追加的提示
+const TruncateMark = "\n\n……（内容过长已截断）"
+
+// Truncate 按字节数截断文本并追加截断提示，结果不超过 limit 字节，不会截断多字节字符；
+// limit 小于截断提示的长度时只截断，不追加提示
+func Truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	if limit < len(TruncateMark) {
+		return s[:runeStart(s, max(limit, 0))]
+	}
+	return s[:runeStart(s, limit-len(TruncateMark))] + TruncateMark
+}
+
+// runeStart 返回不大于 i 的最近的字符起始位置
+func runeStart(s string, i int) int {
+	for i > 0 && !utf8.RuneStart(s[i]) {
+		i--
+	}
+	return i
+}
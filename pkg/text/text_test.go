@@ -0,0 +1,21 @@
+package text
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncate(t *testing.T) {
+	s := strings.Repeat("评审", 100)
+	got := Truncate(s, 100)
+	assert.LessOrEqual(t, len(got), 100)
+	assert.True(t, strings.HasSuffix(got, TruncateMark))
+	assert.Equal(t, "abc", Truncate("abc", 100))
+
+	// 放不下截断提示时只截断
+	assert.Equal(t, "评", Truncate(s, 5))
+	assert.Equal(t, "", Truncate(s, 2))
+	assert.Equal(t, "", Truncate(s, -1))
+}
@@ -0,0 +1,412 @@
+// Package triage 交互式处理评审问题：查看问题对应的变更块，标记处理结果并应用修复
+package triage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/icatw/cr-tool/pkg/diff"
+	"github.com/icatw/cr-tool/pkg/history"
+	"github.com/icatw/cr-tool/pkg/review"
+	"github.com/icatw/cr-tool/pkg/term"
+)
+
+// Action 按键处理后需要调用方执行的操作
+type Action int
+
+const (
+	None Action = iota
+	// Quit 退出
+	Quit
+	// Edit 在编辑器中打开当前问题
+	Edit
+	// Changed 处理决定有变化，需要保存评审记录
+	Changed
+)
+
+// Help 按键说明
+const Help = "↑↓/jk 选择  a 接受  f 误报  w 不修复  u 撤销  p 应用修复  e 编辑  q 退出"
+
+// statusIcons 处理结果在列表中的标记
+var statusIcons = map[history.Status]string{
+	"":                          "[ ]",
+	history.StatusAccepted:      "[✓]",
+	history.StatusFalsePositive: "[✗]",
+	history.StatusWontFix:       "[-]",
+}
+
+// Session 一次交互式处理
+type Session struct {
+	Record *history.Record
+	// Root 仓库根目录，问题中的文件路径相对于该目录
+	Root string
+	// Color 是否输出 ANSI 颜色
+	Color bool
+	// Message 状态栏消息
+	Message string
+
+	files  []*diff.File
+	cursor int
+	offset int
+}
+
+// New 创建交互式处理
+func New(record *history.Record, root string) *Session {
+	return &Session{
+		Record: record,
+		Root:   root,
+		Color:  os.Getenv("NO_COLOR") == "",
+		files:  diff.Parse(record.Diff),
+	}
+}
+
+// Current 返回当前选中的问题
+func (s *Session) Current() (review.Finding, bool) {
+	if s.cursor < 0 || s.cursor >= len(s.Record.Findings) {
+		return review.Finding{}, false
+	}
+	return s.Record.Findings[s.cursor], true
+}
+
+// Path 返回问题对应的本地文件路径
+func (s *Session) Path(f review.Finding) string {
+	return filepath.Join(s.Root, filepath.FromSlash(f.File))
+}
+
+// Handle 处理按键，key 为单个字符或 up、down、home、end、pgup、pgdown、esc、ctrl-c
+func (s *Session) Handle(key string) Action {
+	s.Message = ""
+	n := len(s.Record.Findings)
+	switch key {
+	case "q", "esc", "ctrl-c":
+		return Quit
+	case "up", "k":
+		s.move(-1)
+	case "down", "j":
+		s.move(1)
+	case "pgup":
+		s.move(-10)
+	case "pgdown":
+		s.move(10)
+	case "home", "g":
+		s.cursor = 0
+	case "end", "G":
+		s.cursor = n - 1
+	case "a":
+		return s.decide(history.StatusAccepted)
+	case "f":
+		return s.decide(history.StatusFalsePositive)
+	case "w":
+		return s.decide(history.StatusWontFix)
+	case "u":
+		return s.decide("")
+	case "e":
+		if f, ok := s.Current(); ok && f.File != "" {
+			return Edit
+		}
+		s.Message = "该问题没有对应的文件"
+	case "p":
+		return s.apply()
+	}
+	return None
+}
+
+// move 移动光标
+func (s *Session) move(delta int) {
+	s.cursor += delta
+	if s.cursor >= len(s.Record.Findings) {
+		s.cursor = len(s.Record.Findings) - 1
+	}
+	if s.cursor < 0 {
+		s.cursor = 0
+	}
+}
+
+// decide 记录当前问题的处理决定并移动到下一个问题
+func (s *Session) decide(status history.Status) Action {
+	f, ok := s.Current()
+	if !ok {
+		return None
+	}
+	applied := false
+	if d, ok := s.Record.Decision(f); ok {
+		applied = d.Applied
+	}
+	s.Record.Decide(f, status, applied && status != "")
+	if status != "" {
+		s.move(1)
+	}
+	return Changed
+}
+
+// apply 将当前问题的修复应用到工作区，成功后标记为接受
+func (s *Session) apply() Action {
+	f, ok := s.Current()
+	if !ok {
+		return None
+	}
+	if d, ok := s.Record.Decision(f); ok && d.Applied {
+		s.Message = "修复已应用过"
+		return None
+	}
+	if err := ApplyFix(s.Path(f), f, s.file(f)); err != nil {
+		s.Message = "应用修复失败: " + err.Error()
+		return None
+	}
+	s.Record.Decide(f, history.StatusAccepted, true)
+	s.Message = fmt.Sprintf("已修改 %s", f.Location())
+	return Changed
+}
+
+// file 返回问题所在文件的 diff
+func (s *Session) file(f review.Finding) *diff.File {
+	for _, file := range s.files {
+		if file.Name() == f.File {
+			return file
+		}
+	}
+	return nil
+}
+
+// Hunk 返回包含问题所在行的变更块
+func (s *Session) Hunk(f review.Finding) *diff.Hunk {
+	file := s.file(f)
+	if file == nil || len(file.Hunks) == 0 {
+		return nil
+	}
+	for _, h := range file.Hunks {
+		if f.Line >= h.NewStart && f.Line < h.NewStart+h.NewLines {
+			return h
+		}
+	}
+	if f.Line <= 0 {
+		return file.Hunks[0]
+	}
+	return nil
+}
+
+// ApplyFix 用问题中的修复代码替换文件中 Line 到 EndLine 的整行。
+// file 不为空时先核对文件内容与评审时的 diff 是否一致，避免覆盖已经变化的代码
+func ApplyFix(path string, f review.Finding, file *diff.File) error {
+	if f.Fix == "" {
+		return errors.New("该问题没有可应用的修复")
+	}
+	if f.Line <= 0 {
+		return errors.New("该问题没有行号")
+	}
+	end := f.EndLine
+	if end < f.Line {
+		end = f.Line
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取文件失败: %w", err)
+	}
+	content := string(data)
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if end > len(lines) {
+		return fmt.Errorf("文件只有 %d 行", len(lines))
+	}
+	if file != nil {
+		if err := file.CheckLines(content, f.Line, end); err != nil {
+			return err
+		}
+	}
+
+	fix := f.Fix
+	if !strings.HasSuffix(fix, "\n") {
+		fix += "\n"
+	}
+	var b strings.Builder
+	for _, l := range lines[:f.Line-1] {
+		b.WriteString(l)
+	}
+	b.WriteString(fix)
+	for _, l := range lines[end:] {
+		b.WriteString(l)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(b.String()), info.Mode().Perm())
+}
+
+// Render 渲染完整的界面，宽度足够时问题列表和详情左右排列，否则上下排列
+func (s *Session) Render(width, height int) string {
+	if height < 8 {
+		height = 8
+	}
+	bodyHeight := height - 3
+
+	var left, right []string
+	listWidth := width
+	detailWidth := width
+	if width >= 100 {
+		listWidth = width * 2 / 5
+		if listWidth > 60 {
+			listWidth = 60
+		}
+		detailWidth = width - listWidth - 3
+		left = s.list(listWidth, bodyHeight)
+		right = s.detail(detailWidth)
+	} else {
+		listHeight := bodyHeight / 3
+		if listHeight < 3 {
+			listHeight = 3
+		}
+		left = s.list(listWidth, listHeight)
+		left = append(left, s.paint(strings.Repeat("─", width), "2"))
+		left = append(left, s.detail(detailWidth)...)
+	}
+
+	var b strings.Builder
+	b.WriteString(s.header(width) + "\n")
+	for i := 0; i < bodyHeight; i++ {
+		var line string
+		if i < len(left) {
+			line = left[i]
+		}
+		if right != nil {
+			line = term.Pad(line, listWidth) + s.paint(" │ ", "2")
+			if i < len(right) {
+				line += right[i]
+			}
+		}
+		b.WriteString(line + "\n")
+	}
+	status := s.Message
+	if status == "" {
+		status = Help
+	}
+	b.WriteString(s.paint(term.Truncate(status, width), "2"))
+	return b.String()
+}
+
+// header 标题栏：评审 ID、分支和处理进度
+func (s *Session) header(width int) string {
+	done := 0
+	for _, f := range s.Record.Findings {
+		if _, ok := s.Record.Decision(f); ok {
+			done++
+		}
+	}
+	title := fmt.Sprintf(" cr triage · 评审 %s", s.Record.ID)
+	if branch := s.Record.Branch(); branch != "" {
+		title += " · " + branch
+	}
+	title += fmt.Sprintf(" · 已处理 %d/%d", done, len(s.Record.Findings))
+	return s.paint(term.Pad(term.Truncate(title, width), width), "7")
+}
+
+// list 问题列表，保证光标所在行可见
+func (s *Session) list(width, height int) []string {
+	if len(s.Record.Findings) == 0 {
+		return []string{"没有需要处理的问题"}
+	}
+	if s.cursor < s.offset {
+		s.offset = s.cursor
+	}
+	if s.cursor >= s.offset+height {
+		s.offset = s.cursor - height + 1
+	}
+
+	var lines []string
+	for i := s.offset; i < len(s.Record.Findings) && i < s.offset+height; i++ {
+		f := s.Record.Findings[i]
+		d, _ := s.Record.Decision(f)
+		marker := "  "
+		if i == s.cursor {
+			marker = "> "
+		}
+		line := term.Truncate(fmt.Sprintf("%s%s %s %s %s", marker, statusIcons[d.Status], f.Severity, f.Location(), f.Title), width)
+		if i == s.cursor {
+			line = s.paint(term.Pad(line, width), "1", "7")
+		} else if d.Status != "" {
+			line = s.paint(line, "2")
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// detail 当前问题的详情和对应的变更块
+func (s *Session) detail(width int) []string {
+	f, ok := s.Current()
+	if !ok {
+		return nil
+	}
+	var lines []string
+	add := func(text, first, rest string) {
+		lines = append(lines, strings.Split(term.Wrap(text, width, first, rest), "\n")...)
+	}
+
+	add(s.paint(f.Title, "1"), "", "")
+	meta := fmt.Sprintf("%s · %s", f.Severity, f.Location())
+	if f.Category != "" {
+		meta += " · " + f.Category
+	}
+	if d, ok := s.Record.Decision(f); ok {
+		meta += " · " + d.Status.Label()
+		if d.Applied {
+			meta += "（已应用修复）"
+		}
+	}
+	add(s.paint(meta, "2"), "", "")
+	if f.Detail != "" {
+		lines = append(lines, "")
+		add(f.Detail, "", "")
+	}
+	if f.Suggestion != "" {
+		add(s.paint("建议：", "32")+f.Suggestion, "", "")
+	}
+	if f.Fix != "" {
+		lines = append(lines, "", s.paint("修复（p 应用）：", "32"))
+		for _, l := range strings.Split(strings.TrimRight(f.Fix, "\n"), "\n") {
+			lines = append(lines, s.paint(term.Truncate("  "+strings.ReplaceAll(l, "\t", "    "), width), "36"))
+		}
+	}
+
+	if h := s.Hunk(f); h != nil {
+		lines = append(lines, "", s.paint(term.Truncate(fmt.Sprintf("@@ -%d,%d +%d,%d @@ %s", h.OldStart, h.OldLines, h.NewStart, h.NewLines, h.Section), width), "36"))
+		end := f.EndLine
+		if end < f.Line {
+			end = f.Line
+		}
+		for _, l := range h.Lines {
+			prefix, color := " ", ""
+			number := fmt.Sprintf("%4d", l.NewLine)
+			switch l.Kind {
+			case diff.Added:
+				prefix, color = "+", "32"
+			case diff.Deleted:
+				prefix, color, number = "-", "31", "    "
+			}
+			text := term.Truncate(number+" "+prefix+strings.ReplaceAll(l.Content, "\t", "    "), width)
+			if l.Kind != diff.Deleted && l.NewLine >= f.Line && l.NewLine <= end {
+				// 高亮问题所在的行
+				text = s.paint(text, "1", "4")
+			} else if color != "" {
+				text = s.paint(text, color)
+			}
+			lines = append(lines, text)
+		}
+	}
+	return lines
+}
+
+// paint 为文本添加 ANSI 样式，关闭颜色时原样返回
+func (s *Session) paint(text string, codes ...string) string {
+	if !s.Color || text == "" {
+		return text
+	}
+	return "\x1b[" + strings.Join(codes, ";") + "m" + text + "\x1b[0m"
+}
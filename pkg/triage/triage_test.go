@@ -0,0 +1,102 @@
+package triage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/icatw/cr-tool/pkg/history"
+	"github.com/icatw/cr-tool/pkg/review"
+	"github.com/icatw/cr-tool/pkg/term"
+	"github.com/stretchr/testify/assert"
+)
+
+const testDiff = `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,4 @@
+ package main
+ 
+-func main() {}
++func main() {
++	run()
+`
+
+func newSession(t *testing.T) *Session {
+	root := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n\nfunc main() {\n\trun()\n}\n"), 0644))
+	record := history.NewRecord(&review.ReviewHistory{
+		ID: "abcd1234",
+		Findings: []review.Finding{
+			{File: "main.go", Line: 4, Severity: review.SeverityHigh, Title: "未处理错误", Detail: "run 的错误被忽略", Fix: "\tif err := run(); err != nil {\n\t\tpanic(err)\n\t}"},
+			{File: "main.go", Line: 3, Severity: review.SeverityLow, Title: "缺少注释"},
+		},
+	}, testDiff)
+	s := New(record, root)
+	s.Color = false
+	return s
+}
+
+func TestHandle(t *testing.T) {
+	s := newSession(t)
+
+	assert.Equal(t, Changed, s.Handle("f"))
+	d, ok := s.Record.Decision(s.Record.Findings[0])
+	assert.True(t, ok)
+	assert.Equal(t, history.StatusFalsePositive, d.Status)
+	f, _ := s.Current()
+	assert.Equal(t, "缺少注释", f.Title, "标记后移动到下一个问题")
+
+	assert.Equal(t, None, s.Handle("down"), "已经是最后一个问题")
+	assert.Equal(t, Changed, s.Handle("w"))
+	assert.Equal(t, Changed, s.Handle("u"))
+	_, ok = s.Record.Decision(s.Record.Findings[1])
+	assert.False(t, ok)
+
+	assert.Equal(t, None, s.Handle("p"))
+	assert.Contains(t, s.Message, "没有可应用的修复")
+
+	assert.Equal(t, None, s.Handle("k"))
+	assert.Equal(t, Edit, s.Handle("e"))
+	assert.Equal(t, Quit, s.Handle("q"))
+}
+
+func TestApply(t *testing.T) {
+	s := newSession(t)
+	assert.Equal(t, Changed, s.Handle("p"))
+	data, err := os.ReadFile(s.Path(s.Record.Findings[0]))
+	assert.NoError(t, err)
+	assert.Equal(t, "package main\n\nfunc main() {\n\tif err := run(); err != nil {\n\t\tpanic(err)\n\t}\n}\n", string(data))
+	d, _ := s.Record.Decision(s.Record.Findings[0])
+	assert.Equal(t, history.StatusAccepted, d.Status)
+	assert.True(t, d.Applied)
+
+	assert.Equal(t, None, s.Handle("p"))
+	assert.Equal(t, "修复已应用过", s.Message)
+
+	// 文件与评审时不一致时拒绝修改
+	f := s.Record.Findings[0]
+	err = ApplyFix(s.Path(f), f, s.file(f))
+	assert.ErrorContains(t, err, "与评审时不一致")
+}
+
+func TestRender(t *testing.T) {
+	s := newSession(t)
+	s.Handle("a")
+
+	for _, width := range []int{120, 70} {
+		screen := s.Render(width, 24)
+		lines := strings.Split(screen, "\n")
+		assert.Len(t, lines, 23)
+		for _, line := range lines {
+			assert.LessOrEqual(t, term.Width(line), width, line)
+		}
+		assert.Contains(t, lines[0], "评审 abcd1234 · 已处理 1/2")
+		assert.Contains(t, screen, "[✓] 严重 main.go:4 未处理错误")
+		assert.Contains(t, screen, "> [ ] 低 main.go:3 缺少注释")
+		assert.Contains(t, screen, "@@ -1,3 +1,4 @@")
+		assert.Contains(t, screen, "   3 +func main() {")
+		assert.Contains(t, lines[len(lines)-1], "a 接受")
+	}
+}